@@ -0,0 +1,209 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/log"
+	"google.golang.org/appengine/user"
+)
+
+const profileKind = "Profile"
+
+const (
+	UnitsMetric  = "metric"
+	UnitsEnglish = "english"
+)
+
+var errNotSignedIn = errors.New("Sign in to save a profile")
+
+// Per user preferences, stored under the same user id as the favorites
+type Profile struct {
+	Email    string
+	Units    string
+	TimeZone string
+	Created  time.Time
+}
+
+// The signed in state along with the profile, or the link to sign in with
+type Me struct {
+	SignedIn  bool
+	Profile   *Profile `json:",omitempty"`
+	LoginURL  string   `json:",omitempty"`
+	LogoutURL string   `json:",omitempty"`
+}
+
+type ProfileRequest struct {
+	Units    string
+	TimeZone string
+}
+
+func meHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	currentUser := user.Current(ctx)
+	if currentUser == nil {
+		writeDataResponse(w, r, nil, &Me{LoginURL: "/login"})
+		return
+	}
+
+	profile, profileErr := fetchProfile(ctx, currentUser)
+	if profileErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, profileErr)
+		return
+	}
+
+	writeDataResponse(w, r, nil, &Me{
+		SignedIn:  true,
+		Profile:   profile,
+		LogoutURL: "/logout",
+	})
+}
+
+func updateMeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	currentUser := user.Current(ctx)
+	if currentUser == nil {
+		writeErrorResponse(w, r, http.StatusUnauthorized, errNotSignedIn)
+		return
+	}
+
+	request := ProfileRequest{}
+	if decodeErr := json.NewDecoder(r.Body).Decode(&request); decodeErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("Invalid request body: "+decodeErr.Error()))
+		return
+	}
+	if request.Units != "" && request.Units != UnitsMetric && request.Units != UnitsEnglish {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("Units must be metric or english"))
+		return
+	}
+	if _, locationErr := time.LoadLocation(request.TimeZone); request.TimeZone != "" && locationErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("Unknown time zone "+request.TimeZone))
+		return
+	}
+
+	profile, profileErr := fetchProfile(ctx, currentUser)
+	if profileErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, profileErr)
+		return
+	}
+	if request.Units != "" {
+		profile.Units = request.Units
+	}
+	if request.TimeZone != "" {
+		profile.TimeZone = request.TimeZone
+	}
+
+	key := datastore.NewKey(ctx, profileKind, googleUserID(currentUser), 0, nil)
+	if _, putErr := datastore.Put(ctx, key, profile); putErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, putErr)
+		return
+	}
+
+	writeDataResponse(w, r, nil, &Me{
+		SignedIn:  true,
+		Profile:   profile,
+		LogoutURL: "/logout",
+	})
+}
+
+func loginHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	loginURL, loginErr := user.LoginURL(ctx, "/login/done")
+	if loginErr != nil {
+		http.Error(w, loginErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, loginURL, http.StatusFound)
+}
+
+// Google sends the user back here after signing in. Anything they saved
+// before signing in is moved over to their account.
+func loginDoneHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	if currentUser := user.Current(ctx); currentUser != nil {
+		if mergeErr := mergeAnonymousFavorites(ctx, w, r, currentUser); mergeErr != nil {
+			log.Warningf(ctx, "Could not merge favorites for %s: %v", currentUser.Email, mergeErr)
+		}
+	}
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+func logoutHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	logoutURL, logoutErr := user.LogoutURL(ctx, "/")
+	if logoutErr != nil {
+		http.Error(w, logoutErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, logoutURL, http.StatusFound)
+}
+
+// Returns the user's profile, or a new one with the defaults if they have not
+// saved one yet
+func fetchProfile(ctx context.Context, currentUser *user.User) (*Profile, error) {
+	profile := &Profile{}
+	key := datastore.NewKey(ctx, profileKind, googleUserID(currentUser), 0, nil)
+	getErr := datastore.Get(ctx, key, profile)
+	if getErr == datastore.ErrNoSuchEntity {
+		return &Profile{
+			Email:    currentUser.Email,
+			Units:    UnitsMetric,
+			TimeZone: "UTC",
+			Created:  time.Now(),
+		}, nil
+	} else if getErr != nil {
+		return nil, getErr
+	}
+	return profile, nil
+}
+
+func mergeAnonymousFavorites(ctx context.Context, w http.ResponseWriter, r *http.Request, currentUser *user.User) error {
+	if _, cookieErr := r.Cookie(userCookieName); cookieErr != nil {
+		return nil
+	}
+	anonymousID, anonymousErr := userIDFromCookie(ctx, w, r)
+	if anonymousErr != nil {
+		return anonymousErr
+	}
+
+	anonymousKey := datastore.NewKey(ctx, favoritesKind, anonymousID, 0, nil)
+	anonymous := &Favorites{}
+	if getErr := datastore.Get(ctx, anonymousKey, anonymous); getErr == datastore.ErrNoSuchEntity {
+		return nil
+	} else if getErr != nil {
+		return getErr
+	}
+
+	_, updateErr := updateRequestFavorites(ctx, w, r, func(favorites *Favorites) error {
+		for _, stationID := range anonymous.Stations {
+			if !containsString(favorites.Stations, stationID) && len(favorites.Stations) < maxFavorites {
+				favorites.Stations = append(favorites.Stations, stationID)
+			}
+		}
+		return nil
+	})
+	if updateErr != nil {
+		return updateErr
+	}
+
+	return datastore.Delete(ctx, anonymousKey)
+}
+
+func containsString(values []string, value string) bool {
+	for _, existing := range values {
+		if existing == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/log"
+)
+
+const alertKind = "Alert"
+
+// Every alert is checked against the latest observation of its station each
+// time the cron task runs
+const maxAlerts = 10
+
+var errTooManyAlerts = errors.New("Only 10 alerts can be set up")
+
+// Notifies the user when a station's conditions cross the thresholds. An
+// alert fires once when the conditions cross and again only after they have
+// dropped back below. Heights are in meters.
+type Alert struct {
+	ID             int64  `datastore:"-"`
+	UserID         string `json:"-"`
+	StationID      string
+	MinWaveHeight  float64
+	MinPeriod      float64
+	DiscordWebhook string `datastore:",noindex"`
+	Created        time.Time
+	Triggered      bool
+	LastTriggered  time.Time
+}
+
+func (self Alert) validate() error {
+	if self.StationID == "" {
+		return errors.New("A station id is required")
+	}
+	if self.MinWaveHeight <= 0 && self.MinPeriod <= 0 {
+		return errors.New("An alert needs a minimum wave height or period")
+	}
+	if self.DiscordWebhook == "" {
+		return errors.New("An alert needs somewhere to be delivered")
+	}
+	if !isDiscordWebhookURL(self.DiscordWebhook) {
+		return errors.New("The Discord webhook must be a discord.com webhook url")
+	}
+	return nil
+}
+
+// Whether the conditions are over every threshold the alert sets
+func (self Alert) isTriggeredBy(buoy *ClosestBuoy) bool {
+	summary := buoy.BuoyData.WaveSummary
+	if self.MinWaveHeight > 0 && (!isValidReading(summary.WaveHeight, missingHeightMarker) || summary.WaveHeight < self.MinWaveHeight) {
+		return false
+	}
+	if self.MinPeriod > 0 && (!isValidReading(summary.Period, missingPeriodMarker) || summary.Period < self.MinPeriod) {
+		return false
+	}
+	return true
+}
+
+func listAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	alerts, alertsErr := fetchRequestAlerts(ctx, w, r)
+	if alertsErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, alertsErr)
+		return
+	}
+
+	writeDataResponse(w, r, nil, &alerts)
+}
+
+func createAlertHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	alert := &Alert{}
+	if decodeErr := json.NewDecoder(r.Body).Decode(alert); decodeErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("Invalid request body: "+decodeErr.Error()))
+		return
+	}
+	alert.StationID = strings.ToUpper(strings.TrimSpace(alert.StationID))
+	if validateErr := alert.validate(); validateErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, validateErr)
+		return
+	}
+
+	alerts, alertsErr := fetchRequestAlerts(ctx, w, r)
+	if alertsErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, alertsErr)
+		return
+	}
+	if len(alerts) >= maxAlerts {
+		writeErrorResponse(w, r, http.StatusBadRequest, errTooManyAlerts)
+		return
+	}
+
+	userID, userIDErr := requestUserID(ctx, w, r)
+	if userIDErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, userIDErr)
+		return
+	}
+	alert.UserID = userID
+	alert.Created = time.Now()
+	alert.Triggered = false
+	alert.LastTriggered = time.Time{}
+
+	key, putErr := datastore.Put(ctx, datastore.NewIncompleteKey(ctx, alertKind, nil), alert)
+	if putErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, putErr)
+		return
+	}
+	alert.ID = key.IntID()
+
+	writeEnvelope(w, r, http.StatusCreated, newResponseEnvelope(r, nil, alert))
+}
+
+func deleteAlertHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	alertID, alertIDErr := strconv.ParseInt(mux.Vars(r)["alert"], 10, 64)
+	if alertIDErr != nil {
+		writeErrorResponse(w, r, http.StatusNotFound, errors.New("No alert was found with that id"))
+		return
+	}
+
+	userID, userIDErr := requestUserID(ctx, w, r)
+	if userIDErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, userIDErr)
+		return
+	}
+
+	key := datastore.NewKey(ctx, alertKind, "", alertID, nil)
+	alert := &Alert{}
+	if getErr := datastore.Get(ctx, key, alert); getErr == datastore.ErrNoSuchEntity || (getErr == nil && alert.UserID != userID) {
+		writeErrorResponse(w, r, http.StatusNotFound, errors.New("No alert was found with that id"))
+		return
+	} else if getErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, getErr)
+		return
+	}
+
+	if deleteErr := datastore.Delete(ctx, key); deleteErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, deleteErr)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func fetchRequestAlerts(ctx context.Context, w http.ResponseWriter, r *http.Request) ([]Alert, error) {
+	userID, userIDErr := requestUserID(ctx, w, r)
+	if userIDErr != nil {
+		return nil, userIDErr
+	}
+
+	alerts := []Alert{}
+	keys, alertsErr := datastore.NewQuery(alertKind).Filter("UserID =", userID).GetAll(ctx, &alerts)
+	if alertsErr != nil {
+		return nil, alertsErr
+	}
+	for index, key := range keys {
+		alerts[index].ID = key.IntID()
+	}
+	return alerts, nil
+}
+
+// Run by cron, checks every alert against the latest observation of its
+// station and delivers the ones that just crossed their thresholds
+func checkAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 60*time.Second)
+	client := newFetchClient(ctx)
+
+	alerts := []Alert{}
+	keys, alertsErr := datastore.NewQuery(alertKind).GetAll(ctx, &alerts)
+	if alertsErr != nil {
+		http.Error(w, alertsErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	stationIDs := []string{}
+	for _, alert := range alerts {
+		if !containsString(stationIDs, alert.StationID) {
+			stationIDs = append(stationIDs, alert.StationID)
+		}
+	}
+	buoys, _ := fetchLatestBuoys(ctx, client, stationIDs)
+	latest := map[string]*ClosestBuoy{}
+	for _, buoy := range buoys {
+		if buoy != nil {
+			latest[buoy.BuoyStationID] = buoy
+		}
+	}
+
+	baseURL := "https://" + appengine.DefaultVersionHostname(ctx)
+	changedKeys := []*datastore.Key{}
+	changed := []Alert{}
+	for index, alert := range alerts {
+		buoy, ok := latest[alert.StationID]
+		if !ok {
+			continue
+		}
+
+		triggered := alert.isTriggeredBy(buoy)
+		if triggered == alert.Triggered {
+			continue
+		}
+		if triggered {
+			if deliverErr := deliverAlert(ctx, client, alert, buoy, baseURL); deliverErr != nil {
+				// Left untriggered so the next run tries again
+				log.Errorf(ctx, "Could not deliver alert %d: %v", keys[index].IntID(), deliverErr)
+				continue
+			}
+			alert.LastTriggered = buoy.BuoyData.Date
+		}
+
+		alert.Triggered = triggered
+		changedKeys = append(changedKeys, keys[index])
+		changed = append(changed, alert)
+	}
+
+	if len(changed) > 0 {
+		if _, putErr := datastore.PutMulti(ctx, changedKeys, changed); putErr != nil {
+			http.Error(w, putErr.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	log.Infof(ctx, "Checked %d alerts, %d changed", len(alerts), len(changed))
+}
+
+// Sends the alert everywhere it is set up to go
+func deliverAlert(ctx context.Context, client *http.Client, alert Alert, buoy *ClosestBuoy, baseURL string) error {
+	if alert.DiscordWebhook != "" {
+		return deliverDiscordAlert(ctx, client, alert.DiscordWebhook, buoy, baseURL)
+	}
+	return nil
+}
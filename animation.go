@@ -0,0 +1,112 @@
+package buoyfinder
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+const defaultAnimationHours = 12
+const maxAnimationHours = 24
+
+// Frame delays in hundredths of a second. The last frame holds longer so the
+// loop has a clear end.
+const animationFrameDelay = 50
+const animationLastFrameDelay = 200
+
+// Animates the hourly spectra over the last few hours so a swell can be seen
+// arriving and shifting period. ?chart=directional animates the directional
+// spectra instead of the period distribution.
+func animatedSpectraChartHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+
+	hours := parseChartSpan(r, "hours", defaultAnimationHours, maxAnimationHours)
+
+	chartOptions := spectraDistributionChartOptions
+	chart := r.URL.Query().Get("chart")
+	if chart == "directional" {
+		chartOptions = directionalSpectraChartOptions
+	} else {
+		chart = "distribution"
+	}
+
+	smoothing := parseSpectraSmoothing(r)
+	spectraOptions := parseSpectraChartOptions(r)
+
+	cacheKey := "animated:" + stationID + ":" + chart + ":" + strconv.Itoa(hours) + ":" + parsePalette(r) + ":" + strconv.Itoa(smoothing) + ":" + strconv.FormatBool(spectraOptions.LogScale) + ":" + strconv.FormatBool(spectraOptions.Normalize) + ".gif"
+	writeCachedImage(ctx, w, r, cacheKey, "image/gif", func() ([]byte, int, error) {
+		since := time.Now().Add(-time.Duration(hours) * time.Hour)
+		buoy := &surfnerd.Buoy{StationID: stationID}
+		if fetchErr := fetchDetailedWaveBuoyData(client, buoy, historyCountSince(since), smoothing); fetchErr != nil {
+			return nil, waveFetchErrorStatus(fetchErr, http.StatusBadGateway), fetchErr
+		}
+
+		observations := []surfnerd.BuoyDataItem{}
+		for index := len(buoy.BuoyData) - 1; index >= 0; index-- {
+			if !buoy.BuoyData[index].Date.Before(since) {
+				observations = append(observations, buoy.BuoyData[index])
+			}
+		}
+		observations = downsampleObservations(observations, time.Hour)
+		if len(observations) == 0 {
+			return nil, http.StatusNotFound, errors.New("Station " + stationID + " has no recent wave spectra")
+		}
+
+		// Every frame shares the largest peak so the frames can be compared
+		for _, item := range observations {
+			if peak := peakEnergy(item.WaveSpectra.Energies); peak > spectraOptions.PeakEnergy {
+				spectraOptions.PeakEnergy = peak
+			}
+		}
+
+		options := make([]string, len(observations))
+		for index, item := range observations {
+			options[index] = chartOptions(stationID, item, spectraOptions)
+		}
+
+		frames, exportErr := exportChartImages(ctx, client, options)
+		if exportErr != nil {
+			return nil, http.StatusBadGateway, exportErr
+		}
+
+		animation := bytes.Buffer{}
+		if encodeErr := gif.EncodeAll(&animation, newAnimation(frames)); encodeErr != nil {
+			return nil, http.StatusInternalServerError, encodeErr
+		}
+		return animation.Bytes(), http.StatusOK, nil
+	})
+}
+
+func newAnimation(frames []image.Image) *gif.GIF {
+	animation := &gif.GIF{}
+	for index, frame := range frames {
+		paletted := image.NewPaletted(frame.Bounds(), palette.Plan9)
+		draw.FloydSteinberg.Draw(paletted, frame.Bounds(), frame, frame.Bounds().Min)
+
+		delay := animationFrameDelay
+		if index == len(frames)-1 {
+			delay = animationLastFrameDelay
+		}
+
+		animation.Image = append(animation.Image, paletted)
+		animation.Delay = append(animation.Delay, delay)
+	}
+	return animation
+}
@@ -0,0 +1,255 @@
+// Package archive reads the NDBC historical standard meteorological archives,
+// which hold a gzipped file of observations for every station and year.
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const historicalStandardDataURL = "https://www.ndbc.noaa.gov/data/historical/stdmet/"
+
+// NDBC only publishes a year once it is over, and has nothing before 1970
+const FirstYear = 1970
+
+var ErrNotArchived = errors.New("The station has no archive for that year")
+
+// A year of ten minute observations is a couple of megabytes compressed and
+// around ten uncompressed, so anything far past that is not an archive
+const (
+	maxCompressedSize   = 16 << 20
+	maxUncompressedSize = 64 << 20
+)
+
+var ErrTooLarge = errors.New("The archive is larger than expected")
+
+// NDBC posts a year's archive months into the next one, so a missing year is
+// only known to stay missing once the year after it is over too
+func IsSettled(year int) bool {
+	return year < time.Now().UTC().Year()-1
+}
+
+// One archived observation in metric units. Missing readings keep the NDBC
+// markers, the same ones the realtime data uses.
+type Observation struct {
+	StationID         string
+	Date              time.Time
+	WindDirection     float64
+	WindSpeed         float64
+	WindGust          float64
+	WaveHeight        float64
+	DominantPeriod    float64
+	AveragePeriod     float64
+	MeanWaveDirection float64
+	Pressure          float64
+	AirTemperature    float64
+	WaterTemperature  float64
+	DewPoint          float64
+}
+
+// A named reading, for writers that store each one as its own column
+type Field struct {
+	Name  string
+	Value float64
+	Valid bool
+}
+
+// The readings in archive column order
+func (self Observation) Fields() []Field {
+	field := func(name string, value, marker float64) Field {
+		return Field{Name: name, Value: value, Valid: value < marker}
+	}
+	return []Field{
+		field("wind_direction", self.WindDirection, 999),
+		field("wind_speed", self.WindSpeed, 99),
+		field("wind_gust", self.WindGust, 99),
+		field("wave_height", self.WaveHeight, 99),
+		field("dominant_period", self.DominantPeriod, 99),
+		field("average_period", self.AveragePeriod, 99),
+		field("mean_wave_direction", self.MeanWaveDirection, 999),
+		field("pressure", self.Pressure, 9999),
+		field("air_temperature", self.AirTemperature, 999),
+		field("water_temperature", self.WaterTemperature, 999),
+		field("dew_point", self.DewPoint, 999),
+	}
+}
+
+// A single year like 2015 or an inclusive range like 2010-2015
+func ParseYears(raw string) ([]int, error) {
+	bounds := strings.SplitN(raw, "-", 2)
+	first, firstErr := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	last, lastErr := first, firstErr
+	if len(bounds) == 2 {
+		last, lastErr = strconv.Atoi(strings.TrimSpace(bounds[1]))
+	}
+	if firstErr != nil || lastErr != nil || first < FirstYear || last < first || last >= time.Now().Year() {
+		return nil, errors.New("The years must be a year or a range like 2010-2015, from " + strconv.Itoa(FirstYear) + " through last year")
+	}
+
+	years := []int{}
+	for year := first; year <= last; year++ {
+		years = append(years, year)
+	}
+	return years, nil
+}
+
+// The names of the values in a record, in order
+func Columns() []string {
+	columns := []string{"station_id", "time"}
+	for _, field := range (Observation{}).Fields() {
+		columns = append(columns, field.Name)
+	}
+	return columns
+}
+
+// The observation keyed by the column names, with nil for missing readings
+func (self Observation) Record() map[string]interface{} {
+	record := map[string]interface{}{
+		"station_id": self.StationID,
+		"time":       self.Date.UTC().Format(time.RFC3339),
+	}
+	for _, field := range self.Fields() {
+		if field.Valid {
+			record[field.Name] = field.Value
+		} else {
+			record[field.Name] = nil
+		}
+	}
+	return record
+}
+
+func URL(stationID string, year int) string {
+	return historicalStandardDataURL + strings.ToLower(stationID) + "h" + strconv.Itoa(year) + ".txt.gz"
+}
+
+// Downloads and parses the station's archive for the year, oldest observation
+// first
+func Fetch(client *http.Client, stationID string, year int) ([]Observation, error) {
+	resp, fetchErr := client.Get(URL(stationID, year))
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotArchived
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("The archive could not be downloaded: " + resp.Status)
+	}
+
+	reader, gzipErr := gzip.NewReader(&limitedReader{resp.Body, maxCompressedSize})
+	if gzipErr != nil {
+		return nil, gzipErr
+	}
+	defer reader.Close()
+
+	return Parse(stationID, &limitedReader{reader, maxUncompressedSize})
+}
+
+// Fails with ErrTooLarge once the limit is read, where io.LimitReader would
+// end early and leave a partial archive looking complete
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (self *limitedReader) Read(p []byte) (int, error) {
+	if self.remaining <= 0 {
+		return 0, ErrTooLarge
+	}
+	if int64(len(p)) > self.remaining {
+		p = p[:self.remaining]
+	}
+	n, readErr := self.reader.Read(p)
+	self.remaining -= int64(n)
+	return n, readErr
+}
+
+// Reads an uncompressed archive. The columns have changed over the years, two
+// digit years until 1999, minutes since 2005, and a units line since 2007, so
+// they are found by the names in the header rather than by position.
+func Parse(stationID string, r io.Reader) ([]Observation, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		if scanErr := scanner.Err(); scanErr != nil {
+			return nil, scanErr
+		}
+		return nil, errors.New("The archive is empty")
+	}
+
+	columns := map[string]int{}
+	for index, name := range strings.Fields(strings.TrimPrefix(scanner.Text(), "#")) {
+		columns[name] = index
+	}
+	yearColumn, ok := columns["YYYY"]
+	if !ok {
+		yearColumn, ok = columns["YY"]
+	}
+	if !ok {
+		return nil, errors.New("The archive has no year column")
+	}
+	for _, name := range []string{"MM", "DD", "hh"} {
+		if _, ok := columns[name]; !ok {
+			return nil, errors.New("The archive has no " + name + " column")
+		}
+	}
+
+	observations := []Observation{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		values := strings.Fields(line)
+		if len(values) < len(columns) {
+			continue
+		}
+
+		integer := func(column int) int {
+			value, _ := strconv.Atoi(values[column])
+			return value
+		}
+		reading := func(marker float64, names ...string) float64 {
+			for _, name := range names {
+				if column, ok := columns[name]; ok {
+					if value, valueErr := strconv.ParseFloat(values[column], 64); valueErr == nil {
+						return value
+					}
+				}
+			}
+			return marker
+		}
+
+		year := integer(yearColumn)
+		if year < 100 {
+			year += 1900
+		}
+		minute := 0
+		if column, ok := columns["mm"]; ok {
+			minute = integer(column)
+		}
+
+		observations = append(observations, Observation{
+			StationID:         strings.ToUpper(stationID),
+			Date:              time.Date(year, time.Month(integer(columns["MM"])), integer(columns["DD"]), integer(columns["hh"]), minute, 0, 0, time.UTC),
+			WindDirection:     reading(999, "WDIR", "WD"),
+			WindSpeed:         reading(99, "WSPD"),
+			WindGust:          reading(99, "GST"),
+			WaveHeight:        reading(99, "WVHT"),
+			DominantPeriod:    reading(99, "DPD"),
+			AveragePeriod:     reading(99, "APD"),
+			MeanWaveDirection: reading(999, "MWD"),
+			Pressure:          reading(9999, "PRES", "BAR"),
+			AirTemperature:    reading(999, "ATMP"),
+			WaterTemperature:  reading(999, "WTMP"),
+			DewPoint:          reading(999, "DEWP"),
+		})
+	}
+	return observations, scanner.Err()
+}
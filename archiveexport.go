@@ -0,0 +1,170 @@
+package buoyfinder
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/buoyfinder/archive"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/log"
+)
+
+// A year of half hourly observations still downloads in well under the
+// request deadline
+const maxArchiveExportRange = 366 * 24 * time.Hour
+
+const defaultArchiveExportRange = 30 * 24 * time.Hour
+
+// Streams a zip of the station's archived observations with one file per
+// month. The zip is written as the months are read, so an error partway
+// through can only cut the download short.
+func exportArchiveHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 55*time.Second)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	} else if format != "csv" && format != "json" {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("The format must be csv or json"))
+		return
+	}
+
+	start, end, rangeErr := parseArchiveExportRange(r)
+	if rangeErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, rangeErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+stationID+".zip\"")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	zipWriter := zip.NewWriter(w)
+	for monthStart := start; monthStart.Before(end); {
+		monthEnd := time.Date(monthStart.Year(), monthStart.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		if monthEnd.After(end) {
+			monthEnd = end
+		}
+
+		file, createErr := zipWriter.Create(stationID + "-" + monthStart.Format("2006-01") + "." + format)
+		if createErr != nil {
+			log.Errorf(ctx, "Could not add %s to the export: %v", monthStart.Format("2006-01"), createErr)
+			return
+		}
+		if writeErr := writeArchivedObservations(ctx, file, format, stationID, monthStart, monthEnd); writeErr != nil {
+			log.Errorf(ctx, "Could not export %s for %s: %v", stationID, monthStart.Format("2006-01"), writeErr)
+			return
+		}
+
+		monthStart = monthEnd
+	}
+	zipWriter.Close()
+}
+
+// Reads the start and end query parameters as unix epochs. Unlike the realtime
+// exports the range can reach back as far as the archive goes.
+func parseArchiveExportRange(r *http.Request) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+
+	end := time.Now().UTC()
+	if rawEnd := query.Get("end"); rawEnd != "" {
+		epoch, epochErr := strconv.ParseInt(rawEnd, 10, 64)
+		if epochErr != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid end date")
+		}
+		end = time.Unix(epoch, 0).UTC()
+	}
+
+	start := end.Add(-defaultArchiveExportRange)
+	if rawStart := query.Get("start"); rawStart != "" {
+		epoch, epochErr := strconv.ParseInt(rawStart, 10, 64)
+		if epochErr != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid start date")
+		}
+		start = time.Unix(epoch, 0).UTC()
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, errors.New("The start date must be before the end date")
+	}
+	if end.Sub(start) > maxArchiveExportRange {
+		return time.Time{}, time.Time{}, errors.New("Only a year of data can be exported at a time")
+	}
+
+	return start, end, nil
+}
+
+// Writes the archived observations in the range as csv, or as a json array of
+// records, oldest first
+func writeArchivedObservations(ctx context.Context, w io.Writer, format, stationID string, start, end time.Time) error {
+	query := datastore.NewQuery(archivedObservationKind).
+		Filter("StationID =", stationID).
+		Filter("Date >=", start).
+		Filter("Date <", end).
+		Order("Date")
+
+	columns := archive.Columns()
+	csvWriter := csv.NewWriter(w)
+	if format == "csv" {
+		csvWriter.Write(columns)
+	} else {
+		io.WriteString(w, "[")
+	}
+
+	count := 0
+	iterator := query.Run(ctx)
+	for {
+		observation := archive.Observation{}
+		_, nextErr := iterator.Next(&observation)
+		if nextErr == datastore.Done {
+			break
+		} else if nextErr != nil {
+			return nextErr
+		}
+
+		record := observation.Record()
+		if format == "csv" {
+			row := make([]string, len(columns))
+			for index, column := range columns {
+				switch value := record[column].(type) {
+				case string:
+					row[index] = value
+				case float64:
+					row[index] = strconv.FormatFloat(value, 'f', -1, 64)
+				}
+			}
+			csvWriter.Write(row)
+		} else {
+			encoded, encodeErr := json.Marshal(record)
+			if encodeErr != nil {
+				return encodeErr
+			}
+			if count > 0 {
+				io.WriteString(w, ",")
+			}
+			w.Write(encoded)
+		}
+		count++
+	}
+
+	if format == "csv" {
+		csvWriter.Flush()
+		return csvWriter.Error()
+	}
+	_, writeErr := io.WriteString(w, "]")
+	return writeErr
+}
@@ -0,0 +1,108 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+const defaultAroundWindow = 3 * time.Hour
+const maxAroundWindow = 7 * 24 * time.Hour
+
+// Every observation within the window either side of the requested date,
+// oldest first, so an event can be followed as it built and faded. Dates
+// older than the realtime files come from the archive. The window is
+// encoded in seconds.
+type ObservationWindow struct {
+	StationID     string
+	RequestedDate time.Time
+	Window        time.Duration
+	Start         time.Time
+	End           time.Time
+	Observations  []surfnerd.BuoyDataItem
+}
+
+type observationWindowFields ObservationWindow
+
+func (self ObservationWindow) MarshalJSON() ([]byte, error) {
+	observations, observationsErr := qualityControlledJSONList(self.Observations)
+	if observationsErr != nil {
+		return nil, observationsErr
+	}
+
+	return json.Marshal(struct {
+		observationWindowFields
+		Window       int64
+		Observations []json.RawMessage
+	}{observationWindowFields(self), int64(self.Window / time.Second), observations})
+}
+
+func aroundTimeHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+
+	rawdate, dateErr := strconv.ParseInt(vars["epoch"], 10, 64)
+	if dateErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("Invalid date "+vars["epoch"]))
+		return
+	}
+	requestedDate := time.Unix(rawdate, 0)
+
+	window, windowErr := parseAroundWindow(r)
+	if windowErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, windowErr)
+		return
+	}
+
+	downsample, downsampleErr := parseDownsampleOptions(r)
+	if downsampleErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, downsampleErr)
+		return
+	}
+
+	start, end := requestedDate.Add(-window), requestedDate.Add(window)
+	inWindow, fetchErr := fetchStandardObservationRange(ctx, client, stationID, start, end)
+	if fetchErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, fetchErr)
+		return
+	}
+	if len(inWindow) == 0 {
+		writeErrorResponse(w, r, http.StatusNotFound, errors.New("Station "+stationID+" has no observations within "+window.String()+" of the requested time"))
+		return
+	}
+
+	writeDataResponse(w, r, client, ObservationWindow{
+		StationID:     stationID,
+		RequestedDate: requestedDate,
+		Window:        window,
+		Start:         start,
+		End:           end,
+		Observations:  downsample.apply(inWindow),
+	})
+}
+
+// Reads the ?window= either side of the requested time, like 3h or 90m
+func parseAroundWindow(r *http.Request) (time.Duration, error) {
+	rawWindow := r.URL.Query().Get("window")
+	if rawWindow == "" {
+		return defaultAroundWindow, nil
+	}
+
+	window, windowErr := time.ParseDuration(rawWindow)
+	if windowErr != nil || window <= 0 || window > maxAroundWindow {
+		return 0, errors.New("The window must be a duration like 3h, up to 7 days")
+	}
+	return window, nil
+}
@@ -0,0 +1,281 @@
+package buoyfinder
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+const astronomyDateLayout = "2006-01-02"
+
+const (
+	julianUnixEpoch = 2440587.5
+	julianJ2000     = 2451545.0
+	earthObliquity  = 23.4397 * math.Pi / 180.0
+)
+
+// The sun's altitude at each event, in degrees. Sunrise and sunset allow for
+// refraction and the size of the disc.
+const (
+	sunriseAltitude       = -0.833
+	civilTwilightAltitude = -6.0
+	moonHorizonAltitude   = 0.133 * math.Pi / 180.0
+)
+
+var moonPhaseNames = []string{"New Moon", "Waxing Crescent", "First Quarter", "Waxing Gibbous", "Full Moon", "Waning Gibbous", "Last Quarter", "Waning Crescent"}
+
+// The sun and moon for a day at a location. Times are left out when the event
+// does not happen that day, like sunset during the polar summer.
+type Astronomy struct {
+	Location surfnerd.Location
+	Date     string
+	// First light and last light, when the sun is 6 degrees below the horizon
+	CivilDawn        *time.Time `json:",omitempty"`
+	Sunrise          *time.Time `json:",omitempty"`
+	Sunset           *time.Time `json:",omitempty"`
+	CivilDusk        *time.Time `json:",omitempty"`
+	Moonrise         *time.Time `json:",omitempty"`
+	Moonset          *time.Time `json:",omitempty"`
+	MoonIllumination float64
+	// From 0 at the new moon through 0.5 at the full moon
+	MoonPhase     float64
+	MoonPhaseName string
+}
+
+func astronomyHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	latitude, latitudeErr := strconv.ParseFloat(vars["lat"], 64)
+	longitude, longitudeErr := strconv.ParseFloat(vars["lon"], 64)
+	if latitudeErr != nil || longitudeErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("The location must be a latitude and longitude"))
+		return
+	}
+
+	date, dateErr := time.Parse(astronomyDateLayout, vars["date"])
+	if dateErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("The date must look like 2006-01-02"))
+		return
+	}
+
+	writeDataResponse(w, r, nil, newAstronomy(surfnerd.NewLocationForLatLong(latitude, longitude), date))
+}
+
+func stationAstronomyHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+
+	date, dateErr := time.Parse(astronomyDateLayout, vars["date"])
+	if dateErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("The date must look like 2006-01-02"))
+		return
+	}
+
+	buoy, buoyErr := fetchBuoyWithID(ctx, client, stationID)
+	if buoyErr != nil {
+		writeErrorResponse(w, r, http.StatusNotFound, buoyErr)
+		return
+	} else if buoy.Location == nil {
+		writeErrorResponse(w, r, http.StatusNotFound, errors.New("Station "+stationID+" has no location"))
+		return
+	}
+
+	writeDataResponse(w, r, client, newAstronomy(*buoy.Location, date))
+}
+
+// The date is the calendar day at the location. Without time zones it starts
+// at the location's mean solar midnight, which is close enough for planning.
+func newAstronomy(location surfnerd.Location, date time.Time) Astronomy {
+	astronomy := Astronomy{Location: location, Date: date.Format(astronomyDateLayout)}
+
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC).Add(-time.Duration(location.Longitude / 15.0 * float64(time.Hour)))
+	astronomy.CivilDawn, astronomy.CivilDusk = sunTimes(location, midnight, civilTwilightAltitude)
+	astronomy.Sunrise, astronomy.Sunset = sunTimes(location, midnight, sunriseAltitude)
+	astronomy.Moonrise, astronomy.Moonset = moonTimes(location, midnight)
+
+	fraction, phase := moonIllumination(midnight.Add(12 * time.Hour))
+	astronomy.MoonIllumination = ToFixedPoint(fraction, 3)
+	astronomy.MoonPhase = ToFixedPoint(phase, 3)
+	astronomy.MoonPhaseName = moonPhaseNames[int(math.Floor(phase*8.0+0.5))%len(moonPhaseNames)]
+	return astronomy
+}
+
+func degreesToRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180.0
+}
+
+func radiansToDegrees(radians float64) float64 {
+	return radians * 180.0 / math.Pi
+}
+
+func toJulianDays(date time.Time) float64 {
+	return float64(date.UnixNano())/float64(24*time.Hour) + julianUnixEpoch - julianJ2000
+}
+
+func fromJulianDays(days float64) time.Time {
+	return time.Unix(0, int64((days+julianJ2000-julianUnixEpoch)*float64(24*time.Hour))).UTC()
+}
+
+func solarMeanAnomaly(days float64) float64 {
+	return degreesToRadians(357.5291 + 0.98560028*days)
+}
+
+func eclipticLongitude(meanAnomaly float64) float64 {
+	center := degreesToRadians(1.9148*math.Sin(meanAnomaly) + 0.02*math.Sin(2*meanAnomaly) + 0.0003*math.Sin(3*meanAnomaly))
+	perihelion := degreesToRadians(102.9372)
+	return meanAnomaly + center + perihelion + math.Pi
+}
+
+func rightAscension(longitude, latitude float64) float64 {
+	return math.Atan2(math.Sin(longitude)*math.Cos(earthObliquity)-math.Tan(latitude)*math.Sin(earthObliquity), math.Cos(longitude))
+}
+
+func declination(longitude, latitude float64) float64 {
+	return math.Asin(math.Sin(latitude)*math.Cos(earthObliquity) + math.Cos(latitude)*math.Sin(earthObliquity)*math.Sin(longitude))
+}
+
+// When the sun passes through the altitude on its way up and down, from the
+// sunrise equation. Either is nil when the sun never reaches the altitude.
+func sunTimes(location surfnerd.Location, midnight time.Time, altitude float64) (*time.Time, *time.Time) {
+	west := -location.Longitude
+	cycle := math.Floor(toJulianDays(midnight.Add(12*time.Hour)) - 0.0009 - west/360.0 + 0.5)
+	approxTransit := 0.0009 + west/360.0 + cycle
+	meanAnomaly := solarMeanAnomaly(approxTransit)
+	longitude := eclipticLongitude(meanAnomaly)
+	transit := approxTransit + 0.0053*math.Sin(meanAnomaly) - 0.0069*math.Sin(2*longitude)
+	sunDeclination := declination(longitude, 0)
+
+	latitude := degreesToRadians(location.Latitude)
+	cosHourAngle := (math.Sin(degreesToRadians(altitude)) - math.Sin(latitude)*math.Sin(sunDeclination)) / (math.Cos(latitude) * math.Cos(sunDeclination))
+	if cosHourAngle < -1 || cosHourAngle > 1 {
+		return nil, nil
+	}
+	hourAngle := radiansToDegrees(math.Acos(cosHourAngle)) / 360.0
+
+	rise := fromJulianDays(transit - hourAngle)
+	set := fromJulianDays(transit + hourAngle)
+	return &rise, &set
+}
+
+func moonCoordinates(days float64) (float64, float64, float64) {
+	meanLongitude := degreesToRadians(218.316 + 13.176396*days)
+	meanAnomaly := degreesToRadians(134.963 + 13.064993*days)
+	meanDistance := degreesToRadians(93.272 + 13.229350*days)
+
+	longitude := meanLongitude + degreesToRadians(6.289)*math.Sin(meanAnomaly)
+	latitude := degreesToRadians(5.128) * math.Sin(meanDistance)
+	distance := 385001.0 - 20905.0*math.Cos(meanAnomaly)
+	return rightAscension(longitude, latitude), declination(longitude, latitude), distance
+}
+
+// The moon's altitude above the horizon in radians, corrected for refraction
+func moonAltitude(location surfnerd.Location, date time.Time) float64 {
+	days := toJulianDays(date)
+	ascension, moonDeclination, _ := moonCoordinates(days)
+	siderealTime := degreesToRadians(280.16+360.9856235*days) + degreesToRadians(location.Longitude)
+	hourAngle := siderealTime - ascension
+	latitude := degreesToRadians(location.Latitude)
+
+	altitude := math.Asin(math.Sin(latitude)*math.Sin(moonDeclination) + math.Cos(latitude)*math.Cos(moonDeclination)*math.Cos(hourAngle))
+	refracted := math.Max(altitude, 0)
+	return altitude + 0.0002967/math.Tan(refracted+0.00312536/(refracted+0.08901179))
+}
+
+// Steps through the day two hours at a time, fitting a parabola to the moon's
+// altitude to find where it crosses the horizon
+func moonTimes(location surfnerd.Location, midnight time.Time) (*time.Time, *time.Time) {
+	altitudeAt := func(hours float64) float64 {
+		return moonAltitude(location, midnight.Add(time.Duration(hours*float64(time.Hour)))) - moonHorizonAltitude
+	}
+
+	var rise, set *float64
+	previous := altitudeAt(0)
+	for hour := 1.0; hour <= 24.0; hour += 2 {
+		middle := altitudeAt(hour)
+		next := altitudeAt(hour + 1)
+
+		a := (previous+next)/2 - middle
+		b := (next - previous) / 2
+		extremum := -b / (2 * a)
+		extremumAltitude := (a*extremum+b)*extremum + middle
+		discriminant := b*b - 4*a*middle
+
+		roots := 0
+		first, second := 0.0, 0.0
+		if discriminant >= 0 {
+			offset := math.Sqrt(discriminant) / (math.Abs(a) * 2)
+			first, second = extremum-offset, extremum+offset
+			if math.Abs(first) <= 1 {
+				roots++
+			}
+			if math.Abs(second) <= 1 {
+				roots++
+			}
+			if first < -1 {
+				first = second
+			}
+		}
+
+		crossing := func(x float64) *float64 {
+			value := hour + x
+			return &value
+		}
+		if roots == 1 {
+			if previous < 0 {
+				rise = crossing(first)
+			} else {
+				set = crossing(first)
+			}
+		} else if roots == 2 {
+			if extremumAltitude < 0 {
+				rise, set = crossing(second), crossing(first)
+			} else {
+				rise, set = crossing(first), crossing(second)
+			}
+		}
+
+		if rise != nil && set != nil {
+			break
+		}
+		previous = next
+	}
+
+	toTime := func(hours *float64) *time.Time {
+		if hours == nil {
+			return nil
+		}
+		date := midnight.Add(time.Duration(*hours * float64(time.Hour)))
+		return &date
+	}
+	return toTime(rise), toTime(set)
+}
+
+// The lit fraction of the moon and where it is in its cycle
+func moonIllumination(date time.Time) (float64, float64) {
+	days := toJulianDays(date)
+	sunLongitude := eclipticLongitude(solarMeanAnomaly(days))
+	sunAscension, sunDeclination := rightAscension(sunLongitude, 0), declination(sunLongitude, 0)
+	moonAscension, moonDeclination, moonDistance := moonCoordinates(days)
+	sunDistance := 149598000.0
+
+	elongation := math.Acos(math.Sin(sunDeclination)*math.Sin(moonDeclination) + math.Cos(sunDeclination)*math.Cos(moonDeclination)*math.Cos(sunAscension-moonAscension))
+	inclination := math.Atan2(sunDistance*math.Sin(elongation), moonDistance-sunDistance*math.Cos(elongation))
+	angle := math.Atan2(math.Cos(sunDeclination)*math.Sin(sunAscension-moonAscension), math.Sin(sunDeclination)*math.Cos(moonDeclination)-math.Cos(sunDeclination)*math.Sin(moonDeclination)*math.Cos(sunAscension-moonAscension))
+
+	sign := 1.0
+	if angle < 0 {
+		sign = -1.0
+	}
+	return (1 + math.Cos(inclination)) / 2, 0.5 + 0.5*inclination*sign/math.Pi
+}
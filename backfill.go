@@ -0,0 +1,177 @@
+package buoyfinder
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/mpiannucci/buoyfinder/archive"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/log"
+	"google.golang.org/appengine/taskqueue"
+)
+
+const archivedObservationKind = "ArchivedObservation"
+const backfillProgressKind = "BackfillProgress"
+
+// Datastore takes at most 500 entities in a single put
+const backfillBatchSize = 500
+
+const maxBackfillStations = 50
+
+// Puts the slice of entities backfillBatchSize at a time, since one put over
+// the limit fails whole
+func putMultiInBatches(ctx context.Context, keys []*datastore.Key, src interface{}) error {
+	entities := reflect.ValueOf(src)
+	for start := 0; start < len(keys); start += backfillBatchSize {
+		end := start + backfillBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		if _, putErr := datastore.PutMulti(ctx, keys[start:end], entities.Slice(start, end).Interface()); putErr != nil {
+			return putErr
+		}
+	}
+	return nil
+}
+
+// Leaves room under the ten minute task deadline to save progress and queue
+// the rest of the backfill
+const backfillTaskBudget = 5 * time.Minute
+
+// NDBC asks that its archives are not downloaded all at once
+const backfillFetchDelay = 2 * time.Second
+
+// How far a station and year has been loaded, so an interrupted backfill picks
+// up where it stopped
+type BackfillProgress struct {
+	StationID string
+	Year      int
+	Loaded    int
+	Done      bool
+	Updated   time.Time
+}
+
+func backfillProgressKey(ctx context.Context, stationID string, year int) *datastore.Key {
+	return datastore.NewKey(ctx, backfillProgressKind, stationID+":"+strconv.Itoa(year), 0, nil)
+}
+
+// Observations are keyed by station and time so loading one again overwrites
+// it rather than duplicating it
+func archivedObservationKey(ctx context.Context, observation archive.Observation) *datastore.Key {
+	return datastore.NewKey(ctx, archivedObservationKind, observation.StationID+":"+strconv.FormatInt(observation.Date.Unix(), 10), 0, nil)
+}
+
+// Loads the NDBC archives for ?stations= and ?years= into the Datastore. The
+// backfill runs until its budget is spent and then queues itself to carry on,
+// skipping whatever the saved progress says is already loaded.
+func backfillHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 9*time.Minute)
+	client := newFetchClient(ctx)
+
+	stationIDs, stationsErr := parseStationList(r, maxBackfillStations)
+	if stationsErr != nil {
+		http.Error(w, stationsErr.Error(), http.StatusBadRequest)
+		return
+	}
+	years, yearsErr := archive.ParseYears(r.URL.Query().Get("years"))
+	if yearsErr != nil {
+		http.Error(w, yearsErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	deadline := time.Now().Add(backfillTaskBudget)
+	loaded := 0
+	for _, stationID := range stationIDs {
+		for _, year := range years {
+			if time.Now().After(deadline) {
+				task := &taskqueue.Task{Path: "/tasks/backfill?" + r.URL.RawQuery, Method: "GET"}
+				if _, queueErr := taskqueue.Add(ctx, task, ""); queueErr != nil {
+					http.Error(w, queueErr.Error(), http.StatusInternalServerError)
+					return
+				}
+				fmt.Fprintf(w, "Loaded %d observations, the rest of the backfill is queued\n", loaded)
+				return
+			}
+
+			count, backfillErr := backfillStationYear(ctx, client, stationID, year, deadline)
+			loaded += count
+			if backfillErr != nil {
+				log.Errorf(ctx, "Could not backfill %s for %d: %v", stationID, year, backfillErr)
+			}
+		}
+	}
+
+	// The percentiles are only worth rebuilding once every year is in
+	task := &taskqueue.Task{Path: "/tasks/climatology?stations=" + url.QueryEscape(r.URL.Query().Get("stations")), Method: "GET"}
+	if _, queueErr := taskqueue.Add(ctx, task, ""); queueErr != nil {
+		log.Errorf(ctx, "Could not queue the climatology: %v", queueErr)
+	}
+	fmt.Fprintf(w, "Loaded %d observations, the backfill is done\n", loaded)
+}
+
+// Loads one station's year in batches, saving the progress after each so it
+// can stop at the deadline. Returns how many observations it loaded.
+func backfillStationYear(ctx context.Context, client *http.Client, stationID string, year int, deadline time.Time) (int, error) {
+	progressKey := backfillProgressKey(ctx, stationID, year)
+	progress := &BackfillProgress{StationID: stationID, Year: year}
+	if getErr := datastore.Get(ctx, progressKey, progress); getErr != nil && getErr != datastore.ErrNoSuchEntity {
+		return 0, getErr
+	}
+	if progress.Done {
+		return 0, nil
+	}
+
+	time.Sleep(backfillFetchDelay)
+	observations, fetchErr := archive.Fetch(client, stationID, year)
+	if fetchErr == archive.ErrNotArchived {
+		if !archive.IsSettled(year) {
+			// Left unfinished so a later backfill picks up the file once NDBC
+			// posts it
+			log.Infof(ctx, "%s has no archive for %d yet", stationID, year)
+			return 0, nil
+		}
+		observations = nil
+	} else if fetchErr != nil {
+		return 0, fetchErr
+	}
+
+	loaded := 0
+	for progress.Loaded < len(observations) && time.Now().Before(deadline) {
+		end := progress.Loaded + backfillBatchSize
+		if end > len(observations) {
+			end = len(observations)
+		}
+
+		batch := observations[progress.Loaded:end]
+		keys := make([]*datastore.Key, len(batch))
+		for index, observation := range batch {
+			keys[index] = archivedObservationKey(ctx, observation)
+		}
+		if _, putErr := datastore.PutMulti(ctx, keys, batch); putErr != nil {
+			return loaded, putErr
+		}
+
+		loaded += len(batch)
+		progress.Loaded = end
+		progress.Updated = time.Now()
+		if _, putErr := datastore.Put(ctx, progressKey, progress); putErr != nil {
+			return loaded, putErr
+		}
+	}
+
+	if progress.Loaded >= len(observations) {
+		progress.Done = true
+		progress.Updated = time.Now()
+		if _, putErr := datastore.Put(ctx, progressKey, progress); putErr != nil {
+			return loaded, putErr
+		}
+	}
+	return loaded, nil
+}
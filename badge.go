@@ -0,0 +1,100 @@
+package buoyfinder
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/memcache"
+)
+
+const badgeCacheExpiration = 10 * time.Minute
+
+// Rough width of a character of 11px Verdana, which is close enough to size
+// the badge without measuring the text
+const badgeCharacterWidth = 6.5
+const badgePadding = 10
+
+const badgeMissingColor = "#9f9f9f"
+
+const badgeTemplate = `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[2]s: %[3]s">
+<title>%[2]s: %[3]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%[4]d" height="20" fill="#555"/><rect x="%[4]d" width="%[5]d" height="20" fill="%[6]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>
+<g text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11"><text x="%[7]d" y="14" fill="#fff">%[2]s</text><text x="%[8]d" y="14" fill="%[9]s">%[3]s</text></g>
+</svg>`
+
+// A small badge with the latest wave height and period for embedding in
+// readmes, forums, and dashboards
+func stationBadgeHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+	palette := parsePalette(r)
+
+	cacheKey := "badge:" + stationID + ":" + palette
+	badge := []byte{}
+	if item, cacheErr := memcache.Get(ctx, cacheKey); cacheErr == nil {
+		badge = item.Value
+	} else {
+		value, color := "no data", badgeMissingColor
+
+		buoy := &surfnerd.Buoy{StationID: stationID}
+		if fetchErr := fetchLatestBuoyData(client, buoy); fetchErr == nil && len(buoy.BuoyData) > 0 {
+			summary := buoy.BuoyData[0].WaveSummary
+			if isValidReading(summary.WaveHeight, missingHeightMarker) {
+				buoyData := buoy.BuoyData[0]
+				buoyData.ChangeUnits(surfnerd.English)
+				summary = buoyData.WaveSummary
+
+				value = fmt.Sprintf("%.1f ft", summary.WaveHeight)
+				if isValidReading(summary.Period, missingPeriodMarker) {
+					value += fmt.Sprintf(" @ %.0f s", summary.Period)
+				}
+				fraction := summary.WaveHeight / waveHeightColorMax
+				if fraction > 1 {
+					fraction = 1
+				}
+				color = gradientPalettes[palette]().GetInterpolatedColorForFraction(fraction).Hex()
+			}
+		}
+
+		badge = []byte(newBadgeSVG(stationID, value, color))
+		memcache.Set(ctx, &memcache.Item{
+			Key:        cacheKey,
+			Value:      badge,
+			Expiration: badgeCacheExpiration,
+		})
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "public, max-age=600")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(badge)
+}
+
+func newBadgeSVG(label, value, color string) string {
+	labelWidth := int(float64(len(label))*badgeCharacterWidth) + badgePadding
+	valueWidth := int(float64(len(value))*badgeCharacterWidth) + badgePadding
+
+	// Light colors from the gradient need dark text to stay readable
+	textColor := "#fff"
+	if badgeColor, colorErr := colorful.Hex(color); colorErr == nil {
+		if lightness, _, _ := badgeColor.Lab(); lightness > 0.7 {
+			textColor = "#333"
+		}
+	}
+
+	return fmt.Sprintf(badgeTemplate, labelWidth+valueWidth, html.EscapeString(label), html.EscapeString(value), labelWidth, valueWidth, color, labelWidth/2, labelWidth+valueWidth/2, textColor)
+}
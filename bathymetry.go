@@ -0,0 +1,85 @@
+package buoyfinder
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+// NOAA's one arc minute ETOPO relief, served by the CoastWatch ERDDAP. Its
+// altitudes are negative below sea level.
+const (
+	bathymetryURL     = "https://coastwatch.pfeg.noaa.gov/erddap/griddap/etopo180.json"
+	bathymetryDataset = "ETOPO1"
+)
+
+// The seafloor does not move
+const bathymetryCacheExpiration = 30 * 24 * time.Hour
+
+var errOnLand = errors.New("The location is on land")
+
+// The depth of the water at a point, in meters below sea level
+type WaterDepth struct {
+	Location surfnerd.Location
+	Depth    float64
+	Dataset  string
+	// The center of the grid cell the depth was read from
+	GridLocation surfnerd.Location
+}
+
+func waterDepthHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	latitude, latitudeErr := strconv.ParseFloat(vars["lat"], 64)
+	longitude, longitudeErr := strconv.ParseFloat(vars["lon"], 64)
+	if latitudeErr != nil || longitudeErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("The location must be a latitude and longitude"))
+		return
+	}
+
+	depth, depthErr := fetchWaterDepth(ctx, client, surfnerd.NewLocationForLatLong(latitude, longitude))
+	if depthErr == errOnLand {
+		writeErrorResponse(w, r, http.StatusNotFound, depthErr)
+		return
+	} else if depthErr != nil {
+		writeErrorResponse(w, r, http.StatusBadGateway, depthErr)
+		return
+	}
+
+	writeDataResponse(w, r, client, depth)
+}
+
+// The depth at the grid cell nearest the location. The grid is about 2 km
+// across, so points right on the beach can land on a dry cell.
+func fetchWaterDepth(ctx context.Context, client *http.Client, location surfnerd.Location) (*WaterDepth, error) {
+	query := "altitude[(" + strconv.FormatFloat(location.Latitude, 'f', 4, 64) + ")][(" + strconv.FormatFloat(location.Longitude, 'f', 4, 64) + ")]"
+	row, fetchErr := fetchERDDAPRow(ctx, client, bathymetryURL, query, bathymetryCacheExpiration)
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	altitude, ok := row["altitude"].(float64)
+	if !ok {
+		return nil, errors.New("The bathymetry has no depth for the location")
+	} else if altitude >= 0 {
+		return nil, errOnLand
+	}
+
+	depth := &WaterDepth{Location: location, Depth: -altitude, Dataset: bathymetryDataset}
+	if latitude, ok := row["latitude"].(float64); ok {
+		depth.GridLocation.Latitude = latitude
+	}
+	if longitude, ok := row["longitude"].(float64); ok {
+		depth.GridLocation.Longitude = longitude
+	}
+	return depth, nil
+}
@@ -1,28 +1,25 @@
 package buoyfinder
 
 import (
-	"encoding/json"
-	"encoding/xml"
 	"errors"
-	"fmt"
 	"html/template"
-	"io/ioutil"
 	"math"
 	"net/http"
-	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/mpiannucci/buoyfinder/archive"
 	"github.com/mpiannucci/surfnerd"
 	"golang.org/x/net/context"
 	"google.golang.org/appengine"
-	"google.golang.org/appengine/urlfetch"
+	"google.golang.org/appengine/memcache"
 )
 
 var funcMap = template.FuncMap{
-	"ToFixedPoint": ToFixedPoint,
+	"ToFixedPoint":     ToFixedPoint,
+	"CompassDirection": CompassDirection,
 }
 
 var indexTemplate = template.Must(template.New("base.html").Funcs(nil).ParseFiles("templates/base.html", "templates/index.html"))
@@ -32,17 +29,34 @@ var apiDocTemplate = template.Must(template.New("base.html").Funcs(nil).ParseFil
 func init() {
 	router := mux.NewRouter().StrictSlash(true)
 	router.HandleFunc("/", indexHandler)
+	router.HandleFunc("/sitemap.xml", sitemapHandler)
 
 	// Free API
 	router.HandleFunc("/api", apiDocHandler)
 	router.HandleFunc("/api/stations", findAllStationsHandler)
+	router.HandleFunc("/api/stations/changes", stationChangesHandler)
 	router.HandleFunc("/api/stationinfo/{station}", findStationInfoHandler)
+	router.HandleFunc("/api/status/{station}", stationStatusHandler)
+	router.HandleFunc("/api/coverage/{station}", stationCoverageHandler)
+	router.HandleFunc("/api/outages", outagesHandler)
+	router.HandleFunc("/api/validate/{stationA}/{stationB}", validateStationsHandler)
+	router.HandleFunc("/api/verification/{station}", forecastVerificationHandler)
+	router.HandleFunc("/api/extremes/{station}/{year}", annualExtremesHandler)
+	router.HandleFunc("/api/closest", closestBuoysHandler).Methods("POST")
+	router.HandleFunc("/api/route", routeConditionsHandler).Methods("POST")
+	router.HandleFunc("/api/marine/{lat}/{lon}", marineConditionsHandler)
+	router.HandleFunc("/api/marine/{station}", stationMarineConditionsHandler)
+	router.HandleFunc("/api/astronomy/{lat}/{lon}/{date}", astronomyHandler)
+	router.HandleFunc("/api/depth/{lat}/{lon}", waterDepthHandler)
+	router.HandleFunc("/api/windwaves", windWaveHandler)
+	router.HandleFunc("/api/astronomy/{station}/{date}", stationAstronomyHandler)
 	router.HandleFunc("/api/latest/wave/charts{lat}/{lon}", closestLatestWaveChartsHandler)
 	router.HandleFunc("/api/latest/wave/charts/{station}", latestWaveIDChartsHandler)
 	router.HandleFunc("/api/latest/wave/{lat}/{lon}", closestLatestWaveHandler)
 	router.HandleFunc("/api/latest/weather/{lat}/{lon}", closestLatestWeatherHandler)
 	router.HandleFunc("/api/latest/wave/{station}", latestWaveIDHandler)
 	router.HandleFunc("/api/latest/weather/{station}", latestWeatherIDHandler)
+	router.HandleFunc("/api/latest/dart/{station}", latestDartHandler)
 	router.HandleFunc("/api/latest/{lat}/{lon}", closestLatestHandler)
 	router.HandleFunc("/api/latest/{station}", latestIDHandler)
 	router.HandleFunc("/api/date/wave/charts/{lat}/{lon}/{epoch}", closestWaveChartsDateHandler)
@@ -51,9 +65,89 @@ func init() {
 	router.HandleFunc("/api/date/weather/{lat}/{lon}/{epoch}", closestWeatherDateHandler)
 	router.HandleFunc("/api/date/wave/{station}/{epoch}", dateWaveIDHandler)
 	router.HandleFunc("/api/date/weather/{station}/{epoch}", dateWeatherIDHandler)
+	router.HandleFunc("/api/around/{station}/{epoch}", aroundTimeHandler)
+	router.HandleFunc("/api/diff/{station}/{epoch1}/{epoch2}", conditionsDiffHandler)
+	router.HandleFunc("/api/export/{station}.nc", exportNetCDFHandler)
+	router.HandleFunc("/api/export/{station}.parquet", exportParquetHandler)
+	router.HandleFunc("/api/export/{station}.zip", exportArchiveHandler)
+	router.HandleFunc("/api/raw/{station}/{product}", rawProductHandler)
+	router.HandleFunc("/api/qr/{station}.png", stationQRHandler)
+	router.HandleFunc("/api/sensor/{station}", sensorHandler)
+	router.HandleFunc("/api/charts/windrose/{station}.{format}", windRoseChartHandler)
+	router.HandleFunc("/api/charts/summary/{station}.png", summaryChartHandler)
+	router.HandleFunc("/api/cards/{station}.png", conditionsCardHandler)
+	router.HandleFunc("/api/charts/animated/{station}.gif", animatedSpectraChartHandler)
+	router.HandleFunc("/api/charts/watertemp/{station}.{format}", waterTemperatureChartHandler)
+	router.HandleFunc("/api/charts/wind/{station}.{format}", windChartHandler)
+	router.HandleFunc("/api/charts/waveheight/{station}.{format}", waveHeightChartHandler)
+	router.HandleFunc("/api/charts/tide/{station}.{format}", tideChartHandler)
+	router.HandleFunc("/api/charts/overlay.{format}", overlayChartHandler)
+	router.HandleFunc("/api/chartdata/spectra/{station}", spectraChartDataHandler)
+	router.HandleFunc("/api/nearshore/{station}", nearshoreSpectrumHandler)
+	router.HandleFunc("/api/chartdata/waveheight/{station}", waveHeightChartDataHandler)
+	router.HandleFunc("/api/spots", listSpotsHandler).Methods("GET")
+	router.HandleFunc("/api/spots", createSpotHandler).Methods("POST")
+	router.HandleFunc("/api/spots/{spot}", getSpotHandler).Methods("GET")
+	router.HandleFunc("/api/spots/{spot}", updateSpotHandler).Methods("PUT")
+	router.HandleFunc("/api/spots/{spot}", deleteSpotHandler).Methods("DELETE")
+	router.HandleFunc("/api/spots/{spot}/conditions", spotConditionsHandler)
+	router.HandleFunc("/api/spots/{spot}/recommended-buoys", recommendedSpotBuoysHandler).Methods("GET")
+	router.HandleFunc("/api/regions", listRegionsHandler).Methods("GET")
+	router.HandleFunc("/api/regions", createRegionHandler).Methods("POST")
+	router.HandleFunc("/api/regions/{region}", getRegionHandler).Methods("GET")
+	router.HandleFunc("/api/regions/{region}", updateRegionHandler).Methods("PUT")
+	router.HandleFunc("/api/regions/{region}", deleteRegionHandler).Methods("DELETE")
+	router.HandleFunc("/api/regions/{region}/stations", regionStationsHandler).Methods("GET")
+	router.HandleFunc("/api/favorites", listFavoritesHandler).Methods("GET")
+	router.HandleFunc("/api/favorites", addFavoriteHandler).Methods("POST")
+	router.HandleFunc("/api/favorites/latest", latestFavoritesHandler).Methods("GET")
+	router.HandleFunc("/api/favorites/{station}", deleteFavoriteHandler).Methods("DELETE")
+	router.HandleFunc("/api/alerts", listAlertsHandler).Methods("GET")
+	router.HandleFunc("/api/alerts", createAlertHandler).Methods("POST")
+	router.HandleFunc("/api/alerts/{alert}", deleteAlertHandler).Methods("DELETE")
+	router.HandleFunc("/api/me", meHandler).Methods("GET")
+	router.HandleFunc("/api/me", updateMeHandler).Methods("PUT")
+
+	// Accounts
+	router.HandleFunc("/login", loginHandler)
+	router.HandleFunc("/login/done", loginDoneHandler)
+	router.HandleFunc("/logout", logoutHandler)
 
 	// Buoy Web Views
 	router.HandleFunc("/buoy/{station}", buoyViewHandler)
+	router.HandleFunc("/buoy/{station}/partial", buoyPartialHandler)
+	router.HandleFunc("/buoy/{station}/{epoch}", buoyViewHandler)
+
+	// Badges
+	router.HandleFunc("/badge/{station}.svg", stationBadgeHandler)
+
+	// Metrics
+	router.HandleFunc("/metrics/buoys", buoyMetricsHandler)
+	router.HandleFunc("/grafana/", grafanaTestHandler)
+	router.HandleFunc("/grafana/search", grafanaSearchHandler).Methods("POST")
+	router.HandleFunc("/grafana/query", grafanaQueryHandler).Methods("POST")
+	router.HandleFunc("/grafana/annotations", grafanaAnnotationsHandler).Methods("POST")
+
+	// Webhooks
+	router.HandleFunc("/webhooks/voice", voiceWebhookHandler).Methods("POST")
+	router.HandleFunc("/webhooks/sms", smsWebhookHandler).Methods("POST")
+	router.HandleFunc("/webhooks/telegram", telegramWebhookHandler).Methods("POST")
+
+	// Automation Triggers
+	router.HandleFunc("/api/triggers/observations/{station}", newObservationTriggerHandler).Methods("GET")
+	router.HandleFunc("/api/triggers/thresholds/{station}", thresholdCrossedTriggerHandler).Methods("GET")
+	router.HandleFunc("/ifttt/v1/status", iftttStatusHandler).Methods("GET")
+	router.HandleFunc("/ifttt/v1/test/setup", iftttTestSetupHandler).Methods("POST")
+	router.HandleFunc("/ifttt/v1/triggers/"+newObservationTrigger, iftttTriggerHandler(newObservationTrigger)).Methods("POST")
+	router.HandleFunc("/ifttt/v1/triggers/"+thresholdCrossedTrigger, iftttTriggerHandler(thresholdCrossedTrigger)).Methods("POST")
+
+	// Cron Tasks
+	router.HandleFunc("/tasks/outages", checkOutagesHandler)
+	router.HandleFunc("/tasks/stationchanges", checkStationChangesHandler)
+	router.HandleFunc("/tasks/stationchanges/deliver", deliverStationChangesHandler).Methods("POST")
+	router.HandleFunc("/tasks/alerts", checkAlertsHandler)
+	router.HandleFunc("/tasks/backfill", backfillHandler)
+	router.HandleFunc("/tasks/climatology", climatologyHandler)
 	http.Handle("/", router)
 }
 
@@ -66,50 +160,114 @@ func indexHandler(w http.ResponseWriter, r *http.Request) {
 func buoyViewHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
 	vars := mux.Vars(r)
 
-	// Grab the user vars
+	// Grab the user vars. Permalinks carry the observation time, otherwise
+	// the latest conditions are shown
 	stationID := vars["station"]
 	requestedDate := time.Now()
+	rawdate, rawdateErr := strconv.ParseInt(vars["epoch"], 10, 64)
+	isPermalink := rawdateErr == nil
+	if isPermalink {
+		requestedDate = time.Unix(rawdate, 0)
+	}
 
 	// Create the requested buoy
-	requestedBuoy, _ := fetchBuoyWithID(client, stationID)
-
-	count := int(time.Since(requestedDate).Hours()*2) + 1
-	fetchBuoyError := fetchDetailedWaveBuoyData(client, requestedBuoy, count)
-	if fetchBuoyError != nil {
-		http.Error(w, fetchBuoyError.Error(), http.StatusInternalServerError)
+	requestedBuoy, requestedBuoyError := fetchBuoyWithID(ctx, client, stationID)
+	if requestedBuoyError != nil {
+		http.Error(w, requestedBuoyError.Error(), http.StatusNotFound)
 		return
 	}
 
-	requestedBuoyData, timeDiff := requestedBuoy.FindConditionsForDateAndTime(requestedDate)
+	// Permalinks from before the realtime files, like the annual extremes
+	// links, are served from the archive, which has no spectra to chart
+	isArchived := isPermalink && time.Since(requestedDate) > maxHistoryHours*time.Hour
+	var requestedBuoyData surfnerd.BuoyDataItem
+	var timeDiff time.Duration
+	if isArchived {
+		archivedData, archivedErr := fetchArchivedConditions(ctx, client, requestedBuoy.StationID, requestedDate)
+		if archivedErr == archive.ErrNotArchived {
+			http.Error(w, "There is no observation from "+stationID+" at that time", http.StatusNotFound)
+			return
+		} else if archivedErr != nil {
+			http.Error(w, archivedErr.Error(), http.StatusInternalServerError)
+			return
+		}
+		requestedBuoyData, timeDiff = *archivedData, archivedData.Date.Sub(requestedDate)
+	} else {
+		count := historyCountSince(requestedDate)
+		fetchBuoyError := fetchDetailedWaveBuoyData(client, requestedBuoy, count, parseSpectraSmoothing(r))
+		if fetchBuoyError != nil {
+			http.Error(w, fetchBuoyError.Error(), waveFetchErrorStatus(fetchBuoyError, http.StatusInternalServerError))
+			return
+		}
+		requestedBuoyData, timeDiff = requestedBuoy.FindConditionsForDateAndTime(requestedDate)
+	}
 
-	directionalPlot, directionalError := fetchDirectionalSpectraChart(client, stationID, requestedBuoyData)
-	if directionalError != nil {
-		directionalPlot = ""
+	if isPermalink && !isPermalinkMatch(timeDiff) {
+		http.Error(w, "There is no observation from "+stationID+" at that time", http.StatusNotFound)
+		return
 	}
 
-	spectraPlot, spectraError := fetchSpectraDistributionChart(client, stationID, requestedBuoyData)
-	if spectraError != nil {
-		spectraPlot = ""
+	requestedBuoyContainer := ClosestBuoy{
+		RequestedDate: requestedDate,
+		TimeDiffFound: timeDiff,
+		BuoyStationID: requestedBuoy.StationID,
+		BuoyData:      requestedBuoyData,
+		BuoyLocation:  *requestedBuoy.Location,
+	}
+	if !isArchived {
+		requestedBuoyContainer.fetchSpectraCharts(ctx, client, parseSpectraChartOptions(r))
 	}
+	requestedBuoyContainer.Climatology = fetchConditionsPercentile(ctx, requestedBuoy.StationID, requestedBuoyData)
 
 	// For now convert the swell to feet
+	requestedBuoyContainer.BuoyData.ChangeUnits(surfnerd.English)
+
+	if err := buoyTemplate.Execute(w, newBuoyPage(r, requestedBuoyContainer)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Just the conditions block of the buoy page for the page to poll, leaving the
+// charts alone
+func buoyPartialHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := vars["station"]
+
+	requestedBuoy, requestedBuoyError := fetchBuoyWithID(ctx, client, stationID)
+	if requestedBuoyError != nil {
+		http.Error(w, requestedBuoyError.Error(), http.StatusNotFound)
+		return
+	}
+
+	fetchBuoyError := fetchDetailedWaveBuoyData(client, requestedBuoy, 1, parseSpectraSmoothing(r))
+	if fetchBuoyError != nil {
+		http.Error(w, fetchBuoyError.Error(), waveFetchErrorStatus(fetchBuoyError, http.StatusInternalServerError))
+		return
+	}
+
+	requestedDate := time.Now()
+	requestedBuoyData, timeDiff := requestedBuoy.FindConditionsForDateAndTime(requestedDate)
 	requestedBuoyData.ChangeUnits(surfnerd.English)
 
 	requestedBuoyContainer := ClosestBuoy{
-		RequestedDate:           requestedDate,
-		TimeDiffFound:           timeDiff,
-		BuoyStationID:           requestedBuoy.StationID,
-		BuoyData:                requestedBuoyData,
-		BuoyLocation:            *requestedBuoy.Location,
-		DirectionalSpectraPlot:  directionalPlot,
-		SpectraDistributionPlot: spectraPlot,
+		RequestedDate: requestedDate,
+		TimeDiffFound: timeDiff,
+		BuoyStationID: requestedBuoy.StationID,
+		BuoyData:      requestedBuoyData,
+		BuoyLocation:  *requestedBuoy.Location,
 	}
+	requestedBuoyContainer.Climatology = fetchConditionsPercentile(ctx, requestedBuoy.StationID, requestedBuoyData)
 
-	if err := buoyTemplate.Execute(w, requestedBuoyContainer); err != nil {
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := buoyTemplate.ExecuteTemplate(w, "conditions", newBuoyPage(r, requestedBuoyContainer)); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
@@ -123,50 +281,65 @@ func apiDocHandler(w http.ResponseWriter, r *http.Request) {
 func findAllStationsHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
-	stationsResponse, _ := client.Get(surfnerd.ActiveBuoysURL)
-	defer stationsResponse.Body.Close()
+	stations, stationsError := fetchAllStations(ctx, client)
+	if stationsError != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, stationsError)
+		return
+	}
 
-	stationsContents, _ := ioutil.ReadAll(stationsResponse.Body)
-	stations := surfnerd.BuoyStations{}
-	xml.Unmarshal(stationsContents, &stations)
-	stationsJson, _ := stations.ToJSON()
+	// ?capability=dart lists only the stations with that capability
+	listing := newStationsListing(stations, r.URL.Query().Get("capability"))
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Write(stationsJson)
+	if acceptsProtobuf(r) {
+		w.Header().Set("Content-Type", protobufContentType)
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Write(encodeStations(listing))
+		return
+	}
+
+	writeDataResponse(w, r, client, &listing)
 }
 
 func findStationInfoHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
 	vars := mux.Vars(r)
 	stationID := vars["station"]
 
-	requestedBuoy, requestedBuoyError := fetchBuoyWithID(client, stationID)
+	requestedBuoy, requestedBuoyError := fetchBuoyWithID(ctx, client, stationID)
 	if requestedBuoyError != nil {
-		http.Error(w, requestedBuoyError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, requestedBuoyError)
 		return
 	}
 
-	buoyJson, buoyJsonErr := requestedBuoy.ToJSON()
-	if buoyJsonErr != nil {
-		http.Error(w, buoyJsonErr.Error(), http.StatusInternalServerError)
+	// The station list covers the basics, so a failed page scrape just leaves
+	// the extra metadata empty rather than failing the request
+	metadata, _ := fetchStationMetadata(ctx, client, requestedBuoy)
+	stationInfo := StationInfo{
+		Buoy:         requestedBuoy,
+		Class:        stationClass(*requestedBuoy),
+		Capabilities: stationCapabilities(*requestedBuoy),
+		Metadata:     metadata,
+	}
+
+	if acceptsProtobuf(r) {
+		w.Header().Set("Content-Type", protobufContentType)
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Write(stationInfo.ToProtobuf())
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Write(buoyJson)
+	writeDataResponse(w, r, client, &stationInfo)
 }
 
 func closestWaveDateHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
 	vars := mux.Vars(r)
 
@@ -179,46 +352,52 @@ func closestWaveDateHandler(w http.ResponseWriter, r *http.Request) {
 	requestedDate := time.Unix(rawdate, 0)
 
 	// Find the closest buoy
-	closestBuoy, closestError := fetchClosestBuoy(client, requestedLocation)
+	closestBuoy, closestError := fetchClosestBuoy(ctx, client, requestedLocation, parseClosestBuoyOptions(r))
 	if closestError != nil {
-		http.Error(w, closestError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, closestError)
 		return
 	}
 
 	// Get the buoy data
-	count := int(time.Since(requestedDate).Hours())
-	fetchBuoyError := fetchDetailedWaveBuoyData(client, closestBuoy, count)
+	count, countError := parseHistoryCount(r, requestedDate)
+	if countError != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, countError)
+		return
+	}
+	downsample, downsampleError := parseDownsampleOptions(r)
+	if downsampleError != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, downsampleError)
+		return
+	}
+	fetchBuoyError := fetchDetailedWaveBuoyData(client, closestBuoy, count, parseSpectraSmoothing(r))
 	if fetchBuoyError != nil {
-		http.Error(w, fetchBuoyError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, waveFetchErrorStatus(fetchBuoyError, http.StatusInternalServerError), fetchBuoyError)
 		return
 	}
 
-	closestBuoyData, timeDiff := closestBuoy.FindConditionsForDateAndTime(requestedDate)
+	closestBuoyData, timeDiff, interpolated := findConditionsForDate(closestBuoy, requestedDate, parseInterpolate(r))
 
 	closestBuoyContainer := ClosestBuoy{
 		RequestedLocation: requestedLocation,
 		RequestedDate:     requestedDate,
 		TimeDiffFound:     timeDiff,
+		Interpolated:      interpolated,
 		BuoyStationID:     closestBuoy.StationID,
 		BuoyLocation:      *closestBuoy.Location,
 		BuoyData:          closestBuoyData,
 	}
 
-	closestBuoyJson, closestBuoyJsonErr := json.MarshalIndent(&closestBuoyContainer, "", "    ")
-	if closestBuoyJsonErr != nil {
-		http.Error(w, closestBuoyJsonErr.Error(), http.StatusInternalServerError)
-		return
+	if parseIncludeHistory(r) {
+		closestBuoyContainer.History = downsample.apply(closestBuoy.BuoyData)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Write(closestBuoyJson)
+	writeClosestBuoyResponse(w, r, client, &closestBuoyContainer)
 }
 
 func closestWaveChartsDateHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
 	vars := mux.Vars(r)
 
@@ -230,59 +409,57 @@ func closestWaveChartsDateHandler(w http.ResponseWriter, r *http.Request) {
 	requestedLocation := surfnerd.NewLocationForLatLong(latitude, longitude)
 	requestedDate := time.Unix(rawdate, 0)
 
-	// Find the closest buoy
-	closestBuoy, closestError := fetchClosestBuoy(client, requestedLocation)
+	// Find the closest buoy publishing the spectra the charts are drawn from
+	options := parseClosestBuoyOptions(r)
+	options.Spectra = true
+	closestBuoy, closestError := fetchClosestBuoy(ctx, client, requestedLocation, options)
 	if closestError != nil {
-		http.Error(w, closestError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, closestError)
 		return
 	}
 
 	// Get the buoy data
-	count := int(time.Since(requestedDate).Hours())
-	fetchBuoyError := fetchDetailedWaveBuoyData(client, closestBuoy, count)
+	count, countError := parseHistoryCount(r, requestedDate)
+	if countError != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, countError)
+		return
+	}
+	downsample, downsampleError := parseDownsampleOptions(r)
+	if downsampleError != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, downsampleError)
+		return
+	}
+	fetchBuoyError := fetchDetailedWaveBuoyData(client, closestBuoy, count, parseSpectraSmoothing(r))
 	if fetchBuoyError != nil {
-		http.Error(w, fetchBuoyError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, waveFetchErrorStatus(fetchBuoyError, http.StatusInternalServerError), fetchBuoyError)
 		return
 	}
 
-	closestBuoyData, timeDiff := closestBuoy.FindConditionsForDateAndTime(requestedDate)
+	closestBuoyData, timeDiff, interpolated := findConditionsForDate(closestBuoy, requestedDate, parseInterpolate(r))
 
-	directionalPlot, directionalError := fetchDirectionalSpectraChart(client, closestBuoy.StationID, closestBuoyData)
-	if directionalError != nil {
-		directionalPlot = ""
+	closestBuoyContainer := ClosestBuoy{
+		RequestedLocation: requestedLocation,
+		RequestedDate:     requestedDate,
+		TimeDiffFound:     timeDiff,
+		Interpolated:      interpolated,
+		BuoyStationID:     closestBuoy.StationID,
+		BuoyLocation:      *closestBuoy.Location,
+		BuoyData:          closestBuoyData,
 	}
 
-	spectraPlot, spectraError := fetchSpectraDistributionChart(client, closestBuoy.StationID, closestBuoyData)
-	if spectraError != nil {
-		spectraPlot = ""
-	}
+	closestBuoyContainer.fetchSpectraCharts(ctx, client, parseSpectraChartOptions(r))
 
-	closestBuoyContainer := ClosestBuoy{
-		RequestedLocation:       requestedLocation,
-		RequestedDate:           requestedDate,
-		TimeDiffFound:           timeDiff,
-		BuoyStationID:           closestBuoy.StationID,
-		BuoyLocation:            *closestBuoy.Location,
-		BuoyData:                closestBuoyData,
-		DirectionalSpectraPlot:  directionalPlot,
-		SpectraDistributionPlot: spectraPlot,
-	}
-
-	closestBuoyJson, closestBuoyJsonErr := json.MarshalIndent(&closestBuoyContainer, "", "    ")
-	if closestBuoyJsonErr != nil {
-		http.Error(w, closestBuoyJsonErr.Error(), http.StatusInternalServerError)
-		return
+	if parseIncludeHistory(r) {
+		closestBuoyContainer.History = downsample.apply(closestBuoy.BuoyData)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Write(closestBuoyJson)
+	writeClosestBuoyResponse(w, r, client, &closestBuoyContainer)
 }
 
 func closestWeatherDateHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
 	vars := mux.Vars(r)
 
@@ -295,46 +472,54 @@ func closestWeatherDateHandler(w http.ResponseWriter, r *http.Request) {
 	requestedDate := time.Unix(rawdate, 0)
 
 	// Find the closest buoy
-	closestBuoy, closestError := fetchClosestBuoy(client, requestedLocation)
+	options := parseClosestBuoyOptions(r)
+	options.Weather = true
+	closestBuoy, closestError := fetchClosestBuoy(ctx, client, requestedLocation, options)
 	if closestError != nil {
-		http.Error(w, closestError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, closestError)
 		return
 	}
 
 	// Get the buoy data
-	count := int(time.Since(requestedDate).Hours())
+	count, countError := parseHistoryCount(r, requestedDate)
+	if countError != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, countError)
+		return
+	}
+	downsample, downsampleError := parseDownsampleOptions(r)
+	if downsampleError != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, downsampleError)
+		return
+	}
 	fetchBuoyError := fetchStandardBuoyData(client, closestBuoy, count)
 	if fetchBuoyError != nil {
-		http.Error(w, fetchBuoyError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, fetchBuoyError)
 		return
 	}
 
-	closestBuoyData, timeDiff := closestBuoy.FindConditionsForDateAndTime(requestedDate)
+	closestBuoyData, timeDiff, interpolated := findConditionsForDate(closestBuoy, requestedDate, parseInterpolate(r))
 
 	closestBuoyContainer := ClosestBuoy{
 		RequestedLocation: requestedLocation,
 		RequestedDate:     requestedDate,
 		TimeDiffFound:     timeDiff,
+		Interpolated:      interpolated,
 		BuoyStationID:     closestBuoy.StationID,
 		BuoyLocation:      *closestBuoy.Location,
 		BuoyData:          closestBuoyData,
 	}
 
-	closestBuoyJson, closestBuoyJsonErr := json.MarshalIndent(&closestBuoyContainer, "", "    ")
-	if closestBuoyJsonErr != nil {
-		http.Error(w, closestBuoyJsonErr.Error(), http.StatusInternalServerError)
-		return
+	if parseIncludeHistory(r) {
+		closestBuoyContainer.History = downsample.apply(closestBuoy.BuoyData)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Write(closestBuoyJson)
+	writeClosestBuoyResponse(w, r, client, &closestBuoyContainer)
 }
 
 func closestLatestHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
 	vars := mux.Vars(r)
 
@@ -344,16 +529,16 @@ func closestLatestHandler(w http.ResponseWriter, r *http.Request) {
 	requestedLocation := surfnerd.NewLocationForLatLong(latitude, longitude)
 
 	// Find the closest buoy
-	closestBuoy, closestError := fetchClosestBuoy(client, requestedLocation)
+	closestBuoy, closestError := fetchClosestBuoy(ctx, client, requestedLocation, parseClosestBuoyOptions(r))
 	if closestError != nil {
-		http.Error(w, closestError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, closestError)
 		return
 	}
 
 	// Get the buoy data
 	buoyFetchError := fetchLatestBuoyData(client, closestBuoy)
 	if buoyFetchError != nil {
-		http.Error(w, buoyFetchError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, buoyFetchError)
 		return
 	}
 
@@ -371,21 +556,13 @@ func closestLatestHandler(w http.ResponseWriter, r *http.Request) {
 		BuoyData:          closestBuoyData,
 	}
 
-	closestBuoyJson, closestBuoyJsonErr := json.MarshalIndent(&closestBuoyContainer, "", "    ")
-	if closestBuoyJsonErr != nil {
-		http.Error(w, closestBuoyJsonErr.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Write(closestBuoyJson)
+	writeClosestBuoyResponse(w, r, client, &closestBuoyContainer)
 }
 
 func closestLatestWaveHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
 	vars := mux.Vars(r)
 
@@ -398,16 +575,16 @@ func closestLatestWaveHandler(w http.ResponseWriter, r *http.Request) {
 	requestedDate := time.Unix(rawdate, 0)
 
 	// Find the closest buoy
-	closestBuoy, closestError := fetchClosestBuoy(client, requestedLocation)
+	closestBuoy, closestError := fetchClosestBuoy(ctx, client, requestedLocation, parseClosestBuoyOptions(r))
 	if closestError != nil {
-		http.Error(w, closestError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, closestError)
 		return
 	}
 
 	// Get the buoy data
-	fetchBuoyError := fetchDetailedWaveBuoyData(client, closestBuoy, 1)
+	fetchBuoyError := fetchDetailedWaveBuoyData(client, closestBuoy, 1, parseSpectraSmoothing(r))
 	if fetchBuoyError != nil {
-		http.Error(w, fetchBuoyError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, waveFetchErrorStatus(fetchBuoyError, http.StatusInternalServerError), fetchBuoyError)
 		return
 	}
 
@@ -422,21 +599,13 @@ func closestLatestWaveHandler(w http.ResponseWriter, r *http.Request) {
 		BuoyData:          closestBuoyData,
 	}
 
-	closestBuoyJson, closestBuoyJsonErr := json.MarshalIndent(&closestBuoyContainer, "", "    ")
-	if closestBuoyJsonErr != nil {
-		http.Error(w, closestBuoyJsonErr.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Write(closestBuoyJson)
+	writeClosestBuoyResponse(w, r, client, &closestBuoyContainer)
 }
 
 func closestLatestWaveChartsHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
 	vars := mux.Vars(r)
 
@@ -448,58 +617,42 @@ func closestLatestWaveChartsHandler(w http.ResponseWriter, r *http.Request) {
 	requestedLocation := surfnerd.NewLocationForLatLong(latitude, longitude)
 	requestedDate := time.Unix(rawdate, 0)
 
-	// Find the closest buoy
-	closestBuoy, closestError := fetchClosestBuoy(client, requestedLocation)
+	// Find the closest buoy publishing the spectra the charts are drawn from
+	options := parseClosestBuoyOptions(r)
+	options.Spectra = true
+	closestBuoy, closestError := fetchClosestBuoy(ctx, client, requestedLocation, options)
 	if closestError != nil {
-		http.Error(w, closestError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, closestError)
 		return
 	}
 
 	// Get the buoy data
-	fetchBuoyError := fetchDetailedWaveBuoyData(client, closestBuoy, 1)
+	fetchBuoyError := fetchDetailedWaveBuoyData(client, closestBuoy, 1, parseSpectraSmoothing(r))
 	if fetchBuoyError != nil {
-		http.Error(w, fetchBuoyError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, waveFetchErrorStatus(fetchBuoyError, http.StatusInternalServerError), fetchBuoyError)
 		return
 	}
 
 	closestBuoyData, timeDiff := closestBuoy.FindConditionsForDateAndTime(requestedDate)
 
-	directionalPlot, directionalError := fetchDirectionalSpectraChart(client, closestBuoy.StationID, closestBuoyData)
-	if directionalError != nil {
-		directionalPlot = ""
-	}
-
-	spectraPlot, spectraError := fetchSpectraDistributionChart(client, closestBuoy.StationID, closestBuoyData)
-	if spectraError != nil {
-		spectraPlot = ""
-	}
-
 	closestBuoyContainer := ClosestBuoy{
-		RequestedLocation:       requestedLocation,
-		RequestedDate:           requestedDate,
-		TimeDiffFound:           timeDiff,
-		BuoyStationID:           closestBuoy.StationID,
-		BuoyLocation:            *closestBuoy.Location,
-		BuoyData:                closestBuoyData,
-		DirectionalSpectraPlot:  directionalPlot,
-		SpectraDistributionPlot: spectraPlot,
-	}
-
-	closestBuoyJson, closestBuoyJsonErr := json.MarshalIndent(&closestBuoyContainer, "", "    ")
-	if closestBuoyJsonErr != nil {
-		http.Error(w, closestBuoyJsonErr.Error(), http.StatusInternalServerError)
-		return
+		RequestedLocation: requestedLocation,
+		RequestedDate:     requestedDate,
+		TimeDiffFound:     timeDiff,
+		BuoyStationID:     closestBuoy.StationID,
+		BuoyLocation:      *closestBuoy.Location,
+		BuoyData:          closestBuoyData,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Write(closestBuoyJson)
+	closestBuoyContainer.fetchSpectraCharts(ctx, client, parseSpectraChartOptions(r))
+
+	writeClosestBuoyResponse(w, r, client, &closestBuoyContainer)
 }
 
 func closestLatestWeatherHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
 	vars := mux.Vars(r)
 
@@ -512,16 +665,18 @@ func closestLatestWeatherHandler(w http.ResponseWriter, r *http.Request) {
 	requestedDate := time.Unix(rawdate, 0)
 
 	// Find the closest buoy
-	closestBuoy, closestError := fetchClosestBuoy(client, requestedLocation)
+	options := parseClosestBuoyOptions(r)
+	options.Weather = true
+	closestBuoy, closestError := fetchClosestBuoy(ctx, client, requestedLocation, options)
 	if closestError != nil {
-		http.Error(w, closestError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, closestError)
 		return
 	}
 
 	// Get the buoy data
 	fetchBuoyError := fetchStandardBuoyData(client, closestBuoy, 1)
 	if fetchBuoyError != nil {
-		http.Error(w, fetchBuoyError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, fetchBuoyError)
 		return
 	}
 
@@ -536,21 +691,13 @@ func closestLatestWeatherHandler(w http.ResponseWriter, r *http.Request) {
 		BuoyData:          closestBuoyData,
 	}
 
-	closestBuoyJson, closestBuoyJsonErr := json.MarshalIndent(&closestBuoyContainer, "", "    ")
-	if closestBuoyJsonErr != nil {
-		http.Error(w, closestBuoyJsonErr.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Write(closestBuoyJson)
+	writeClosestBuoyResponse(w, r, client, &closestBuoyContainer)
 }
 
 func latestIDHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
 	vars := mux.Vars(r)
 	stationID := vars["station"]
@@ -561,7 +708,7 @@ func latestIDHandler(w http.ResponseWriter, r *http.Request) {
 	// Get the buoy data
 	buoyFetchError := fetchLatestBuoyData(client, requestedBuoy)
 	if buoyFetchError != nil {
-		http.Error(w, buoyFetchError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, buoyFetchError)
 		return
 	}
 
@@ -577,21 +724,13 @@ func latestIDHandler(w http.ResponseWriter, r *http.Request) {
 		BuoyData:      requestedBuoyData,
 	}
 
-	requestedBuoyJson, requestedBuoyJsonErr := json.MarshalIndent(&requestedBuoyContainer, "", "    ")
-	if requestedBuoyJsonErr != nil {
-		http.Error(w, requestedBuoyJsonErr.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Write(requestedBuoyJson)
+	writeClosestBuoyResponse(w, r, client, &requestedBuoyContainer)
 }
 
 func latestWaveIDHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
 	vars := mux.Vars(r)
 	stationID := vars["station"]
@@ -600,9 +739,9 @@ func latestWaveIDHandler(w http.ResponseWriter, r *http.Request) {
 	requestedBuoy := &surfnerd.Buoy{StationID: stationID}
 
 	// Get the buoy data
-	buoyFetchError := fetchDetailedWaveBuoyData(client, requestedBuoy, 1)
+	buoyFetchError := fetchDetailedWaveBuoyData(client, requestedBuoy, 1, parseSpectraSmoothing(r))
 	if buoyFetchError != nil {
-		http.Error(w, buoyFetchError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, waveFetchErrorStatus(buoyFetchError, http.StatusInternalServerError), buoyFetchError)
 		return
 	}
 
@@ -616,21 +755,13 @@ func latestWaveIDHandler(w http.ResponseWriter, r *http.Request) {
 		BuoyData:      requestedBuoyData,
 	}
 
-	requestedBuoyJson, requestedBuoyJsonErr := json.MarshalIndent(&requestedBuoyContainer, "", "    ")
-	if requestedBuoyJsonErr != nil {
-		http.Error(w, requestedBuoyJsonErr.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Write(requestedBuoyJson)
+	writeClosestBuoyResponse(w, r, client, &requestedBuoyContainer)
 }
 
 func latestWaveIDChartsHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
 	vars := mux.Vars(r)
 	stationID := vars["station"]
@@ -639,49 +770,31 @@ func latestWaveIDChartsHandler(w http.ResponseWriter, r *http.Request) {
 	requestedBuoy := &surfnerd.Buoy{StationID: stationID}
 
 	// Get the buoy data
-	buoyFetchError := fetchDetailedWaveBuoyData(client, requestedBuoy, 1)
+	buoyFetchError := fetchDetailedWaveBuoyData(client, requestedBuoy, 1, parseSpectraSmoothing(r))
 	if buoyFetchError != nil {
-		http.Error(w, buoyFetchError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, waveFetchErrorStatus(buoyFetchError, http.StatusInternalServerError), buoyFetchError)
 		return
 	}
 
 	requestedDate := time.Now()
 	requestedBuoyData, timeDiff := requestedBuoy.FindConditionsForDateAndTime(requestedDate)
 
-	directionalPlot, directionalError := fetchDirectionalSpectraChart(client, stationID, requestedBuoyData)
-	if directionalError != nil {
-		directionalPlot = ""
-	}
-
-	spectraPlot, spectraError := fetchSpectraDistributionChart(client, stationID, requestedBuoyData)
-	if spectraError != nil {
-		spectraPlot = ""
-	}
-
 	requestedBuoyContainer := ClosestBuoy{
-		RequestedDate:           requestedDate,
-		TimeDiffFound:           timeDiff,
-		BuoyStationID:           requestedBuoy.StationID,
-		BuoyData:                requestedBuoyData,
-		DirectionalSpectraPlot:  directionalPlot,
-		SpectraDistributionPlot: spectraPlot,
+		RequestedDate: requestedDate,
+		TimeDiffFound: timeDiff,
+		BuoyStationID: requestedBuoy.StationID,
+		BuoyData:      requestedBuoyData,
 	}
 
-	requestedBuoyJson, requestedBuoyJsonErr := json.MarshalIndent(&requestedBuoyContainer, "", "    ")
-	if requestedBuoyJsonErr != nil {
-		http.Error(w, requestedBuoyJsonErr.Error(), http.StatusInternalServerError)
-		return
-	}
+	requestedBuoyContainer.fetchSpectraCharts(ctx, client, parseSpectraChartOptions(r))
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Write(requestedBuoyJson)
+	writeClosestBuoyResponse(w, r, client, &requestedBuoyContainer)
 }
 
 func latestWeatherIDHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
 	vars := mux.Vars(r)
 	stationID := vars["station"]
@@ -692,7 +805,7 @@ func latestWeatherIDHandler(w http.ResponseWriter, r *http.Request) {
 	// Get the buoy data
 	buoyFetchError := fetchStandardBuoyData(client, requestedBuoy, 1)
 	if buoyFetchError != nil {
-		http.Error(w, buoyFetchError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, buoyFetchError)
 		return
 	}
 
@@ -706,21 +819,13 @@ func latestWeatherIDHandler(w http.ResponseWriter, r *http.Request) {
 		BuoyData:      requestedBuoyData,
 	}
 
-	requestedBuoyJson, requestedBuoyJsonErr := json.MarshalIndent(&requestedBuoyContainer, "", "    ")
-	if requestedBuoyJsonErr != nil {
-		http.Error(w, requestedBuoyJsonErr.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Write(requestedBuoyJson)
+	writeClosestBuoyResponse(w, r, client, &requestedBuoyContainer)
 }
 
 func dateWaveIDHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
 	vars := mux.Vars(r)
 
@@ -733,37 +838,43 @@ func dateWaveIDHandler(w http.ResponseWriter, r *http.Request) {
 	// Create the requested buoy
 	requestedBuoy := &surfnerd.Buoy{StationID: stationID}
 
-	count := int(time.Since(requestedDate).Hours() * 2)
-	fetchBuoyError := fetchDetailedWaveBuoyData(client, requestedBuoy, count)
+	count, countError := parseHistoryCount(r, requestedDate)
+	if countError != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, countError)
+		return
+	}
+	downsample, downsampleError := parseDownsampleOptions(r)
+	if downsampleError != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, downsampleError)
+		return
+	}
+	fetchBuoyError := fetchDetailedWaveBuoyData(client, requestedBuoy, count, parseSpectraSmoothing(r))
 	if fetchBuoyError != nil {
-		http.Error(w, fetchBuoyError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, waveFetchErrorStatus(fetchBuoyError, http.StatusInternalServerError), fetchBuoyError)
 		return
 	}
 
-	requestedBuoyData, timeDiff := requestedBuoy.FindConditionsForDateAndTime(requestedDate)
+	requestedBuoyData, timeDiff, interpolated := findConditionsForDate(requestedBuoy, requestedDate, parseInterpolate(r))
 
 	requestedBuoyContainer := ClosestBuoy{
 		RequestedDate: requestedDate,
 		TimeDiffFound: timeDiff,
+		Interpolated:  interpolated,
 		BuoyStationID: requestedBuoy.StationID,
 		BuoyData:      requestedBuoyData,
 	}
 
-	requestedBuoyJson, requestedBuoyJsonErr := json.MarshalIndent(&requestedBuoyContainer, "", "    ")
-	if requestedBuoyJsonErr != nil {
-		http.Error(w, requestedBuoyJsonErr.Error(), http.StatusInternalServerError)
-		return
+	if parseIncludeHistory(r) {
+		requestedBuoyContainer.History = downsample.apply(requestedBuoy.BuoyData)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Write(requestedBuoyJson)
+	writeClosestBuoyResponse(w, r, client, &requestedBuoyContainer)
 }
 
 func dateWaveIDChartsHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
 	vars := mux.Vars(r)
 
@@ -776,49 +887,45 @@ func dateWaveIDChartsHandler(w http.ResponseWriter, r *http.Request) {
 	// Create the requested buoy
 	requestedBuoy := &surfnerd.Buoy{StationID: stationID}
 
-	count := int(time.Since(requestedDate).Hours() * 2)
-	fetchBuoyError := fetchDetailedWaveBuoyData(client, requestedBuoy, count)
-	if fetchBuoyError != nil {
-		http.Error(w, fetchBuoyError.Error(), http.StatusInternalServerError)
+	count, countError := parseHistoryCount(r, requestedDate)
+	if countError != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, countError)
 		return
 	}
-
-	requestedBuoyData, timeDiff := requestedBuoy.FindConditionsForDateAndTime(requestedDate)
-
-	directionalPlot, directionalError := fetchDirectionalSpectraChart(client, stationID, requestedBuoyData)
-	if directionalError != nil {
-		directionalPlot = ""
+	downsample, downsampleError := parseDownsampleOptions(r)
+	if downsampleError != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, downsampleError)
+		return
 	}
-
-	spectraPlot, spectraError := fetchSpectraDistributionChart(client, stationID, requestedBuoyData)
-	if spectraError != nil {
-		spectraPlot = ""
+	fetchBuoyError := fetchDetailedWaveBuoyData(client, requestedBuoy, count, parseSpectraSmoothing(r))
+	if fetchBuoyError != nil {
+		writeErrorResponse(w, r, waveFetchErrorStatus(fetchBuoyError, http.StatusInternalServerError), fetchBuoyError)
+		return
 	}
 
+	requestedBuoyData, timeDiff, interpolated := findConditionsForDate(requestedBuoy, requestedDate, parseInterpolate(r))
+
 	requestedBuoyContainer := ClosestBuoy{
-		RequestedDate:           requestedDate,
-		TimeDiffFound:           timeDiff,
-		BuoyStationID:           requestedBuoy.StationID,
-		BuoyData:                requestedBuoyData,
-		DirectionalSpectraPlot:  directionalPlot,
-		SpectraDistributionPlot: spectraPlot,
+		RequestedDate: requestedDate,
+		TimeDiffFound: timeDiff,
+		Interpolated:  interpolated,
+		BuoyStationID: requestedBuoy.StationID,
+		BuoyData:      requestedBuoyData,
 	}
 
-	requestedBuoyJson, requestedBuoyJsonErr := json.MarshalIndent(&requestedBuoyContainer, "", "    ")
-	if requestedBuoyJsonErr != nil {
-		http.Error(w, requestedBuoyJsonErr.Error(), http.StatusInternalServerError)
-		return
+	requestedBuoyContainer.fetchSpectraCharts(ctx, client, parseSpectraChartOptions(r))
+
+	if parseIncludeHistory(r) {
+		requestedBuoyContainer.History = downsample.apply(requestedBuoy.BuoyData)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Write(requestedBuoyJson)
+	writeClosestBuoyResponse(w, r, client, &requestedBuoyContainer)
 }
 
 func dateWeatherIDHandler(w http.ResponseWriter, r *http.Request) {
 	ctxParent := appengine.NewContext(r)
 	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
-	client := urlfetch.Client(ctx)
+	client := newFetchClient(ctx)
 
 	vars := mux.Vars(r)
 
@@ -831,43 +938,93 @@ func dateWeatherIDHandler(w http.ResponseWriter, r *http.Request) {
 	// Create the requested buoy
 	requestedBuoy := &surfnerd.Buoy{StationID: stationID}
 
-	count := int(time.Since(requestedDate).Hours() * 2)
+	count, countError := parseHistoryCount(r, requestedDate)
+	if countError != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, countError)
+		return
+	}
+	downsample, downsampleError := parseDownsampleOptions(r)
+	if downsampleError != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, downsampleError)
+		return
+	}
 	fetchBuoyError := fetchStandardBuoyData(client, requestedBuoy, count)
 	if fetchBuoyError != nil {
-		http.Error(w, fetchBuoyError.Error(), http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, fetchBuoyError)
 		return
 	}
 
-	requestedBuoyData, timeDiff := requestedBuoy.FindConditionsForDateAndTime(requestedDate)
+	requestedBuoyData, timeDiff, interpolated := findConditionsForDate(requestedBuoy, requestedDate, parseInterpolate(r))
 
 	requestedBuoyContainer := ClosestBuoy{
 		RequestedDate: requestedDate,
 		TimeDiffFound: timeDiff,
+		Interpolated:  interpolated,
 		BuoyStationID: requestedBuoy.StationID,
 		BuoyData:      requestedBuoyData,
 	}
 
-	requestedBuoyJson, requestedBuoyJsonErr := json.MarshalIndent(&requestedBuoyContainer, "", "    ")
-	if requestedBuoyJsonErr != nil {
-		http.Error(w, requestedBuoyJsonErr.Error(), http.StatusInternalServerError)
+	if parseIncludeHistory(r) {
+		requestedBuoyContainer.History = downsample.apply(requestedBuoy.BuoyData)
+	}
+
+	writeClosestBuoyResponse(w, r, client, &requestedBuoyContainer)
+}
+
+func writeClosestBuoyResponse(w http.ResponseWriter, r *http.Request, client *http.Client, container *ClosestBuoy) {
+	container.BuoyStatus = fetchBuoyStatus(appengine.NewContext(r), container.BuoyStationID)
+	// Warnings are only ever the active ones, so they only belong on the
+	// latest conditions
+	if isLatestRequest(r) {
+		container.Warnings = fetchStationWarnings(appengine.NewContext(r), client, container)
+
+		// The arrows are a nicety, so a failed fetch just leaves them out
+		if previous, _ := fetchPreviousObservation(client, container.BuoyStationID, container.BuoyData.Date); previous != nil {
+			container.PreviousBuoyData = previous
+			container.Changes = newObservationChanges(container.BuoyData, *previous)
+		}
+	}
+	if isMissingWaterTemperature(container.BuoyData) {
+		applySatelliteWaterTemperature(appengine.NewContext(r), client, container)
+	}
+	container.SetDistance(parseClosestBuoyOptions(r).DistanceAlgorithm)
+	container.SetWaterBody()
+	container.SeaState = newSeaState(container.BuoyData)
+	container.Climatology = fetchConditionsPercentile(appengine.NewContext(r), container.BuoyStationID, container.BuoyData)
+	container.SpectralWaveHeight = newSpectralWaveHeightCheck(container.BuoyData)
+	container.SpectralPeaks = newSpectralPeaks(container.BuoyData)
+	swellOptions := parseSwellOptions(r)
+	if swellOptions.Window != nil {
+		container.SwellWindow = swellOptions.Window.summarize(container.BuoyData.SwellComponents)
+	}
+	container.BuoyData.SwellComponents = swellOptions.apply(container.BuoyData.SwellComponents)
+	for index := range container.History {
+		container.History[index].SwellComponents = swellOptions.apply(container.History[index].SwellComponents)
+	}
+
+	if acceptsProtobuf(r) {
+		w.Header().Set("Content-Type", protobufContentType)
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Write(container.ToProtobuf())
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Write(requestedBuoyJson)
+	if wantsInflux(r) {
+		writeInflux(w, influxLine(container.BuoyStationID, container.BuoyData))
+		return
+	}
+
+	container.verbose = parseVerboseJSON(r)
+	envelope := newResponseEnvelope(r, client, container)
+	envelope.SetObservation(container.BuoyStationID, container.BuoyData.Date)
+	writeEnvelope(w, r, http.StatusOK, envelope)
 }
 
-func fetchBuoyWithID(client *http.Client, stationID string) (*surfnerd.Buoy, error) {
-	stationsResponse, stationsError := client.Get(surfnerd.ActiveBuoysURL)
+func fetchBuoyWithID(ctx context.Context, client *http.Client, stationID string) (*surfnerd.Buoy, error) {
+	stations, stationsError := fetchAllStations(ctx, client)
 	if stationsError != nil {
 		return nil, stationsError
 	}
-	defer stationsResponse.Body.Close()
-
-	stationsContents, _ := ioutil.ReadAll(stationsResponse.Body)
-	stations := surfnerd.BuoyStations{}
-	xml.Unmarshal(stationsContents, &stations)
 
 	requestedBuoy := stations.FindBuoyByID(stationID)
 	if requestedBuoy == nil {
@@ -877,33 +1034,72 @@ func fetchBuoyWithID(client *http.Client, stationID string) (*surfnerd.Buoy, err
 	return requestedBuoy, nil
 }
 
-func fetchClosestBuoy(client *http.Client, requestedLocation surfnerd.Location) (*surfnerd.Buoy, error) {
-	stationsResponse, stationsError := client.Get(surfnerd.ActiveBuoysURL)
+func fetchClosestBuoy(ctx context.Context, client *http.Client, requestedLocation surfnerd.Location, options ClosestBuoyOptions) (*surfnerd.Buoy, error) {
+	stations, stationsError := fetchAllStations(ctx, client)
 	if stationsError != nil {
 		return nil, stationsError
 	}
-	defer stationsResponse.Body.Close()
 
-	stationsContents, _ := ioutil.ReadAll(stationsResponse.Body)
-	stations := surfnerd.BuoyStations{}
-	xml.Unmarshal(stationsContents, &stations)
+	return selectClosestBuoy(ctx, client, stations, requestedLocation, options, map[string]bool{})
+}
+
+// Picks the closest buoy from the station list. Freshness checks are recorded
+// in fresh so lookups for several nearby locations only check each station once.
+func selectClosestBuoy(ctx context.Context, client *http.Client, stations *surfnerd.BuoyStations, requestedLocation surfnerd.Location, options ClosestBuoyOptions, fresh map[string]bool) (*surfnerd.Buoy, error) {
+	capability := CapabilityWaves
+	if options.Weather {
+		capability = CapabilityMeteorology
+	}
+	maxCandidates := maxClosestBuoyCandidates
+	if options.Spectra {
+		maxCandidates = maxSpectraBuoyCandidates
+	}
+
+	candidates := 0
+	for _, buoy := range sortedActiveStations(stations, requestedLocation, options.DistanceAlgorithm, capability) {
+		if options.isExcluded(buoy.StationID) {
+			continue
+		}
+		if options.MaxAge == 0 && !options.Spectra {
+			return buoy, nil
+		}
 
-	closestBuoy := stations.FindClosestActiveWaveBuoy(requestedLocation)
-	if closestBuoy == nil {
-		return nil, errors.New("Could not find the closest buoy")
+		// The closest station is no use if its data is days old, so keep
+		// walking outwards until one has reported recently
+		if candidates++; candidates > maxCandidates {
+			break
+		}
+		isFresh, checked := fresh[buoy.StationID]
+		if !checked {
+			isFresh = isFreshStation(ctx, client, buoy.StationID, options)
+			fresh[buoy.StationID] = isFresh
+		}
+		if isFresh {
+			return buoy, nil
+		}
 	}
 
-	return closestBuoy, nil
+	if options.Spectra {
+		return nil, errors.New("Could not find a nearby buoy publishing spectra")
+	}
+	return nil, errors.New("Could not find the closest buoy")
 }
 
 func fetchLatestBuoyData(client *http.Client, buoy *surfnerd.Buoy) error {
+	if provider, providerStationID := stationProvider(buoy.StationID); provider != nil {
+		return fetchProviderBuoyData(client, provider, providerStationID, buoy, 1)
+	}
+
 	buoyResponse, buoyError := client.Get(buoy.CreateLatestReadingURL())
 	if buoyError != nil {
 		return buoyError
 	}
 	defer buoyResponse.Body.Close()
 
-	buoyContents, _ := ioutil.ReadAll(buoyResponse.Body)
+	buoyContents, readErr := readNDBCBody(buoyResponse)
+	if readErr != nil {
+		return readErr
+	}
 	rawBuoyData := string(buoyContents[:])
 
 	buoyParseError := buoy.ParseRawLatestBuoyData(rawBuoyData)
@@ -911,17 +1107,27 @@ func fetchLatestBuoyData(client *http.Client, buoy *surfnerd.Buoy) error {
 		return buoyParseError
 	}
 
+	for index := range buoy.BuoyData {
+		buoy.BuoyData[index] = withUnreportedLatestReadings(buoy.BuoyData[index], rawBuoyData)
+	}
 	return nil
 }
 
 func fetchStandardBuoyData(client *http.Client, buoy *surfnerd.Buoy, count int) error {
+	if provider, providerStationID := stationProvider(buoy.StationID); provider != nil {
+		return fetchProviderBuoyData(client, provider, providerStationID, buoy, count)
+	}
+
 	buoyResponse, buoyError := client.Get(buoy.CreateStandardDataURL())
 	if buoyError != nil {
 		return buoyError
 	}
 	defer buoyResponse.Body.Close()
 
-	buoyContents, _ := ioutil.ReadAll(buoyResponse.Body)
+	buoyContents, readErr := readNDBCBody(buoyResponse)
+	if readErr != nil {
+		return readErr
+	}
 	rawBuoyData := strings.Fields(string(buoyContents))
 
 	buoyParseError := buoy.ParseRawStandardData(rawBuoyData, count)
@@ -932,13 +1138,24 @@ func fetchStandardBuoyData(client *http.Client, buoy *surfnerd.Buoy, count int)
 	return nil
 }
 
-func fetchDetailedWaveBuoyData(client *http.Client, buoy *surfnerd.Buoy, count int) error {
+func fetchDetailedWaveBuoyData(client *http.Client, buoy *surfnerd.Buoy, count, smoothing int) error {
+	// Providers only report the wave summary, without spectra
+	if provider, providerStationID := stationProvider(buoy.StationID); provider != nil {
+		return fetchProviderBuoyData(client, provider, providerStationID, buoy, count)
+	}
+
 	directionalResponse, directionalError := client.Get(buoy.CreateDirectionalSpectraDataURL())
 	if directionalError != nil {
 		return directionalError
 	}
 	defer directionalResponse.Body.Close()
-	directionalContents, _ := ioutil.ReadAll(directionalResponse.Body)
+	if directionalResponse.StatusCode == http.StatusNotFound {
+		return errNoWaveSensor
+	}
+	directionalContents, directionalReadErr := readNDBCBody(directionalResponse)
+	if directionalReadErr != nil {
+		return directionalReadErr
+	}
 	rawAlphaData := strings.Split(string(directionalContents), "\n")
 
 	energyResponse, energyError := client.Get(buoy.CreateEnergySpectraDataURL())
@@ -946,85 +1163,109 @@ func fetchDetailedWaveBuoyData(client *http.Client, buoy *surfnerd.Buoy, count i
 		return energyError
 	}
 	defer energyResponse.Body.Close()
-	energyContents, _ := ioutil.ReadAll(energyResponse.Body)
-	rawEnergyData := strings.Split(string(energyContents), "\n")
+	if energyResponse.StatusCode == http.StatusNotFound {
+		return errNoWaveSensor
+	}
+	energyContents, energyReadErr := readNDBCBody(energyResponse)
+	if energyReadErr != nil {
+		return energyReadErr
+	}
+	rawEnergyData := smoothRawEnergySpectra(strings.Split(string(energyContents), "\n"), smoothing)
 
 	buoyParseError := buoy.ParseRawWaveSpectraData(rawAlphaData, rawEnergyData, count)
 	if buoyParseError != nil {
 		return buoyParseError
 	}
 
+	for index := range buoy.BuoyData {
+		buoy.BuoyData[index] = withMissingMeteorology(buoy.BuoyData[index])
+	}
 	return nil
 }
 
-func fetchDirectionalSpectraChart(client *http.Client, stationID string, buoyData surfnerd.BuoyDataItem) (string, error) {
-	// Create the color gradient
-	gradient := NewGradient()
+// Renders both spectra charts for the container's observation. A chart that
+// fails is left empty with the reason in ChartErrors.
+func (self *ClosestBuoy) fetchSpectraCharts(ctx context.Context, client *http.Client, options SpectraChartOptions) {
+	directionalPlot, directionalError := fetchDirectionalSpectraChart(ctx, client, self.BuoyStationID, self.BuoyData, options)
+	if directionalError != nil {
+		self.setChartError("DirectionalSpectraPlot", directionalError)
+	}
+	self.DirectionalSpectraPlot = directionalPlot
+
+	spectraPlot, spectraError := fetchSpectraDistributionChart(ctx, client, self.BuoyStationID, self.BuoyData, options)
+	if spectraError != nil {
+		self.setChartError("SpectraDistributionPlot", spectraError)
+	}
+	self.SpectraDistributionPlot = spectraPlot
+}
+
+func fetchDirectionalSpectraChart(ctx context.Context, client *http.Client, stationID string, buoyData surfnerd.BuoyDataItem, options SpectraChartOptions) (string, error) {
+	return fetchChartURL(ctx, client, directionalSpectraChartOptions(stationID, buoyData, options))
+}
+
+func directionalSpectraChartOptions(stationID string, buoyData surfnerd.BuoyDataItem, options SpectraChartOptions) string {
+	// Color each column by how much of the peak energy it holds
+	maxEnergy := options.peakEnergy(buoyData.WaveSpectra.Energies)
 
 	values := "["
-	colors := "["
-	for index, energy := range buoyData.WaveSpectra.Energies {
+	for index, point := range directionalSpectraSeries(buoyData).Points {
 		if index > 0 {
 			values += ","
-			colors += ","
 		}
-		values += "[" + strconv.FormatFloat(buoyData.WaveSpectra.Angles[index], 'f', 2, 64) + "," + strconv.FormatFloat(energy, 'f', 2, 64) + "]"
-		gradColor := gradient.GetInterpolatedColorFor(1 / buoyData.WaveSpectra.Frequencies[index])
-		colors += fmt.Sprintf("'rgba(%3.0f, %3.0f, %3.0f, %1.1f)'", gradColor.R*255.0, gradColor.G*255.0, gradColor.B*255.0, 0.5)
+		gradColor := energyColor(options.Gradient, point[1], maxEnergy)
+		values += "{x: " + strconv.FormatFloat(point[0], 'f', 2, 64) + ", y: " + options.energyValue(point[1], maxEnergy) + ", color: " + chartColor(gradColor, 0.8) + "}"
 	}
 	values += "]"
-	colors += "]"
 
 	buoyTime := buoyData.Date.Format("01/02/2006 15:04 UTC")
 
-	exportURL := "http://export.highcharts.com"
-	data := url.Values{}
-	data.Set("content", "options")
-	data.Set("options", "{chart: {polar: true, type: 'column', spacing: [0, 0, 0, 0], margin: [20, 0, 0, 0], width: 600, height: 600}, navigation: {buttonOptions: {enabled: false}}, title: {text: 'Station "+stationID+": Directional Wave Spectra', style: {font: '10px Helvetica, sans-serif'}}, subtitle: {text: 'Valid "+buoyTime+"', style: {font: '8px Helvetica, sans-serif'}}, legend: {enabled: false}, credits: {enabled: false}, pane: {startAngle: 0, endAngle: 360}, xAxis: {labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1, tickmarkPlacement: 'on', tickInterval: 45, min: 0, max: 360, minPadding: 0, maxPadding: 0}, yAxis: {labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1, min: 0, endOnTick: true, showLastLabel: true, title: {useHTML: true, text: 'Energy (m<sup>2</sup>/Hz)'}, labels: {formatter: function(){return this.value}}, reversedStacks: false}, plotOptions: {series: {stacking: null, shadow: false, groupPadding: 0, pointPlacement: 'on', pointWidth: 0.6}, column: {colors: "+colors+"}}, series: [{type: 'column', name: 'Energy', data: "+values+", pointPlacement: 'on', colorByPoint: true}]};")
-	data.Set("scale", "2")
-	data.Set("type", "image/png")
-	data.Set("constr", "Chart")
-	data.Set("async", "true")
-
-	resp, err := client.PostForm(exportURL, data)
-	if err != nil {
-		return "", nil
-	}
+	return "{chart: {polar: true, type: 'column', spacing: [0, 0, 0, 0], margin: [20, 0, 0, 0], width: 600, height: 600}, navigation: {buttonOptions: {enabled: false}}, title: {text: 'Station " + stationID + ": Directional Wave Spectra', style: {font: '10px Helvetica, sans-serif'}}, subtitle: {text: 'Valid " + buoyTime + "', style: {font: '8px Helvetica, sans-serif'}}, legend: {enabled: false}, credits: {enabled: false}, pane: {startAngle: 0, endAngle: 360}, xAxis: {labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1, tickmarkPlacement: 'on', tickInterval: 45, min: 0, max: 360, minPadding: 0, maxPadding: 0}, yAxis: {labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1, " + options.energyAxis() + ", endOnTick: true, showLastLabel: true, labels: {formatter: function(){return this.value}}, reversedStacks: false}, plotOptions: {series: {stacking: null, shadow: false, groupPadding: 0, pointPlacement: 'on', pointWidth: 0.6}}, series: [{type: 'column', name: 'Energy', data: " + values + ", pointPlacement: 'on'}]};"
+}
 
-	defer resp.Body.Close()
-	plotFile, _ := ioutil.ReadAll(resp.Body)
-	return "https://export.highcharts.com/" + string(plotFile), err
+func fetchSpectraDistributionChart(ctx context.Context, client *http.Client, stationID string, buoyData surfnerd.BuoyDataItem, options SpectraChartOptions) (string, error) {
+	return fetchChartURL(ctx, client, spectraDistributionChartOptions(stationID, buoyData, options))
 }
 
-func fetchSpectraDistributionChart(client *http.Client, stationID string, buoyData surfnerd.BuoyDataItem) (string, error) {
+func spectraDistributionChartOptions(stationID string, buoyData surfnerd.BuoyDataItem, options SpectraChartOptions) string {
+	// Color the points the same way as the directional columns
+	maxEnergy := options.peakEnergy(buoyData.WaveSpectra.Energies)
+
 	values := "["
-	for index, freq := range buoyData.WaveSpectra.Frequencies {
+	for index, point := range spectraDistributionSeries(buoyData).Points {
 		if index > 0 {
 			values += ","
 		}
-		values += "[" + strconv.FormatFloat(1.0/freq, 'f', 2, 64) + "," + strconv.FormatFloat(buoyData.WaveSpectra.Energies[index], 'f', 2, 64) + "]"
+		gradColor := energyColor(options.Gradient, point[1], maxEnergy)
+		values += "{x: " + strconv.FormatFloat(point[0], 'f', 2, 64) + ", y: " + options.energyValue(point[1], maxEnergy) + ", color: " + chartColor(gradColor, 1.0) + "}"
 	}
 	values += "]"
 
 	buoyTime := buoyData.Date.Format("01/02/2006 15:04 UTC")
 
-	exportURL := "http://export.highcharts.com"
-	data := url.Values{}
-	data.Set("content", "options")
-	data.Set("options", "{chart: {type: 'line', width: 600}, navigation: {buttonOptions: {enabled: false}}, title: {text: 'Station "+stationID+": Wave Spectra', style: {font: '10px Helvetica, sans-serif'}}, subtitle: {text: 'Valid "+buoyTime+"', style: {font: '8px Helvetica, sans-serif'}}, legend: {enabled: false}, credits: {enabled: false}, xAxis: {labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, min: 0, max: 20, title: {text: 'Period (s)'}, gridLineWidth: 1, tickmarkPlacement: 'on', minPadding: 0, maxPadding: 0}, yAxis: {labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1, min: 0, endOnTick: true, showLastLabel: true, title: {useHTML: true, text: 'Energy (m<sup>2</sup>/Hz)'}, labels: {formatter: function(){return this.value}}, reversedStacks: false}, plotOptions: {series: {stacking: null, shadow: false, groupPadding: 0}}, series: [{type: 'line', name: 'Energy', data: "+values+"}]};")
-	data.Set("scale", "2")
-	data.Set("type", "image/png")
-	data.Set("constr", "Chart")
-	data.Set("async", "true")
+	return "{chart: {type: 'line', width: 600}, navigation: {buttonOptions: {enabled: false}}, title: {text: 'Station " + stationID + ": Wave Spectra', style: {font: '10px Helvetica, sans-serif'}}, subtitle: {text: 'Valid " + buoyTime + "', style: {font: '8px Helvetica, sans-serif'}}, legend: {enabled: false}, credits: {enabled: false}, xAxis: {labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, min: 0, max: 20, title: {text: 'Period (s)'}, gridLineWidth: 1, tickmarkPlacement: 'on', minPadding: 0, maxPadding: 0}, yAxis: {labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1, " + options.energyAxis() + ", endOnTick: true, showLastLabel: true, labels: {formatter: function(){return this.value}}, reversedStacks: false}, plotOptions: {series: {stacking: null, shadow: false, groupPadding: 0}}, series: [{type: 'line', name: 'Energy', data: " + values + "}]};"
+}
+
+// Renders the chart options and returns a link to the image. The links from
+// export servers expire, so they are only cached as long as the station charts.
+func fetchChartURL(ctx context.Context, client *http.Client, options string) (string, error) {
+	backend := configuredChartBackend()
+	chart := Chart{Options: options}
+	cacheKey := chartExportCacheKey(backend, "url", chart, "image/png")
+	if item, cacheErr := memcache.Get(ctx, cacheKey); cacheErr == nil {
+		return string(item.Value), nil
+	}
 
-	resp, err := client.PostForm(exportURL, data)
-	if err != nil {
-		return "", nil
+	chartURL, renderErr := backend.RenderURL(ctx, client, chart)
+	if renderErr != nil {
+		return "", renderErr
 	}
 
-	defer resp.Body.Close()
-	plotFile, _ := ioutil.ReadAll(resp.Body)
-	return "https://export.highcharts.com/" + string(plotFile), err
+	memcache.Set(ctx, &memcache.Item{
+		Key:        cacheKey,
+		Value:      []byte(chartURL),
+		Expiration: chartCacheExpiration,
+	})
+	return chartURL, nil
 }
 
 func round(num float64) int {
@@ -0,0 +1,98 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// What the buoy page template renders. The page URL, description, and JSON-LD
+// feed the link preview metadata in the page head.
+type BuoyPage struct {
+	ClosestBuoy
+	PageURL     string
+	Title       string
+	Description string
+	JSONLD      template.JS
+	Palette     string
+	// The summary chart makes a better preview image than the spectra
+	// links, which expire
+	SummaryChart string
+	// Where a Home Assistant REST sensor can read the station from
+	SensorURL string
+	// First and last light for dawn patrol, on the observation's day
+	Astronomy Astronomy
+	// Live pages show the latest conditions and keep them up to date, while
+	// permalinks stay on their observation
+	Live bool
+}
+
+func newBuoyPage(r *http.Request, container ClosestBuoy) BuoyPage {
+	container.SetWaterBody()
+	page := BuoyPage{
+		ClosestBuoy: container,
+		PageURL:     buoyPermalink(requestBaseURL(r), container.BuoyStationID, container.BuoyData.Date),
+		Title:       "NDBC Station " + container.BuoyStationID,
+		Palette:     parsePalette(r),
+		Live:        mux.Vars(r)["epoch"] == "",
+	}
+	page.SummaryChart = requestBaseURL(r) + "/api/charts/summary/" + container.BuoyStationID + ".png?palette=" + page.Palette
+	page.SensorURL = requestBaseURL(r) + "/api/sensor/" + container.BuoyStationID
+	page.Astronomy = newAstronomy(container.BuoyLocation, container.BuoyData.Date.Add(time.Duration(container.BuoyLocation.Longitude/15.0*float64(time.Hour))).UTC())
+	if container.BuoyLocation.LocationName != "" {
+		page.Title = container.BuoyLocation.LocationName + " - " + page.Title
+	}
+
+	swell := container.BuoyData.WaveSummary
+	if isValidReading(swell.WaveHeight, missingHeightMarker) {
+		page.Description = fmt.Sprintf("%.1f ft at %.1f s from the %s, observed %s", swell.WaveHeight, swell.Period, CompassDirection(swell.Direction), container.BuoyData.Date.UTC().Format("01/02/2006 15:04 UTC"))
+	}
+
+	if jsonLD, jsonLDErr := json.Marshal(newObservationJSONLD(page)); jsonLDErr == nil {
+		page.JSONLD = template.JS(jsonLD)
+	}
+
+	return page
+}
+
+// A schema.org Observation describing the wave summary. json.Marshal escapes
+// angle brackets so the output is safe to drop into a script tag.
+func newObservationJSONLD(page BuoyPage) map[string]interface{} {
+	swell := page.BuoyData.WaveSummary
+	values := []map[string]interface{}{}
+	addValue := func(name string, value float64, unit string, valid bool) {
+		if valid {
+			values = append(values, map[string]interface{}{
+				"@type":    "PropertyValue",
+				"name":     name,
+				"value":    ToFixedPoint(value, 2),
+				"unitText": unit,
+			})
+		}
+	}
+	addValue("Wave Height", swell.WaveHeight, "ft", isValidReading(swell.WaveHeight, missingHeightMarker))
+	addValue("Dominant Period", swell.Period, "s", isValidReading(swell.Period, missingPeriodMarker))
+	addValue("Mean Wave Direction", swell.Direction, "degrees", isValidReading(swell.Direction, missingDirectionMarker))
+
+	return map[string]interface{}{
+		"@context":        "https://schema.org",
+		"@type":           "Observation",
+		"name":            page.Title,
+		"url":             page.PageURL,
+		"observationDate": page.BuoyData.Date.UTC().Format(time.RFC3339),
+		"observationAbout": map[string]interface{}{
+			"@type": "Place",
+			"name":  page.Title,
+			"geo": map[string]interface{}{
+				"@type":     "GeoCoordinates",
+				"latitude":  page.BuoyLocation.Latitude,
+				"longitude": page.BuoyLocation.Longitude,
+			},
+		},
+		"variableMeasured": values,
+	}
+}
@@ -0,0 +1,101 @@
+package buoyfinder
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mpiannucci/surfnerd"
+)
+
+// What a station measures, from the flags on the NDBC station list
+const (
+	CapabilityWaves        = "waves"
+	CapabilityMeteorology  = "meteorology"
+	CapabilityCurrents     = "currents"
+	CapabilityWaterQuality = "water_quality"
+	CapabilityDart         = "dart"
+)
+
+// The kinds of station on the NDBC list. C-MAN stations are fixed to a pier,
+// lighthouse, or platform and measure the weather but not the waves.
+const (
+	StationClassBuoy  = "buoy"
+	StationClassCMAN  = "cman"
+	StationClassDart  = "dart"
+	StationClassOther = "other"
+)
+
+// NDBC publishes no spectra for a station without a wave sensor, which would
+// otherwise surface as a parse failure
+var errNoWaveSensor = errors.New("The station has no wave sensor")
+
+func stationClass(station surfnerd.Buoy) string {
+	switch station.Type {
+	case "buoy":
+		return StationClassBuoy
+	case "fixed":
+		return StationClassCMAN
+	case "dart":
+		return StationClassDart
+	}
+	return StationClassOther
+}
+
+// Missing wave sensors end up as a not found rather than as whatever went
+// wrong upstream
+func waveFetchErrorStatus(err error, otherwise int) int {
+	if err == errNoWaveSensor {
+		return http.StatusNotFound
+	}
+	return otherwise
+}
+
+func stationCapabilities(station surfnerd.Buoy) []string {
+	if provider, _ := stationProvider(station.StationID); provider != nil {
+		return provider.Capabilities()
+	}
+
+	capabilities := []string{}
+	// Only the moored weather buoys carry wave sensors
+	if stationClass(station) == StationClassBuoy && station.Active == "y" {
+		capabilities = append(capabilities, CapabilityWaves)
+	}
+	if station.Active == "y" {
+		capabilities = append(capabilities, CapabilityMeteorology)
+	}
+	if station.Currents == "y" {
+		capabilities = append(capabilities, CapabilityCurrents)
+	}
+	if station.WaterQuality == "y" {
+		capabilities = append(capabilities, CapabilityWaterQuality)
+	}
+	if station.Dart == "y" {
+		capabilities = append(capabilities, CapabilityDart)
+	}
+	return capabilities
+}
+
+// A station in the listing, the same as in the NDBC list with its
+// capabilities spelled out
+type StationListing struct {
+	surfnerd.Buoy
+	Class        string
+	Capabilities []string
+}
+
+type StationsListing struct {
+	Stations []StationListing
+}
+
+// The stations with the capability, or all of them when it is empty
+func newStationsListing(stations *surfnerd.BuoyStations, capability string) StationsListing {
+	listing := StationsListing{Stations: []StationListing{}}
+	for _, station := range stations.Stations {
+		capabilities := stationCapabilities(station)
+		if capability != "" && !containsString(capabilities, capability) {
+			continue
+		}
+		listing.Stations = append(listing.Stations, StationListing{Buoy: station, Class: stationClass(station), Capabilities: capabilities})
+	}
+	return listing
+}
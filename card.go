@@ -0,0 +1,103 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+// Exported at twice the size, which is the 1200x630 that link previews and
+// social posts expect
+const (
+	conditionsCardWidth  = 600
+	conditionsCardHeight = 315
+)
+
+// A postable image of the latest conditions on a background colored by the
+// wave height, with a small spectra along the bottom
+func conditionsCardHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+	smoothing := parseSpectraSmoothing(r)
+	palette := parsePalette(r)
+
+	cacheKey := "card:" + stationID + ":" + palette + ":" + strconv.Itoa(smoothing) + ".png"
+	writeCachedImage(ctx, w, r, cacheKey, "image/png", func() ([]byte, int, error) {
+		buoy, buoyErr := fetchBuoyWithID(ctx, client, stationID)
+		if buoyErr != nil {
+			return nil, http.StatusNotFound, buoyErr
+		}
+		if fetchErr := fetchDetailedWaveBuoyData(client, buoy, 1, smoothing); fetchErr != nil {
+			return nil, waveFetchErrorStatus(fetchErr, http.StatusBadGateway), fetchErr
+		}
+		if len(buoy.BuoyData) == 0 {
+			return nil, http.StatusNotFound, errors.New("Station " + stationID + " has no wave spectra")
+		}
+
+		image, exportErr := exportChart(ctx, client, Chart{Options: conditionsCardOptions(buoy, buoy.BuoyData[0], gradientPalettes[palette]())}, "image/png")
+		if exportErr != nil {
+			return nil, http.StatusBadGateway, exportErr
+		}
+		return image, http.StatusOK, nil
+	})
+}
+
+// A string as a javascript literal, for text like station names that can hold
+// quotes
+func chartText(text string) string {
+	encoded, _ := json.Marshal(text)
+	return string(encoded)
+}
+
+func conditionsCardOptions(buoy *surfnerd.Buoy, buoyData surfnerd.BuoyDataItem, gradient Gradient) string {
+	// The missing markers are metric, so check the readings before converting
+	summary := buoyData.WaveSummary
+	validHeight := isValidReading(summary.WaveHeight, missingHeightMarker)
+	validPeriod := isValidReading(summary.Period, missingPeriodMarker)
+	height := summary.WaveHeight * metersToFeet
+
+	conditions := "No wave height reported"
+	background := waveHeightColor(gradient, 0)
+	if validHeight {
+		conditions = fmt.Sprintf("%.1f ft", height)
+		if validPeriod {
+			conditions += fmt.Sprintf(" @ %.0f s", summary.Period)
+		}
+		if direction := CompassDirection(summary.Direction); direction != "" {
+			conditions += " " + direction
+		}
+		background = waveHeightColor(gradient, height)
+	}
+	shade := background.BlendRgb(colorful.Color{}, 0.45)
+
+	title := "Station " + buoy.StationID
+	if buoy.Location != nil && buoy.LocationName != "" {
+		title = buoy.LocationName + " (" + buoy.StationID + ")"
+	}
+	observed := "Observed " + buoyData.Date.UTC().Format("Jan 2, 2006 15:04 UTC")
+
+	white := chartColor(colorful.Color{R: 1, G: 1, B: 1}, 1.0)
+	faint := chartColor(colorful.Color{R: 1, G: 1, B: 1}, 0.3)
+	return "{chart: {type: 'areaspline', width: " + strconv.Itoa(conditionsCardWidth) + ", height: " + strconv.Itoa(conditionsCardHeight) + ", marginTop: 150, spacing: [24, 24, 16, 24], backgroundColor: {linearGradient: {x1: 0, y1: 0, x2: 1, y2: 1}, stops: [[0, " + chartColor(background, 1.0) + "], [1, " + chartColor(shade, 1.0) + "]]}}, navigation: {buttonOptions: {enabled: false}}, " +
+		"title: {text: " + chartText(title) + ", align: 'left', style: {color: " + white + ", font: 'bold 18px Helvetica, sans-serif'}}, " +
+		"subtitle: {text: " + chartText(conditions+"<br/>"+observed) + ", align: 'left', style: {color: " + white + ", font: '24px Helvetica, sans-serif'}}, " +
+		"legend: {enabled: false}, credits: {enabled: true, text: 'buoyfinder', href: '', style: {color: " + white + "}}, tooltip: {enabled: false}, " +
+		"xAxis: {min: 0, max: 20, lineColor: " + faint + ", tickColor: " + faint + ", labels: {style: {color: " + white + "}}, title: {text: 'Period (s)', style: {color: " + white + "}}}, " +
+		"yAxis: {min: 0, gridLineColor: " + faint + ", labels: {enabled: false}, title: {text: null}}, " +
+		"plotOptions: {series: {animation: false, marker: {enabled: false}, lineColor: " + white + ", fillColor: " + faint + "}}, " +
+		"series: [{name: 'Energy', data: " + highchartsPoints(spectraDistributionSeries(buoyData).Points) + "}]};"
+}
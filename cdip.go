@@ -0,0 +1,234 @@
+package buoyfinder
+
+import (
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+)
+
+// CDIP serves every realtime buoy as a netcdf file on its THREDDS server,
+// which can hand back any slice of the variables as text over OPeNDAP
+const (
+	cdipCatalogURL  = "https://thredds.cdip.ucsd.edu/thredds/catalog/cdip/realtime/catalog.xml"
+	cdipOPeNDAPURL  = "https://thredds.cdip.ucsd.edu/thredds/dodsC/cdip/realtime/"
+	cdipMissingFill = -999.0
+)
+
+// Only the primary realtime file of each station, not the per deployment ones
+var cdipDatasetRegex = regexp.MustCompile(`^(\d{3})p1_rt\.nc$`)
+
+var cdipWaveTimeLengthRegex = regexp.MustCompile(`waveTime\[waveTime = (\d+)\]`)
+
+// Scripps Institution of Oceanography's Coastal Data Information Program,
+// which runs most of the wave buoys off the US West Coast
+type cdipProvider struct{}
+
+func (cdipProvider) Prefix() string {
+	return "CDIP"
+}
+
+// The buoys only measure waves and sea surface temperature
+func (cdipProvider) Capabilities() []string {
+	return []string{CapabilityWaves}
+}
+
+type cdipCatalog struct {
+	Datasets []cdipCatalogDataset `xml:"dataset"`
+}
+
+type cdipCatalogDataset struct {
+	Name     string               `xml:"name,attr"`
+	Datasets []cdipCatalogDataset `xml:"dataset"`
+}
+
+func (self cdipProvider) FetchStations(client *http.Client) ([]surfnerd.Buoy, error) {
+	resp, fetchErr := client.Get(cdipCatalogURL)
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("The CDIP catalog could not be fetched: " + resp.Status)
+	}
+
+	catalog := cdipCatalog{}
+	if decodeErr := xml.NewDecoder(resp.Body).Decode(&catalog); decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	stationIDs := []string{}
+	var collect func(datasets []cdipCatalogDataset)
+	collect = func(datasets []cdipCatalogDataset) {
+		for _, dataset := range datasets {
+			if match := cdipDatasetRegex.FindStringSubmatch(dataset.Name); match != nil {
+				stationIDs = append(stationIDs, match[1])
+			}
+			collect(dataset.Datasets)
+		}
+	}
+	collect(catalog.Datasets)
+
+	// The catalog has no locations, so each station's is read from its file
+	stations := make([]*surfnerd.Buoy, len(stationIDs))
+	wg := sync.WaitGroup{}
+	for index, stationID := range stationIDs {
+		wg.Add(1)
+		go func(index int, stationID string) {
+			defer wg.Done()
+			values, valuesErr := fetchCDIPValues(client, stationID, "metaLatitude,metaLongitude")
+			if valuesErr != nil || len(values["metaLatitude"]) == 0 || len(values["metaLongitude"]) == 0 {
+				return
+			}
+			stations[index] = &surfnerd.Buoy{
+				StationID: self.Prefix() + stationID,
+				Owner:     "Scripps Institution of Oceanography",
+				PGM:       "CDIP",
+				Type:      "buoy",
+				Active:    "y",
+				Location: &surfnerd.Location{
+					Latitude:     values["metaLatitude"][0],
+					Longitude:    values["metaLongitude"][0],
+					LocationName: "CDIP " + stationID,
+				},
+			}
+		}(index, stationID)
+	}
+	wg.Wait()
+
+	buoys := []surfnerd.Buoy{}
+	for _, station := range stations {
+		if station != nil {
+			buoys = append(buoys, *station)
+		}
+	}
+	return buoys, nil
+}
+
+func (cdipProvider) FetchObservations(client *http.Client, stationID string, count int) ([]surfnerd.BuoyDataItem, error) {
+	length, lengthErr := fetchCDIPWaveTimeLength(client, stationID)
+	if lengthErr != nil {
+		return nil, lengthErr
+	}
+	if length == 0 {
+		return []surfnerd.BuoyDataItem{}, nil
+	}
+
+	first := length - count
+	if first < 0 {
+		first = 0
+	}
+	slice := "[" + strconv.Itoa(first) + ":1:" + strconv.Itoa(length-1) + "]"
+	values, valuesErr := fetchCDIPValues(client, stationID, "waveTime"+slice+",waveHs"+slice+",waveTp"+slice+",waveTa"+slice+",waveDp"+slice)
+	if valuesErr != nil {
+		return nil, valuesErr
+	}
+
+	value := func(name string, index int, marker float64) float64 {
+		if index >= len(values[name]) || values[name][index] <= cdipMissingFill {
+			return marker
+		}
+		return values[name][index]
+	}
+
+	times := values["waveTime"]
+	observations := []surfnerd.BuoyDataItem{}
+	for index := len(times) - 1; index >= 0; index-- {
+		observation := newMissingObservation(time.Unix(int64(times[index]), 0).UTC())
+		observation.WaveSummary.WaveHeight = value("waveHs", index, missingHeightMarker)
+		observation.WaveSummary.Period = value("waveTp", index, missingPeriodMarker)
+		observation.WaveSummary.Direction = value("waveDp", index, missingDirectionMarker)
+		observation.WaveSummary.CompassDirection = CompassDirection(observation.WaveSummary.Direction)
+		observation.AveragePeriod = value("waveTa", index, missingPeriodMarker)
+		observations = append(observations, observation)
+	}
+	return observations, nil
+}
+
+// How many wave observations the station's realtime file holds, from its
+// dataset description
+func fetchCDIPWaveTimeLength(client *http.Client, stationID string) (int, error) {
+	resp, fetchErr := client.Get(cdipOPeNDAPURL + stationID + "p1_rt.nc.dds")
+	if fetchErr != nil {
+		return 0, fetchErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, errors.New("CDIP station " + stationID + " could not be found: " + resp.Status)
+	}
+
+	contents, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil {
+		return 0, readErr
+	}
+	match := cdipWaveTimeLengthRegex.FindSubmatch(contents)
+	if match == nil {
+		return 0, errors.New("CDIP station " + stationID + " has no wave observations")
+	}
+	return strconv.Atoi(string(match[1]))
+}
+
+// Reads the variables in the constraint as text, keyed by variable name
+func fetchCDIPValues(client *http.Client, stationID, constraint string) (map[string][]float64, error) {
+	resp, fetchErr := client.Get(cdipOPeNDAPURL + stationID + "p1_rt.nc.ascii?" + constraint)
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("CDIP station " + stationID + " could not be read: " + resp.Status)
+	}
+
+	contents, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil {
+		return nil, readErr
+	}
+	return parseOPeNDAPASCII(string(contents)), nil
+}
+
+// The ascii response repeats the dataset description, then after a line of
+// dashes lists each variable as its name, like waveHs[3], followed by its
+// comma separated values. Scalars put their value on the same line as the
+// name.
+func parseOPeNDAPASCII(contents string) map[string][]float64 {
+	values := map[string][]float64{}
+	separator := strings.Index(contents, "\n---")
+	if separator < 0 {
+		return values
+	}
+
+	lines := strings.Split(contents[separator+1:], "\n")[1:]
+	name := ""
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			name = ""
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		if name == "" {
+			name = strings.TrimSpace(fields[0])
+			if bracket := strings.Index(name, "["); bracket >= 0 {
+				name = name[:bracket]
+			}
+			fields = fields[1:]
+		}
+		for _, field := range fields {
+			if value, parseErr := strconv.ParseFloat(strings.TrimSpace(field), 64); parseErr == nil {
+				values[name] = append(values[name], value)
+			}
+		}
+	}
+	return values
+}
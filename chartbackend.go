@@ -0,0 +1,225 @@
+package buoyfinder
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// Where charts are rendered is set per deployment with the env_variables in
+// app.yaml. CHART_BACKEND is highcharts for the hosted export server, which is
+// the default, selfhosted for an export server at CHART_EXPORT_URL, or native
+// to draw the line charts here and send the rest to the export server.
+const chartBackendEnv = "CHART_BACKEND"
+const chartExportURLEnv = "CHART_EXPORT_URL"
+
+// A self hosted export server can sit behind basic auth or an api key, set
+// with CHART_EXPORT_AUTH as basic or apikey. The credentials are kept out of
+// app.yaml in the chartexport secret, as user:password for basic auth. The
+// key is sent in CHART_EXPORT_KEY_HEADER, X-API-Key unless it is set.
+const chartExportAuthEnv = "CHART_EXPORT_AUTH"
+const chartExportKeyHeaderEnv = "CHART_EXPORT_KEY_HEADER"
+const chartExportSecretKey = "chartexport"
+const defaultChartExportKeyHeader = "X-API-Key"
+
+// The credentials are kept in memory once loaded, like the cookie secret
+var chartExportCredentials struct {
+	sync.Mutex
+	value string
+}
+
+// Async renders answer with the path of the image, which is all a link can
+// be built from
+const maxChartPathLength = 256
+
+var errChartNotDrawable = errors.New("The chart cannot be drawn natively")
+
+type ChartBackend interface {
+	// Identifies the backend in cache keys, since backends draw the same
+	// chart differently
+	Name() string
+	// Renders the chart as an image of the type
+	Render(ctx context.Context, client *http.Client, chart Chart, imageType string) ([]byte, error)
+	// Renders the chart as a png and returns a link to it, for responses that
+	// embed charts by url
+	RenderURL(ctx context.Context, client *http.Client, chart Chart) (string, error)
+}
+
+// A chart as highcharts options, which every export server understands. Line
+// charts over time also carry their series so backends that draw charts
+// themselves do not have to read the options.
+type Chart struct {
+	Options string
+	Lines   *vegaChart
+}
+
+// Wraps the options of a chart that only export servers can draw
+func optionsChart(options string, err error) (Chart, error) {
+	return Chart{Options: options}, err
+}
+
+func configuredChartBackend() ChartBackend {
+	hosted := highchartsChartBackend{ExportURL: highchartsExportURL}
+
+	// Without a usable url there is nothing to self host, so the hosted
+	// server is used
+	selfHosted := hosted
+	exportURL := strings.TrimRight(os.Getenv(chartExportURLEnv), "/")
+	if parsed, parseErr := url.Parse(exportURL); parseErr == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "" {
+		selfHosted = highchartsChartBackend{
+			ExportURL: exportURL,
+			Auth:      strings.ToLower(os.Getenv(chartExportAuthEnv)),
+			KeyHeader: os.Getenv(chartExportKeyHeaderEnv),
+		}
+		if selfHosted.KeyHeader == "" {
+			selfHosted.KeyHeader = defaultChartExportKeyHeader
+		}
+	}
+
+	switch strings.ToLower(os.Getenv(chartBackendEnv)) {
+	case "selfhosted":
+		return selfHosted
+	case "native":
+		return nativeChartBackend{Fallback: selfHosted}
+	}
+	return hosted
+}
+
+// A highcharts export server, either the hosted one or one run for this
+// deployment. Async renders are linked from the same url the chart was
+// posted to.
+type highchartsChartBackend struct {
+	ExportURL string
+	// basic, apikey, or empty for none
+	Auth      string
+	KeyHeader string
+}
+
+func (self highchartsChartBackend) Name() string {
+	return "highcharts:" + self.ExportURL
+}
+
+func (self highchartsChartBackend) exportValues(chart Chart, imageType string) url.Values {
+	data := url.Values{}
+	data.Set("content", "options")
+	data.Set("options", chart.Options)
+	data.Set("scale", "2")
+	data.Set("type", imageType)
+	data.Set("constr", "Chart")
+	return data
+}
+
+// Posts the export form, signed with the configured credentials
+func (self highchartsChartBackend) post(ctx context.Context, client *http.Client, data url.Values) (*http.Response, error) {
+	req, reqErr := http.NewRequest("POST", self.ExportURL, strings.NewReader(data.Encode()))
+	if reqErr != nil {
+		return nil, reqErr
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if self.Auth == "basic" || self.Auth == "apikey" {
+		credentials, credentialsErr := fetchChartExportCredentials(ctx)
+		if credentialsErr != nil {
+			return nil, errors.New("The chart export credentials could not be loaded: " + credentialsErr.Error())
+		}
+		if self.Auth == "basic" {
+			parts := strings.SplitN(credentials, ":", 2)
+			if len(parts) < 2 {
+				return nil, errors.New("The chart export credentials must be user:password for basic auth")
+			}
+			req.SetBasicAuth(parts[0], parts[1])
+		} else {
+			req.Header.Set(self.KeyHeader, credentials)
+		}
+	}
+
+	return client.Do(req)
+}
+
+func (self highchartsChartBackend) Render(ctx context.Context, client *http.Client, chart Chart, imageType string) ([]byte, error) {
+	resp, err := self.post(ctx, client, self.exportValues(chart, imageType))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("The chart could not be rendered: " + resp.Status)
+	}
+	// Export servers answer errors like a bad chart with an html page and a
+	// 200, which would be served as a broken image
+	if contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); contentType != imageType {
+		return nil, errors.New("The chart export server returned " + resp.Header.Get("Content-Type") + " instead of " + imageType)
+	}
+	return readUpstreamBody(resp, maxChartResponseSize)
+}
+
+func (self highchartsChartBackend) RenderURL(ctx context.Context, client *http.Client, chart Chart) (string, error) {
+	data := self.exportValues(chart, "image/png")
+	data.Set("async", "true")
+
+	resp, err := self.post(ctx, client, data)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New("The chart could not be rendered: " + resp.Status)
+	}
+	plotFile, readErr := ioutil.ReadAll(io.LimitReader(resp.Body, maxChartPathLength+1))
+	if readErr != nil {
+		return "", readErr
+	}
+	path := strings.TrimSpace(string(plotFile))
+	if path == "" || len(path) > maxChartPathLength || strings.ContainsAny(path, "<> \t\n\"") {
+		return "", errors.New("The chart export server did not return a link to the chart")
+	}
+	return self.ExportURL + "/" + strings.TrimLeft(path, "/"), nil
+}
+
+func fetchChartExportCredentials(ctx context.Context) (string, error) {
+	chartExportCredentials.Lock()
+	defer chartExportCredentials.Unlock()
+	if chartExportCredentials.value != "" {
+		return chartExportCredentials.value, nil
+	}
+
+	credentials, secretErr := fetchSecret(ctx, chartExportSecretKey)
+	if secretErr != nil {
+		return "", secretErr
+	}
+	chartExportCredentials.value = strings.TrimSpace(string(credentials))
+	return chartExportCredentials.value, nil
+}
+
+// Draws the line charts as svg without leaving the app. Anything else, like
+// pngs and the polar spectra, goes to the fallback.
+type nativeChartBackend struct {
+	Fallback ChartBackend
+}
+
+func (self nativeChartBackend) Name() string {
+	return "native:" + self.Fallback.Name()
+}
+
+func (self nativeChartBackend) Render(ctx context.Context, client *http.Client, chart Chart, imageType string) ([]byte, error) {
+	if chart.Lines != nil && imageType == chartImageTypes["svg"] {
+		if image, drawErr := newLineChartSVG(*chart.Lines); drawErr != errChartNotDrawable {
+			return image, drawErr
+		}
+	}
+	return self.Fallback.Render(ctx, client, chart, imageType)
+}
+
+func (self nativeChartBackend) RenderURL(ctx context.Context, client *http.Client, chart Chart) (string, error) {
+	return self.Fallback.RenderURL(ctx, client, chart)
+}
@@ -0,0 +1,147 @@
+package buoyfinder
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+// The prepared points of a chart for clients that draw their own. Each point
+// is an [x, y] pair and times are milliseconds since the epoch, the way most
+// charting libraries take them.
+type ChartSeries struct {
+	Name   string
+	XTitle string
+	YTitle string
+	Points [][]float64
+}
+
+type SpectraChartData struct {
+	Date         time.Time
+	Distribution ChartSeries
+	Directional  ChartSeries
+}
+
+func spectraChartDataHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+
+	buoy := &surfnerd.Buoy{StationID: stationID}
+	if fetchErr := fetchDetailedWaveBuoyData(client, buoy, 1, parseSpectraSmoothing(r)); fetchErr != nil {
+		writeErrorResponse(w, r, waveFetchErrorStatus(fetchErr, http.StatusInternalServerError), fetchErr)
+		return
+	}
+	if len(buoy.BuoyData) == 0 {
+		writeErrorResponse(w, r, http.StatusNotFound, errors.New("Station "+stationID+" has no wave spectra"))
+		return
+	}
+
+	buoyData := buoy.BuoyData[0]
+	if wantsVegaSpec(r) {
+		writeVegaLiteJSON(w, r, spectraVegaLiteSpec(stationID, buoyData))
+		return
+	}
+
+	envelope := newResponseEnvelope(r, client, SpectraChartData{
+		Date:         buoyData.Date,
+		Distribution: spectraDistributionSeries(buoyData),
+		Directional:  directionalSpectraSeries(buoyData),
+	})
+	envelope.SetObservation(stationID, buoyData.Date)
+	writeEnvelope(w, r, http.StatusOK, envelope)
+}
+
+func waveHeightChartDataHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+	hours := parseChartSpan(r, "hours", defaultWaveHeightChartHours, maxHistoryHours)
+
+	observations, fetchErr := fetchStandardObservationsSince(client, stationID, time.Now().Add(-time.Duration(hours)*time.Hour))
+	if fetchErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, fetchErr)
+		return
+	}
+	for index := range observations {
+		observations[index].ChangeUnits(surfnerd.English)
+	}
+
+	envelope := newResponseEnvelope(r, client, waveHeightSeries(observations))
+	if len(observations) > 0 {
+		envelope.SetObservation(stationID, observations[0].Date)
+	}
+	writeEnvelope(w, r, http.StatusOK, envelope)
+}
+
+// The spectra distribution over the directional spectra, the same charts the
+// wave endpoints link to as images
+func spectraVegaLiteSpec(stationID string, buoyData surfnerd.BuoyDataItem) map[string]interface{} {
+	subtitle := buoyData.Date.UTC().Format("01/02/2006 15:04 UTC")
+	return newVegaLiteConcatSpec("NDBC Station "+stationID+" Wave Spectra", []vegaChart{
+		{
+			Title:    "Spectra Distribution",
+			Subtitle: subtitle,
+			Series:   []ChartSeries{spectraDistributionSeries(buoyData)},
+			XType:    "quantitative",
+		},
+		{
+			Title:    "Directional Spectra",
+			Subtitle: subtitle,
+			Series:   []ChartSeries{directionalSpectraSeries(buoyData)},
+			XType:    "quantitative",
+			XDomain:  []float64{0, 360},
+			Mark:     "point",
+		},
+	})
+}
+
+// Energy by period, the longest periods first
+func spectraDistributionSeries(buoyData surfnerd.BuoyDataItem) ChartSeries {
+	series := ChartSeries{Name: "Energy", XTitle: "Period (s)", YTitle: "Energy (m^2/Hz)", Points: [][]float64{}}
+	for index, freq := range buoyData.WaveSpectra.Frequencies {
+		if index >= len(buoyData.WaveSpectra.Energies) || freq <= 0 {
+			continue
+		}
+		series.Points = append(series.Points, []float64{ToFixedPoint(1.0/freq, 2), buoyData.WaveSpectra.Energies[index]})
+	}
+	return series
+}
+
+// Energy by the mean direction of each frequency band
+func directionalSpectraSeries(buoyData surfnerd.BuoyDataItem) ChartSeries {
+	series := ChartSeries{Name: "Energy", XTitle: "Direction (degrees)", YTitle: "Energy (m^2/Hz)", Points: [][]float64{}}
+	for index, energy := range buoyData.WaveSpectra.Energies {
+		if index >= len(buoyData.WaveSpectra.Angles) {
+			continue
+		}
+		series.Points = append(series.Points, []float64{ToFixedPoint(buoyData.WaveSpectra.Angles[index], 2), energy})
+	}
+	return series
+}
+
+// The valid wave heights oldest first. The observations are expected newest
+// first, the way ndbc lists them.
+func waveHeightSeries(observations []surfnerd.BuoyDataItem) ChartSeries {
+	series := ChartSeries{Name: "Wave Height", XTitle: "Time", YTitle: "Wave Height (ft)", Points: [][]float64{}}
+	for index := len(observations) - 1; index >= 0; index-- {
+		item := observations[index]
+		if !isValidReading(item.WaveSummary.WaveHeight, missingHeightMarker) {
+			continue
+		}
+		series.Points = append(series.Points, []float64{float64(item.Date.Unix() * 1000), ToFixedPoint(item.WaveSummary.WaveHeight, 2)})
+	}
+	return series
+}
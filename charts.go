@@ -0,0 +1,227 @@
+package buoyfinder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucasb-eyer/go-colorful"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine/memcache"
+)
+
+const highchartsExportURL = "https://export.highcharts.com"
+
+// Rendered charts only change when a new observation comes in
+const chartCacheExpiration = 30 * time.Minute
+
+// The same options always render the same image, so exports can be kept for
+// much longer than the charts that are keyed by station
+const chartExportCacheExpiration = 24 * time.Hour
+
+// The image formats charts can be rendered as, keyed by file extension
+var chartImageTypes = map[string]string{
+	"png": "image/png",
+	"svg": "image/svg+xml",
+}
+
+// The points formatted as highcharts [x, y] pairs
+func highchartsPoints(points [][]float64) string {
+	values := "["
+	for index, point := range points {
+		if index > 0 {
+			values += ","
+		}
+		values += "[" + strconv.FormatFloat(point[0], 'f', -1, 64) + "," + strconv.FormatFloat(point[1], 'f', -1, 64) + "]"
+	}
+	values += "]"
+	return values
+}
+
+// Exports are keyed by a hash of what is posted, so identical observation
+// data is only ever rendered once no matter which endpoint asks for it
+func chartExportCacheKey(backend ChartBackend, kind string, chart Chart, imageType string) string {
+	sum := sha256.Sum256([]byte(backend.Name() + "\n" + imageType + "\n" + chart.Options))
+	return "export:" + kind + ":" + hex.EncodeToString(sum[:])
+}
+
+// Renders the chart with the deployment's backend and returns the image itself
+// rather than a link to it, so it can be served from our own urls
+func exportChart(ctx context.Context, client *http.Client, chart Chart, imageType string) ([]byte, error) {
+	backend := configuredChartBackend()
+	cacheKey := chartExportCacheKey(backend, "image", chart, imageType)
+	if item, cacheErr := memcache.Get(ctx, cacheKey); cacheErr == nil {
+		return item.Value, nil
+	}
+
+	image, renderErr := backend.Render(ctx, client, chart, imageType)
+	if renderErr != nil {
+		return nil, renderErr
+	}
+
+	memcache.Set(ctx, &memcache.Item{
+		Key:        cacheKey,
+		Value:      image,
+		Expiration: chartExportCacheExpiration,
+	})
+	return image, nil
+}
+
+// Reads how far back a history chart reaches from a query param like ?days= or
+// ?hours=
+func parseChartSpan(r *http.Request, param string, defaultSpan, maxSpan int) int {
+	span, spanErr := strconv.Atoi(r.URL.Query().Get(param))
+	if spanErr != nil || span <= 0 {
+		return defaultSpan
+	} else if span > maxSpan {
+		return maxSpan
+	}
+	return span
+}
+
+// Serves a chart image from the cache, rendering the chart from buildChart
+// when it is not cached
+func writeCachedChart(ctx context.Context, w http.ResponseWriter, r *http.Request, client *http.Client, cacheKey, extension string, buildChart func() (Chart, error)) {
+	if wantsVegaSpec(r) {
+		writeErrorResponse(w, r, http.StatusNotFound, errors.New("This chart is not available as a Vega-Lite spec"))
+		return
+	}
+
+	imageType, ok := chartImageTypes[strings.ToLower(extension)]
+	if !ok {
+		writeErrorResponse(w, r, http.StatusNotFound, errors.New("Charts can only be rendered as png or svg"))
+		return
+	}
+
+	writeCachedImage(ctx, w, r, cacheKey+"."+extension, imageType, func() ([]byte, int, error) {
+		chart, chartErr := buildChart()
+		if chartErr != nil {
+			return nil, http.StatusInternalServerError, chartErr
+		}
+
+		image, exportErr := exportChart(ctx, client, chart, imageType)
+		if exportErr != nil {
+			return nil, http.StatusBadGateway, exportErr
+		}
+		return image, http.StatusOK, nil
+	})
+}
+
+// Serves an image from the cache, rendering it when it is not cached. render
+// returns the status code to respond with when it fails.
+func writeCachedImage(ctx context.Context, w http.ResponseWriter, r *http.Request, cacheKey, imageType string, render func() ([]byte, int, error)) {
+	cacheKey = "chart:" + cacheKey
+	image := []byte{}
+	if item, cacheErr := memcache.Get(ctx, cacheKey); cacheErr == nil {
+		image = item.Value
+	} else {
+		rendered, status, renderErr := render()
+		if renderErr != nil {
+			writeErrorResponse(w, r, status, renderErr)
+			return
+		}
+		image = rendered
+
+		memcache.Set(ctx, &memcache.Item{
+			Key:        cacheKey,
+			Value:      image,
+			Expiration: chartCacheExpiration,
+		})
+	}
+
+	w.Header().Set("Content-Type", imageType)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(image)
+}
+
+func chartColor(color colorful.Color, alpha float64) string {
+	return fmt.Sprintf("'rgba(%3.0f, %3.0f, %3.0f, %1.1f)'", color.R*255.0, color.G*255.0, color.B*255.0, alpha)
+}
+
+func peakEnergy(energies []float64) float64 {
+	peak := 0.0
+	for _, energy := range energies {
+		peak = math.Max(peak, energy)
+	}
+	return peak
+}
+
+// Spectra are colored by their share of the peak energy so the same color means
+// the same thing on every chart
+func energyColor(gradient Gradient, energy, peak float64) colorful.Color {
+	if peak <= 0 {
+		return gradient.GetInterpolatedColorForFraction(0)
+	}
+	return gradient.GetInterpolatedColorForFraction(energy / peak)
+}
+
+// How the spectra charts draw the energy axis
+type SpectraChartOptions struct {
+	Gradient Gradient
+	// Log scaled energy makes the small long period forerunners of a new swell
+	// visible next to the existing peak
+	LogScale bool
+	// Plots each energy as a fraction of the peak energy
+	Normalize bool
+	// Fixes the peak energy instead of using each spectrum's own, so a series
+	// of charts share one scale
+	PeakEnergy float64
+}
+
+func parseSpectraChartOptions(r *http.Request) SpectraChartOptions {
+	normalize, _ := strconv.ParseBool(r.URL.Query().Get("normalize"))
+	return SpectraChartOptions{
+		Gradient:  parseGradient(r),
+		LogScale:  r.URL.Query().Get("scale") == "log",
+		Normalize: normalize,
+	}
+}
+
+// The energy the colors and normalization are relative to
+func (self SpectraChartOptions) peakEnergy(energies []float64) float64 {
+	if self.PeakEnergy > 0 {
+		return self.PeakEnergy
+	}
+	return peakEnergy(energies)
+}
+
+// The y value to plot for the energy. Log axes cannot show zero so those points
+// are left out.
+func (self SpectraChartOptions) energyValue(energy, peak float64) string {
+	if self.Normalize {
+		if peak <= 0 {
+			return "null"
+		}
+		energy /= peak
+	}
+
+	if self.LogScale {
+		if energy <= 0 {
+			return "null"
+		}
+		return strconv.FormatFloat(energy, 'g', 4, 64)
+	} else if self.Normalize {
+		return strconv.FormatFloat(energy, 'f', 4, 64)
+	}
+	return strconv.FormatFloat(energy, 'f', 2, 64)
+}
+
+// The type, range, and title parts of the energy axis options
+func (self SpectraChartOptions) energyAxis() string {
+	axis := "min: 0, "
+	if self.LogScale {
+		axis = "type: 'logarithmic', "
+	}
+	if self.Normalize {
+		return axis + "max: 1, title: {text: 'Energy / Peak Energy'}"
+	} else if self.PeakEnergy > 0 {
+		axis += "max: " + strconv.FormatFloat(self.PeakEnergy, 'f', 2, 64) + ", "
+	}
+	return axis + "title: {useHTML: true, text: 'Energy (m<sup>2</sup>/Hz)'}"
+}
@@ -0,0 +1,123 @@
+package buoyfinder
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	svgChartWidth  = 600
+	svgChartHeight = 400
+	svgChartLeft   = 60
+	svgChartRight  = 20
+	svgChartTop    = 50
+	svgChartBottom = 40
+	// Room under the x axis labels for the series names
+	svgChartLegend = 20
+	svgChartTicks  = 5
+)
+
+// Draws the vega chart's series as lines over time, the same way the vega
+// spec describes them
+func newLineChartSVG(chart vegaChart) ([]byte, error) {
+	xMin, xMax := math.Inf(1), math.Inf(-1)
+	yMin, yMax := math.Inf(1), math.Inf(-1)
+	for _, series := range chart.Series {
+		for _, point := range series.Points {
+			xMin, xMax = math.Min(xMin, point[0]), math.Max(xMax, point[0])
+			yMin, yMax = math.Min(yMin, point[1]), math.Max(yMax, point[1])
+		}
+	}
+	if math.IsInf(xMin, 0) || xMin == xMax {
+		return nil, errChartNotDrawable
+	}
+
+	yStep := niceTickStep((yMax - yMin) / float64(svgChartTicks-1))
+	if yStep == 0 {
+		yStep = 1
+	}
+	yMin, yMax = math.Floor(yMin/yStep)*yStep, math.Ceil(yMax/yStep)*yStep
+	if yMin == yMax {
+		yMax += yStep
+	}
+
+	bottom := svgChartBottom
+	if len(chart.Series) > 1 {
+		bottom += svgChartLegend
+	}
+	plotWidth := float64(svgChartWidth - svgChartLeft - svgChartRight)
+	plotHeight := float64(svgChartHeight - svgChartTop - bottom)
+	x := func(value float64) float64 {
+		return svgChartLeft + (value-xMin)/(xMax-xMin)*plotWidth
+	}
+	y := func(value float64) float64 {
+		return svgChartTop + (yMax-value)/(yMax-yMin)*plotHeight
+	}
+
+	colors := chart.Colors
+	if len(colors) < len(chart.Series) {
+		colors = overlayColors(len(chart.Series), gradientPalettes[defaultPalette]())
+	}
+
+	svg := bytes.Buffer{}
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="Helvetica, sans-serif">`, svgChartWidth, svgChartHeight)
+	fmt.Fprintf(&svg, `<rect width="%d" height="%d" fill="#fff"/>`, svgChartWidth, svgChartHeight)
+	fmt.Fprintf(&svg, `<text x="%d" y="20" text-anchor="middle" font-size="14">%s</text>`, svgChartWidth/2, html.EscapeString(chart.Title))
+	if chart.Subtitle != "" {
+		fmt.Fprintf(&svg, `<text x="%d" y="36" text-anchor="middle" font-size="11" fill="#666">%s</text>`, svgChartWidth/2, html.EscapeString(chart.Subtitle))
+	}
+
+	// Grid lines with their labels
+	for value := yMin; value <= yMax+yStep/2; value += yStep {
+		fmt.Fprintf(&svg, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#e6e6e6"/>`, svgChartLeft, y(value), svgChartWidth-svgChartRight, y(value))
+		fmt.Fprintf(&svg, `<text x="%d" y="%.1f" text-anchor="end" font-size="11">%s</text>`, svgChartLeft-6, y(value)+4, strconv.FormatFloat(ToFixedPoint(value, 2), 'f', -1, 64))
+	}
+	for tick := 0; tick < svgChartTicks; tick++ {
+		value := xMin + float64(tick)*(xMax-xMin)/float64(svgChartTicks-1)
+		label := time.Unix(int64(value/1000), 0).UTC().Format("01/02 15:04")
+		fmt.Fprintf(&svg, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%.1f" stroke="#e6e6e6"/>`, x(value), svgChartTop, x(value), svgChartTop+plotHeight)
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%.1f" text-anchor="middle" font-size="11">%s</text>`, x(value), svgChartTop+plotHeight+16, label)
+	}
+
+	if len(chart.Series) > 0 {
+		fmt.Fprintf(&svg, `<text transform="translate(14 %.1f) rotate(-90)" text-anchor="middle" font-size="11">%s</text>`, svgChartTop+plotHeight/2, html.EscapeString(chart.Series[0].YTitle))
+	}
+
+	for index, series := range chart.Series {
+		points := bytes.Buffer{}
+		for _, point := range series.Points {
+			fmt.Fprintf(&points, "%.1f,%.1f ", x(point[0]), y(point[1]))
+		}
+		fmt.Fprintf(&svg, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`, colors[index], bytes.TrimSpace(points.Bytes()))
+
+		if len(chart.Series) > 1 {
+			legendX := svgChartLeft + index*int(plotWidth)/len(chart.Series)
+			legendY := svgChartHeight - svgChartLegend/2
+			fmt.Fprintf(&svg, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/>`, legendX, legendY-9, colors[index])
+			fmt.Fprintf(&svg, `<text x="%d" y="%d" font-size="11">%s</text>`, legendX+14, legendY, html.EscapeString(series.Name))
+		}
+	}
+
+	svg.WriteString("</svg>")
+	return svg.Bytes(), nil
+}
+
+// Rounds the step up to 1, 2, or 5 times a power of ten so the axis labels
+// are round numbers
+func niceTickStep(step float64) float64 {
+	if step <= 0 {
+		return 0
+	}
+
+	magnitude := math.Pow(10, math.Floor(math.Log10(step)))
+	for _, multiple := range []float64{1, 2, 5} {
+		if step <= multiple*magnitude {
+			return multiple * magnitude
+		}
+	}
+	return 10 * magnitude
+}
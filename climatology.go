@@ -0,0 +1,200 @@
+package buoyfinder
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mpiannucci/buoyfinder/archive"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/log"
+	"google.golang.org/appengine/memcache"
+)
+
+const stationClimatologyKind = "StationClimatology"
+
+// A month needs about two weeks of hourly observations before its
+// percentiles mean much
+const minClimatologyObservations = 300
+
+// The climatology only changes when another backfill runs
+const climatologyCacheExpiration = 24 * time.Hour
+
+// The wave height distribution of every archived observation in one calendar
+// month at a station. WaveHeightPercentiles holds the 0th through the 100th
+// percentile in meters.
+type StationClimatology struct {
+	StationID             string
+	Month                 int
+	Count                 int
+	FirstYear             int
+	LastYear              int
+	WaveHeightPercentiles []float64 `datastore:",noindex"`
+	Updated               time.Time
+}
+
+// Where the observation's wave height falls in the station's history for the
+// month, like the 87th percentile for August
+type ConditionsPercentile struct {
+	Percentile  int
+	Month       string
+	FirstYear   int
+	LastYear    int
+	Description string
+}
+
+func stationClimatologyKey(ctx context.Context, stationID string, month time.Month) *datastore.Key {
+	return datastore.NewKey(ctx, stationClimatologyKind, stationID+":"+strconv.Itoa(int(month)), 0, nil)
+}
+
+// Rebuilds the monthly climatologies for ?stations= from their archived
+// observations. The backfill queues it once it has loaded the archives.
+func climatologyHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 9*time.Minute)
+
+	stationIDs, stationsErr := parseStationList(r, maxBackfillStations)
+	if stationsErr != nil {
+		http.Error(w, stationsErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, stationID := range stationIDs {
+		months, buildErr := buildStationClimatology(ctx, stationID)
+		if buildErr != nil {
+			log.Errorf(ctx, "Could not build the climatology for %s: %v", stationID, buildErr)
+			continue
+		}
+		fmt.Fprintf(w, "Built %d months for %s\n", months, stationID)
+	}
+}
+
+func buildStationClimatology(ctx context.Context, stationID string) (int, error) {
+	heights := map[time.Month][]float64{}
+	years := map[time.Month][2]int{}
+
+	iterator := datastore.NewQuery(archivedObservationKind).Filter("StationID =", stationID).Run(ctx)
+	for {
+		observation := archive.Observation{}
+		if _, nextErr := iterator.Next(&observation); nextErr == datastore.Done {
+			break
+		} else if nextErr != nil {
+			return 0, nextErr
+		}
+		if !isValidReading(observation.WaveHeight, missingHeightMarker) {
+			continue
+		}
+
+		month, year := observation.Date.UTC().Month(), observation.Date.UTC().Year()
+		heights[month] = append(heights[month], observation.WaveHeight)
+		span, seen := years[month]
+		if !seen || year < span[0] {
+			span[0] = year
+		}
+		if year > span[1] {
+			span[1] = year
+		}
+		years[month] = span
+	}
+
+	built := 0
+	for month, values := range heights {
+		if len(values) < minClimatologyObservations {
+			continue
+		}
+
+		sort.Float64s(values)
+		climatology := &StationClimatology{
+			StationID:             stationID,
+			Month:                 int(month),
+			Count:                 len(values),
+			FirstYear:             years[month][0],
+			LastYear:              years[month][1],
+			WaveHeightPercentiles: make([]float64, 101),
+			Updated:               time.Now(),
+		}
+		for percentile := range climatology.WaveHeightPercentiles {
+			climatology.WaveHeightPercentiles[percentile] = values[int(math.Floor(float64(percentile)/100.0*float64(len(values)-1)+0.5))]
+		}
+
+		if _, putErr := datastore.Put(ctx, stationClimatologyKey(ctx, stationID, month), climatology); putErr != nil {
+			return built, putErr
+		}
+		memcache.Delete(ctx, "climatology:"+stationID+":"+strconv.Itoa(int(month)))
+		built++
+	}
+	return built, nil
+}
+
+func fetchStationClimatology(ctx context.Context, stationID string, month time.Month) (*StationClimatology, error) {
+	cacheKey := "climatology:" + stationID + ":" + strconv.Itoa(int(month))
+	climatology := &StationClimatology{}
+	if _, cacheErr := memcache.Gob.Get(ctx, cacheKey, climatology); cacheErr == nil {
+		return climatology, nil
+	}
+
+	if getErr := datastore.Get(ctx, stationClimatologyKey(ctx, stationID, month), climatology); getErr != nil {
+		return nil, getErr
+	}
+
+	memcache.Gob.Set(ctx, &memcache.Item{
+		Key:        cacheKey,
+		Object:     climatology,
+		Expiration: climatologyCacheExpiration,
+	})
+	return climatology, nil
+}
+
+// The percentile of the observation's wave height for its month, or nil when
+// the station has no archive to compare against
+func fetchConditionsPercentile(ctx context.Context, stationID string, data surfnerd.BuoyDataItem) *ConditionsPercentile {
+	height := data.WaveSummary.WaveHeight
+	if !isValidReading(height, missingHeightMarker) {
+		return nil
+	}
+	if data.Units == surfnerd.English {
+		height /= metersToFeet
+	}
+
+	month := data.Date.UTC().Month()
+	climatology, climatologyErr := fetchStationClimatology(ctx, stationID, month)
+	if climatologyErr != nil {
+		if climatologyErr != datastore.ErrNoSuchEntity {
+			log.Warningf(ctx, "Could not fetch the climatology for %s: %v", stationID, climatologyErr)
+		}
+		return nil
+	}
+
+	percentile := sort.SearchFloat64s(climatology.WaveHeightPercentiles, height)
+	if percentile > 100 {
+		percentile = 100
+	}
+	return &ConditionsPercentile{
+		Percentile:  percentile,
+		Month:       month.String(),
+		FirstYear:   climatology.FirstYear,
+		LastYear:    climatology.LastYear,
+		Description: "The wave height is in the " + ordinal(percentile) + " percentile for " + month.String() + " at this station",
+	}
+}
+
+func ordinal(number int) string {
+	suffix := "th"
+	if number%100 < 11 || number%100 > 13 {
+		switch number % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(number) + suffix
+}
@@ -1,18 +1,117 @@
 package buoyfinder
 
 import (
+	"encoding/json"
 	"time"
 
+	"github.com/mpiannucci/buoyfinder/geo"
 	"github.com/mpiannucci/surfnerd"
 )
 
 type ClosestBuoy struct {
-	RequestedLocation       surfnerd.Location
-	RequestedDate           time.Time
-	TimeDiffFound           time.Duration
-	BuoyStationID           string
-	BuoyLocation            surfnerd.Location
-	BuoyData                surfnerd.BuoyDataItem
-	DirectionalSpectraPlot  string `json:",omitempty"`
-	SpectraDistributionPlot string `json:",omitempty"`
+	RequestedLocation surfnerd.Location
+	RequestedDate     time.Time
+	TimeDiffFound     time.Duration
+	Interpolated      bool `json:",omitempty"`
+	BuoyStationID     string
+	BuoyStatus        string              `json:",omitempty"`
+	Warnings          []ConditionsWarning `json:",omitempty"`
+	BuoyLocation      surfnerd.Location
+	DistanceKM        float64 `json:",omitempty"`
+	DistanceNM        float64 `json:",omitempty"`
+	Bearing           float64 `json:",omitempty"`
+	BuoyData          surfnerd.BuoyDataItem
+	// Only set on the latest conditions
+	PreviousBuoyData *surfnerd.BuoyDataItem `json:",omitempty"`
+	Changes          *ObservationChanges    `json:",omitempty"`
+	SeaState         *SeaState              `json:",omitempty"`
+	// Only set for stations with an archive
+	Climatology *ConditionsPercentile `json:",omitempty"`
+	// Only set when the spectra were fetched
+	SpectralWaveHeight *SpectralWaveHeightCheck `json:",omitempty"`
+	SpectralPeaks      *SpectralPeaks           `json:",omitempty"`
+	// How much of the swell comes from the requested ?window= directions
+	SwellWindow *SwellWindow `json:",omitempty"`
+	// The lake the buoy is on, where the waves are called seas instead of
+	// swell. Both are left out on the ocean.
+	WaterBody string `json:",omitempty"`
+	WaveTerm  string `json:",omitempty"`
+	// Set when the water temperature was filled in from another source
+	WaterTemperatureSource *WaterTemperatureSource `json:",omitempty"`
+	// Every observation read to find BuoyData, newest first, only with
+	// ?history=true
+	History                 []surfnerd.BuoyDataItem `json:",omitempty"`
+	DirectionalSpectraPlot  string                  `json:",omitempty"`
+	SpectraDistributionPlot string                  `json:",omitempty"`
+	// Why a chart is missing, keyed by its field, so clients can offer a
+	// retry instead of a blank image
+	ChartErrors map[string]string `json:",omitempty"`
+
+	// Zero locations are left out unless ?verbose=true asks for every field,
+	// the way the response looked before
+	verbose bool
+}
+
+func (self *ClosestBuoy) setChartError(chart string, err error) {
+	if self.ChartErrors == nil {
+		self.ChartErrors = map[string]string{}
+	}
+	self.ChartErrors[chart] = err.Error()
+}
+
+// Fills in how far away and in which direction the buoy is from the requested
+// location. Station id requests have no requested location so are left alone.
+func (self *ClosestBuoy) SetDistance(algorithm geo.Algorithm) {
+	if !hasLocation(self.RequestedLocation) || !hasLocation(self.BuoyLocation) {
+		return
+	}
+
+	distance := distanceWith(algorithm, self.RequestedLocation, self.BuoyLocation)
+	self.DistanceKM = ToFixedPoint(distance, 2)
+	self.DistanceNM = ToFixedPoint(distance/kmPerNauticalMile, 2)
+	self.Bearing = ToFixedPoint(bearingBetween(self.RequestedLocation, self.BuoyLocation), 1)
+}
+
+type closestBuoyFields ClosestBuoy
+
+// Swaps in the quality controlled encoding of the buoy data so missing
+// readings come out as nulls instead of NDBC's placeholder values
+func (self ClosestBuoy) MarshalJSON() ([]byte, error) {
+	buoyData, buoyDataErr := qualityControlledJSON(self.BuoyData)
+	if buoyDataErr != nil {
+		return nil, buoyDataErr
+	}
+
+	var previousBuoyData json.RawMessage
+	if self.PreviousBuoyData != nil {
+		encoded, previousErr := qualityControlledJSON(*self.PreviousBuoyData)
+		if previousErr != nil {
+			return nil, previousErr
+		}
+		previousBuoyData = encoded
+	}
+
+	history, historyErr := qualityControlledJSONList(self.History)
+	if historyErr != nil {
+		return nil, historyErr
+	}
+
+	// Station id requests have no requested location, and stations missing
+	// from the active list have no buoy location
+	var requestedLocation, buoyLocation *surfnerd.Location
+	if self.verbose || hasLocation(self.RequestedLocation) {
+		requestedLocation = &self.RequestedLocation
+	}
+	if self.verbose || hasLocation(self.BuoyLocation) {
+		buoyLocation = &self.BuoyLocation
+	}
+
+	return json.Marshal(struct {
+		closestBuoyFields
+		RequestedLocation *surfnerd.Location `json:",omitempty"`
+		BuoyLocation      *surfnerd.Location `json:",omitempty"`
+		BuoyData          json.RawMessage
+		PreviousBuoyData  json.RawMessage   `json:",omitempty"`
+		History           []json.RawMessage `json:",omitempty"`
+	}{closestBuoyFields(self), requestedLocation, buoyLocation, buoyData, previousBuoyData, history})
 }
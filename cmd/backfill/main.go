@@ -0,0 +1,154 @@
+// Command backfill downloads NDBC historical archives and writes them as
+// newline delimited JSON for loading into a BigQuery archive table:
+//
+//	backfill -stations 44097,44017 -years 2010-2015 -out archive
+//	gsutil -m cp 'archive/*.json' gs://bucket/archive/
+//	bq load --source_format=NEWLINE_DELIMITED_JSON buoys.archive 'gs://bucket/archive/*.json' archive/schema.bq
+//
+// bq only expands wildcards in Cloud Storage paths, so the files are copied
+// there first. The table schema is written as schema.bq so the *.json
+// wildcard does not pick it up as rows.
+//
+// Every station and year gets its own file, and the files that already exist
+// are skipped, so running it again resumes an interrupted backfill. To load
+// the Datastore archive instead, run /tasks/backfill on the app with the same
+// stations and years.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpiannucci/buoyfinder/archive"
+)
+
+func main() {
+	stations := flag.String("stations", "", "Comma separated station ids")
+	years := flag.String("years", "", "A year like 2015 or a range like 2010-2015")
+	out := flag.String("out", "archive", "The directory to write the files to")
+	delay := flag.Duration("delay", 2*time.Second, "How long to wait between downloads")
+	flag.Parse()
+
+	stationIDs := []string{}
+	for _, stationID := range strings.Split(*stations, ",") {
+		if stationID = strings.ToUpper(strings.TrimSpace(stationID)); stationID != "" {
+			stationIDs = append(stationIDs, stationID)
+		}
+	}
+	if len(stationIDs) == 0 {
+		fail("At least one station is required")
+	}
+	backfillYears, yearsErr := archive.ParseYears(*years)
+	if yearsErr != nil {
+		fail(yearsErr.Error())
+	}
+
+	if mkdirErr := os.MkdirAll(*out, 0755); mkdirErr != nil {
+		fail(mkdirErr.Error())
+	}
+	if schemaErr := writeSchema(filepath.Join(*out, "schema.bq")); schemaErr != nil {
+		fail(schemaErr.Error())
+	}
+
+	client := &http.Client{Timeout: time.Minute}
+	failed := 0
+	for _, stationID := range stationIDs {
+		for _, year := range backfillYears {
+			path := filepath.Join(*out, stationID+"-"+strconv.Itoa(year)+".json")
+			if _, statErr := os.Stat(path); statErr == nil {
+				continue
+			}
+
+			observations, fetchErr := archive.Fetch(client, stationID, year)
+			time.Sleep(*delay)
+			if fetchErr == archive.ErrNotArchived {
+				if !archive.IsSettled(year) {
+					// No file yet, so running again picks it up once NDBC
+					// posts it
+					fmt.Printf("%s %d: not archived yet\n", stationID, year)
+					continue
+				}
+				// An empty file marks the year as done
+				observations = nil
+			} else if fetchErr != nil {
+				fmt.Fprintf(os.Stderr, "%s %d: %v\n", stationID, year, fetchErr)
+				failed++
+				continue
+			}
+
+			if writeErr := writeObservations(path, observations); writeErr != nil {
+				fmt.Fprintf(os.Stderr, "%s %d: %v\n", stationID, year, writeErr)
+				failed++
+				continue
+			}
+			fmt.Printf("%s %d: %d observations\n", stationID, year, len(observations))
+		}
+	}
+
+	if failed > 0 {
+		fail(strconv.Itoa(failed) + " station years failed, run again to retry them")
+	}
+}
+
+func fail(message string) {
+	fmt.Fprintln(os.Stderr, message)
+	os.Exit(1)
+}
+
+// Writes to a temporary file first so an interrupted write is not mistaken for
+// a finished one
+func writeObservations(path string, observations []archive.Observation) error {
+	partialPath := path + ".partial"
+	file, createErr := os.Create(partialPath)
+	if createErr != nil {
+		return createErr
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, observation := range observations {
+		if encodeErr := encoder.Encode(observation.Record()); encodeErr != nil {
+			file.Close()
+			return encodeErr
+		}
+	}
+
+	if flushErr := writer.Flush(); flushErr != nil {
+		file.Close()
+		return flushErr
+	}
+	if closeErr := file.Close(); closeErr != nil {
+		return closeErr
+	}
+	return os.Rename(partialPath, path)
+}
+
+// The BigQuery table schema matching the rows, as the JSON bq load takes
+func writeSchema(path string) error {
+	schema := []map[string]string{}
+	for _, column := range archive.Columns() {
+		switch column {
+		case "station_id":
+			schema = append(schema, map[string]string{"name": column, "type": "STRING", "mode": "REQUIRED"})
+		case "time":
+			schema = append(schema, map[string]string{"name": column, "type": "TIMESTAMP", "mode": "REQUIRED"})
+		default:
+			schema = append(schema, map[string]string{"name": column, "type": "FLOAT", "mode": "NULLABLE"})
+		}
+	}
+
+	encoded, encodeErr := json.MarshalIndent(schema, "", "  ")
+	if encodeErr != nil {
+		return encodeErr
+	}
+	return ioutil.WriteFile(path, encoded, 0644)
+}
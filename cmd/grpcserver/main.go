@@ -0,0 +1,212 @@
+// Command grpcserver runs the app standalone, serving the BuoyFinder service
+// from proto/buoyfinder.proto over gRPC alongside the usual HTTP handlers:
+//
+//	GRPC_PORT=9090 grpcserver
+//
+// HTTP is served on PORT through appengine.Main, so the datastore, memcache,
+// and urlfetch calls the handlers make need the bundled App Engine APIs. The
+// gRPC calls share the same fetch helpers, each with an App Engine context
+// and the same 20 second timeout as the handlers.
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/mpiannucci/buoyfinder"
+	"github.com/mpiannucci/buoyfinder/proto"
+	"github.com/mpiannucci/surfnerd"
+	"google.golang.org/appengine"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+const defaultGRPCPort = "9090"
+
+// How long a call waits on NDBC and the chart exporter
+const fetchTimeout = 20 * time.Second
+
+// How often the stream checks its stations for new observations. NDBC posts
+// them at most every half hour, and usually hourly.
+const streamPollInterval = 5 * time.Minute
+
+const maxStreamStations = 20
+
+type server struct {
+	buoyfinderpb.UnimplementedBuoyFinderServer
+}
+
+func main() {
+	port := os.Getenv("GRPC_PORT")
+	if port == "" {
+		port = defaultGRPCPort
+	}
+
+	listener, listenErr := net.Listen("tcp", ":"+port)
+	if listenErr != nil {
+		fail(listenErr.Error())
+	}
+
+	grpcServer := grpc.NewServer()
+	buoyfinderpb.RegisterBuoyFinderServer(grpcServer, &server{})
+	go func() {
+		if serveErr := grpcServer.Serve(listener); serveErr != nil {
+			fail(serveErr.Error())
+		}
+	}()
+
+	// Importing the app registered its handlers on the default mux
+	appengine.Main()
+}
+
+func fail(message string) {
+	fmt.Fprintln(os.Stderr, message)
+	os.Exit(1)
+}
+
+// The fetch helpers call the App Engine APIs, which a gRPC context knows
+// nothing about, so each call gets a background App Engine context that is
+// cancelled along with the call
+func newFetchContext(ctxParent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(appengine.BackgroundContext(), fetchTimeout)
+	go func() {
+		select {
+		case <-ctxParent.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+func (self *server) GetStations(ctxParent context.Context, req *buoyfinderpb.GetStationsRequest) (*buoyfinderpb.GetStationsResponse, error) {
+	ctx, cancel := newFetchContext(ctxParent)
+	defer cancel()
+
+	encoded, fetchErr := buoyfinder.FetchStationsMessage(ctx, buoyfinder.NewFetchClient(ctx))
+	if fetchErr != nil {
+		return nil, status.Error(codes.Unavailable, fetchErr.Error())
+	}
+
+	response := &buoyfinderpb.GetStationsResponse{}
+	return response, decode(encoded, response)
+}
+
+func (self *server) GetLatest(ctxParent context.Context, req *buoyfinderpb.GetLatestRequest) (*buoyfinderpb.ClosestBuoy, error) {
+	stationID, location, queryErr := parseBuoyQuery(req.GetBuoy())
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	ctx, cancel := newFetchContext(ctxParent)
+	defer cancel()
+
+	encoded, fetchErr := buoyfinder.FetchLatestMessage(ctx, buoyfinder.NewFetchClient(ctx), stationID, location)
+	if fetchErr != nil {
+		return nil, status.Error(codes.Unavailable, fetchErr.Error())
+	}
+
+	response := &buoyfinderpb.ClosestBuoy{}
+	return response, decode(encoded, response)
+}
+
+func (self *server) GetRange(ctxParent context.Context, req *buoyfinderpb.GetRangeRequest) (*buoyfinderpb.GetRangeResponse, error) {
+	stationID, location, queryErr := parseBuoyQuery(req.GetBuoy())
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	if req.GetStart() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "A start date is required")
+	}
+
+	ctx, cancel := newFetchContext(ctxParent)
+	defer cancel()
+
+	encoded, fetchErr := buoyfinder.FetchRangeMessage(ctx, buoyfinder.NewFetchClient(ctx), stationID, location, req.GetStart(), req.GetEnd())
+	if fetchErr != nil {
+		return nil, status.Error(codes.Unavailable, fetchErr.Error())
+	}
+
+	response := &buoyfinderpb.GetRangeResponse{}
+	return response, decode(encoded, response)
+}
+
+func (self *server) GetChart(ctxParent context.Context, req *buoyfinderpb.GetChartRequest) (*buoyfinderpb.GetChartResponse, error) {
+	stationID, location, queryErr := parseBuoyQuery(req.GetBuoy())
+	if queryErr != nil {
+		return nil, queryErr
+	}
+
+	ctx, cancel := newFetchContext(ctxParent)
+	defer cancel()
+
+	encoded, fetchErr := buoyfinder.FetchChartMessage(ctx, buoyfinder.NewFetchClient(ctx), stationID, location, int(req.GetType()), req.GetDate())
+	if fetchErr != nil {
+		return nil, status.Error(codes.Unavailable, fetchErr.Error())
+	}
+
+	response := &buoyfinderpb.GetChartResponse{}
+	return response, decode(encoded, response)
+}
+
+// Sends the latest conditions of every station right away, then again each
+// time one of them posts a new observation, until the client goes away
+func (self *server) StreamObservations(req *buoyfinderpb.StreamObservationsRequest, stream buoyfinderpb.BuoyFinder_StreamObservationsServer) error {
+	stationIDs := req.GetStationIds()
+	if len(stationIDs) == 0 || len(stationIDs) > maxStreamStations {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("Between 1 and %d stations can be streamed", maxStreamStations))
+	}
+
+	sent := map[string]int64{}
+	for {
+		for _, stationID := range stationIDs {
+			latest, latestErr := self.GetLatest(stream.Context(), &buoyfinderpb.GetLatestRequest{Buoy: &buoyfinderpb.BuoyQuery{StationId: stationID}})
+			if latestErr != nil {
+				// A station that is down for one check is tried again on the next
+				continue
+			}
+
+			date := latest.GetBuoyData().GetDate()
+			if date == sent[stationID] {
+				continue
+			}
+			if sendErr := stream.Send(latest); sendErr != nil {
+				return sendErr
+			}
+			sent[stationID] = date
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-time.After(streamPollInterval):
+		}
+	}
+}
+
+// Either the station id or the location, which picks the closest buoy
+func parseBuoyQuery(query *buoyfinderpb.BuoyQuery) (string, *surfnerd.Location, error) {
+	if query.GetStationId() != "" {
+		return query.GetStationId(), nil, nil
+	}
+	if query.GetLocation() == nil {
+		return "", nil, status.Error(codes.InvalidArgument, "Either a station id or a location is required")
+	}
+
+	location := surfnerd.NewLocationForLatLong(query.GetLocation().GetLatitude(), query.GetLocation().GetLongitude())
+	return "", &location, nil
+}
+
+// The app encodes the messages itself, so they are decoded into the
+// generated types rather than converted field by field
+func decode(encoded []byte, message protobuf.Message) error {
+	if unmarshalErr := protobuf.Unmarshal(encoded, message); unmarshalErr != nil {
+		return status.Error(codes.Internal, unmarshalErr.Error())
+	}
+	return nil
+}
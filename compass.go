@@ -0,0 +1,38 @@
+package buoyfinder
+
+import (
+	"math"
+
+	"github.com/mpiannucci/surfnerd"
+)
+
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+// The 16 point compass name for the direction in degrees, or an empty string
+// if the direction is missing
+func CompassDirection(degrees float64) string {
+	if !isValidReading(degrees, missingDirectionMarker) || degrees < 0 {
+		return ""
+	}
+
+	return compassPoints[compassPointIndex(degrees)]
+}
+
+func compassPointIndex(degrees float64) int {
+	return int(math.Floor(math.Mod(degrees, 360.0)/22.5+0.5)) % len(compassPoints)
+}
+
+// Recomputes the compass names of the wave summary and swell components so
+// they always agree with the degrees, even after interpolation
+func withCompassDirections(data surfnerd.BuoyDataItem) surfnerd.BuoyDataItem {
+	data.WaveSummary.CompassDirection = CompassDirection(data.WaveSummary.Direction)
+
+	swells := make([]surfnerd.Swell, len(data.SwellComponents))
+	for index, swell := range data.SwellComponents {
+		swell.CompassDirection = CompassDirection(swell.Direction)
+		swells[index] = swell
+	}
+	data.SwellComponents = swells
+
+	return data
+}
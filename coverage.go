@@ -0,0 +1,125 @@
+package buoyfinder
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+// The realtime files only reach back 45 days
+const maxCoverageDays = 45
+
+type ProductCoverage struct {
+	Product         string
+	Available       bool
+	ExpectedReports int
+	ReceivedReports int
+	Percentage      float64
+}
+
+type StationCoverage struct {
+	BuoyStationID string
+	Days          int
+	Start         time.Time
+	End           time.Time
+	Products      []ProductCoverage
+}
+
+func stationCoverageHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := vars["station"]
+
+	days := 30
+	if rawDays := r.URL.Query().Get("days"); rawDays != "" {
+		parsedDays, daysErr := strconv.Atoi(rawDays)
+		if daysErr != nil || parsedDays < 1 || parsedDays > maxCoverageDays {
+			writeErrorResponse(w, r, http.StatusBadRequest, errors.New("days must be between 1 and 45"))
+			return
+		}
+		days = parsedDays
+	}
+
+	coverage := fetchStationCoverage(client, stationID, days)
+
+	writeDataResponse(w, r, client, &coverage)
+}
+
+func fetchStationCoverage(client *http.Client, stationID string, days int) StationCoverage {
+	end := time.Now().UTC().Truncate(time.Hour)
+	start := end.AddDate(0, 0, -days)
+	expected := days * 24
+
+	var wg sync.WaitGroup
+	products := make([]ProductCoverage, len(statusProducts))
+	for index, product := range statusProducts {
+		wg.Add(1)
+		go func(index int, product string) {
+			defer wg.Done()
+			products[index] = ProductCoverage{
+				Product:         product,
+				ExpectedReports: expected,
+			}
+
+			reportedHours, fetchErr := fetchReportedHours(client, stationID, product, start, end)
+			if fetchErr != nil {
+				return
+			}
+			products[index].Available = true
+			products[index].ReceivedReports = reportedHours
+			products[index].Percentage = ToFixedPoint(float64(reportedHours)/float64(expected)*100.0, 1)
+		}(index, product)
+	}
+	wg.Wait()
+
+	return StationCoverage{
+		BuoyStationID: stationID,
+		Days:          days,
+		Start:         start,
+		End:           end,
+		Products:      products,
+	}
+}
+
+// Counts the hours in the range with at least one report. Some products like
+// cwind report every ten minutes, so the rows themselves can't be counted.
+func fetchReportedHours(client *http.Client, stationID, product string, start, end time.Time) (int, error) {
+	productResponse, productError := client.Get(realtimeProductURL(stationID, product))
+	if productError != nil {
+		return 0, productError
+	}
+	defer productResponse.Body.Close()
+
+	if productResponse.StatusCode != http.StatusOK {
+		return 0, errors.New("The station does not publish " + product + " data")
+	}
+
+	hours := map[int64]bool{}
+	scanner := bufio.NewScanner(io.LimitReader(productResponse.Body, maxNDBCResponseSize))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		date, dateErr := parseRealtimeRowDate(strings.Fields(line))
+		if dateErr != nil || !date.Before(end) || date.Before(start) {
+			continue
+		}
+		hours[date.Truncate(time.Hour).Unix()] = true
+	}
+
+	return len(hours), scanner.Err()
+}
@@ -0,0 +1,146 @@
+package buoyfinder
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+const missingWaterColumnHeightMarker = 9999.0
+
+const defaultDartHours = 24
+
+// DART buoys report every 15 minutes until they sense a tsunami, then switch
+// to one minute and 15 second readings until the event has passed
+var dartMeasurementTypes = map[string]string{
+	"1": "15-minute",
+	"2": "1-minute",
+	"3": "15-second",
+}
+
+// The height of the water column above the pressure recorder on the sea floor
+type DartReading struct {
+	Date            time.Time
+	MeasurementType string
+	Height          float64
+}
+
+type DartObservations struct {
+	BuoyStationID string
+	Latest        DartReading
+	// Set while the buoy is reporting faster than its 15 minute routine,
+	// which it only does during a tsunami event or a test
+	EventMode bool
+	Readings  []DartReading
+}
+
+func latestDartHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+	hours := parseChartSpan(r, "hours", defaultDartHours, maxHistoryHours)
+
+	readings, fetchErr := fetchDartReadings(client, stationID)
+	if fetchErr != nil {
+		writeErrorResponse(w, r, http.StatusBadGateway, fetchErr)
+		return
+	}
+	if len(readings) == 0 {
+		writeErrorResponse(w, r, http.StatusNotFound, errors.New("Station "+stationID+" has no water column heights"))
+		return
+	}
+
+	observations := DartObservations{
+		BuoyStationID: stationID,
+		Latest:        readings[0],
+		EventMode:     readings[0].MeasurementType != dartMeasurementTypes["1"],
+		Readings:      []DartReading{},
+	}
+	since := readings[0].Date.Add(-time.Duration(hours) * time.Hour)
+	for _, reading := range readings {
+		if reading.Date.Before(since) {
+			break
+		}
+		observations.Readings = append(observations.Readings, reading)
+	}
+
+	envelope := newResponseEnvelope(r, client, &observations)
+	envelope.SetObservation(stationID, observations.Latest.Date)
+	writeEnvelope(w, r, http.StatusOK, envelope)
+}
+
+// The valid water column heights, newest first
+func fetchDartReadings(client *http.Client, stationID string) ([]DartReading, error) {
+	resp, fetchErr := client.Get(realtimeDataURL + stationID + ".dart")
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errors.New("Station " + stationID + " is not a DART station")
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("The DART data could not be fetched: " + resp.Status)
+	}
+
+	contents, readErr := readNDBCBody(resp)
+	if readErr != nil {
+		return nil, readErr
+	}
+	readings := parseDartReadings(string(contents))
+
+	// Event mode readings are listed apart from the routine ones
+	sort.Slice(readings, func(i, j int) bool {
+		return readings[i].Date.After(readings[j].Date)
+	})
+	return readings, nil
+}
+
+// Reads the realtime .dart file, which has header lines starting with # and
+// then rows of year, month, day, hour, minute, second, measurement type, and
+// height in meters
+func parseDartReadings(contents string) []DartReading {
+	readings := []DartReading{}
+	for _, line := range strings.Split(contents, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		parts := make([]int, 6)
+		valid := true
+		for index := range parts {
+			value, parseErr := strconv.Atoi(fields[index])
+			if parseErr != nil {
+				valid = false
+				break
+			}
+			parts[index] = value
+		}
+		height, heightErr := strconv.ParseFloat(fields[7], 64)
+		if !valid || heightErr != nil || !isValidReading(height, missingWaterColumnHeightMarker) {
+			continue
+		}
+
+		measurementType, ok := dartMeasurementTypes[fields[6]]
+		if !ok {
+			measurementType = fields[6]
+		}
+		readings = append(readings, DartReading{
+			Date:            time.Date(parts[0], time.Month(parts[1]), parts[2], parts[3], parts[4], parts[5], 0, time.UTC),
+			MeasurementType: measurementType,
+			Height:          height,
+		})
+	}
+	return readings
+}
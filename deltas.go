@@ -0,0 +1,60 @@
+package buoyfinder
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+)
+
+// How the latest observation differs from the one before it, in metric, so
+// clients can show rising and falling arrows. Readings missing from either
+// observation are left out.
+type ObservationChanges struct {
+	Minutes        float64
+	WaveHeight     *float64 `json:",omitempty"`
+	DominantPeriod *float64 `json:",omitempty"`
+	WindSpeed      *float64 `json:",omitempty"`
+	Pressure       *float64 `json:",omitempty"`
+}
+
+// Latest requests are the ones without a date in the url
+func isLatestRequest(r *http.Request) bool {
+	return mux.Vars(r)["epoch"] == ""
+}
+
+// The observation reported just before the date, from the standard data
+func fetchPreviousObservation(client *http.Client, stationID string, date time.Time) (*surfnerd.BuoyDataItem, error) {
+	buoy := &surfnerd.Buoy{StationID: stationID}
+	if fetchErr := fetchStandardBuoyData(client, buoy, 2*observationsPerHour); fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	for _, item := range buoy.BuoyData {
+		if item.Date.Before(date) {
+			return &item, nil
+		}
+	}
+	return nil, nil
+}
+
+func newObservationChanges(latest, previous surfnerd.BuoyDataItem) *ObservationChanges {
+	changes := &ObservationChanges{
+		Minutes: ToFixedPoint(latest.Date.Sub(previous.Date).Minutes(), 0),
+	}
+
+	change := func(current, before, marker float64) *float64 {
+		if !isValidReading(current, marker) || !isValidReading(before, marker) {
+			return nil
+		}
+		value := ToFixedPoint(current-before, 2)
+		return &value
+	}
+	changes.WaveHeight = change(latest.WaveSummary.WaveHeight, previous.WaveSummary.WaveHeight, missingHeightMarker)
+	changes.DominantPeriod = change(latest.WaveSummary.Period, previous.WaveSummary.Period, missingPeriodMarker)
+	changes.WindSpeed = change(latest.WindSpeed, previous.WindSpeed, missingSpeedMarker)
+	changes.Pressure = change(latest.Pressure, previous.Pressure, missingPressureMarker)
+
+	return changes
+}
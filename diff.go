@@ -0,0 +1,131 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+// The readings whose change is the shortest turn between the two directions
+var directionFields = []string{"mwd", "wdir"}
+
+// How the conditions changed between two times, with every reading keyed by
+// its ndbc column. Hours is negative when the second time is the earlier one.
+type ConditionsDiff struct {
+	StationID string
+	From      surfnerd.BuoyDataItem
+	To        surfnerd.BuoyDataItem
+	Hours     float64
+	Fields    map[string]FieldDiff
+}
+
+// A missing reading is null, and so is the change when either is missing
+type FieldDiff struct {
+	From   *float64
+	To     *float64
+	Change *float64
+}
+
+type conditionsDiffFields ConditionsDiff
+
+func (self ConditionsDiff) MarshalJSON() ([]byte, error) {
+	observations, observationsErr := qualityControlledJSONList([]surfnerd.BuoyDataItem{self.From, self.To})
+	if observationsErr != nil {
+		return nil, observationsErr
+	}
+
+	return json.Marshal(struct {
+		conditionsDiffFields
+		From json.RawMessage
+		To   json.RawMessage
+	}{conditionsDiffFields(self), observations[0], observations[1]})
+}
+
+func conditionsDiffHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+
+	dates := []time.Time{}
+	for _, name := range []string{"epoch1", "epoch2"} {
+		rawdate, dateErr := strconv.ParseInt(vars[name], 10, 64)
+		if dateErr != nil {
+			writeErrorResponse(w, r, http.StatusBadRequest, errors.New("Invalid date "+vars[name]))
+			return
+		}
+		dates = append(dates, time.Unix(rawdate, 0))
+	}
+
+	earliest := dates[0]
+	if dates[1].Before(earliest) {
+		earliest = dates[1]
+	}
+
+	requestedBuoy := &surfnerd.Buoy{StationID: stationID}
+	if fetchErr := fetchStandardBuoyData(client, requestedBuoy, historyCountSince(earliest)); fetchErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, fetchErr)
+		return
+	}
+	if len(requestedBuoy.BuoyData) == 0 {
+		writeErrorResponse(w, r, http.StatusNotFound, errors.New("Station "+stationID+" has no observations"))
+		return
+	}
+
+	interpolate := parseInterpolate(r)
+	from, _, _ := findConditionsForDate(requestedBuoy, dates[0], interpolate)
+	to, _, _ := findConditionsForDate(requestedBuoy, dates[1], interpolate)
+
+	writeDataResponse(w, r, client, newConditionsDiff(stationID, from, to))
+}
+
+func newConditionsDiff(stationID string, from, to surfnerd.BuoyDataItem) ConditionsDiff {
+	diff := ConditionsDiff{
+		StationID: stationID,
+		From:      from,
+		To:        to,
+		Hours:     ToFixedPoint(to.Date.Sub(from.Date).Hours(), 2),
+		Fields:    map[string]FieldDiff{},
+	}
+
+	fromReadings, toReadings := map[string]float64{}, map[string]float64{}
+	for _, reading := range validReadings(from) {
+		fromReadings[reading.Field] = reading.Value
+	}
+	for _, reading := range validReadings(to) {
+		toReadings[reading.Field] = reading.Value
+	}
+
+	for _, field := range observationFields {
+		fieldDiff := FieldDiff{}
+		fromValue, fromOK := fromReadings[field]
+		if fromOK {
+			fieldDiff.From = &fromValue
+		}
+		toValue, toOK := toReadings[field]
+		if toOK {
+			fieldDiff.To = &toValue
+		}
+		if fromOK && toOK {
+			change := toValue - fromValue
+			if containsString(directionFields, field) {
+				change = normalizeAngle(change)
+			}
+			change = ToFixedPoint(change, 2)
+			fieldDiff.Change = &change
+		}
+		diff.Fields[field] = fieldDiff
+	}
+
+	return diff
+}
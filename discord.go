@@ -0,0 +1,97 @@
+package buoyfinder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/appengine/log"
+)
+
+var discordWebhookPrefixes = []string{"https://discord.com/api/webhooks/", "https://discordapp.com/api/webhooks/"}
+
+func isDiscordWebhookURL(webhookURL string) bool {
+	for _, prefix := range discordWebhookPrefixes {
+		if strings.HasPrefix(webhookURL, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// The name the summary chart is attached as, which the embed refers to
+const discordChartFilename = "summary.png"
+
+// Posts the conditions as an embed, striped with the gradient color of the
+// wave height, with the summary chart as its image. The chart is rendered now
+// and attached, since Discord caches a linked image and the link would show
+// whatever the conditions are when someone scrolls back.
+func deliverDiscordAlert(ctx context.Context, client *http.Client, webhookURL string, buoy *ClosestBuoy, baseURL string) error {
+	heightColor := waveHeightColor(NewGradient(), 0)
+	if height := buoy.BuoyData.WaveSummary.WaveHeight; isValidReading(height, missingHeightMarker) {
+		heightColor = waveHeightColor(NewGradient(), height*metersToFeet)
+	}
+	color, colorErr := strconv.ParseInt(strings.TrimPrefix(heightColor.Hex(), "#"), 16, 64)
+	if colorErr != nil {
+		return colorErr
+	}
+
+	embed := map[string]interface{}{
+		"title":       "Station " + buoy.BuoyStationID,
+		"url":         baseURL + "/buoy/" + buoy.BuoyStationID,
+		"description": compactReport(buoy),
+		"color":       color,
+		"timestamp":   buoy.BuoyData.Date.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+
+	// The alert still goes out without the chart when it cannot be rendered
+	chart, _, chartErr := renderSummaryChart(ctx, client, buoy.BuoyStationID, 0, SpectraChartOptions{Gradient: NewGradient()})
+	if chartErr != nil {
+		log.Warningf(ctx, "Could not render the summary chart of %s for Discord: %v", buoy.BuoyStationID, chartErr)
+	} else {
+		embed["image"] = map[string]string{"url": "attachment://" + discordChartFilename}
+	}
+
+	message := map[string]interface{}{
+		"username": "BuoyFinder",
+		"embeds":   []map[string]interface{}{embed},
+	}
+	encoded, encodeErr := json.Marshal(message)
+	if encodeErr != nil {
+		return encodeErr
+	}
+
+	body := bytes.Buffer{}
+	form := multipart.NewWriter(&body)
+	if fieldErr := form.WriteField("payload_json", string(encoded)); fieldErr != nil {
+		return fieldErr
+	}
+	if chartErr == nil {
+		part, partErr := form.CreateFormFile("files[0]", discordChartFilename)
+		if partErr != nil {
+			return partErr
+		}
+		if _, writeErr := part.Write(chart); writeErr != nil {
+			return writeErr
+		}
+	}
+	if closeErr := form.Close(); closeErr != nil {
+		return closeErr
+	}
+
+	resp, postErr := client.Post(webhookURL, form.FormDataContentType(), &body)
+	if postErr != nil {
+		return postErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return errors.New("Discord did not accept the alert: " + resp.Status)
+	}
+	return nil
+}
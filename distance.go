@@ -0,0 +1,26 @@
+package buoyfinder
+
+import (
+	"github.com/mpiannucci/buoyfinder/geo"
+	"github.com/mpiannucci/surfnerd"
+)
+
+const kmPerNauticalMile = 1.852
+
+// Distance between the two locations in kilometers
+func distanceBetween(start, end surfnerd.Location) float64 {
+	return geo.Distance(geo.DefaultAlgorithm, start.Latitude, start.Longitude, end.Latitude, end.Longitude)
+}
+
+func distanceWith(algorithm geo.Algorithm, start, end surfnerd.Location) float64 {
+	return geo.Distance(algorithm, start.Latitude, start.Longitude, end.Latitude, end.Longitude)
+}
+
+// Initial compass bearing in degrees to travel from start to end
+func bearingBetween(start, end surfnerd.Location) float64 {
+	return geo.InitialBearing(start.Latitude, start.Longitude, end.Latitude, end.Longitude)
+}
+
+func hasLocation(location surfnerd.Location) bool {
+	return location.Latitude != 0 || location.Longitude != 0
+}
@@ -0,0 +1,242 @@
+package buoyfinder
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+)
+
+// How ?downsample= reduces each bucket to one observation. Nearest keeps the
+// observation closest to the bucket's time and mean averages the bucket.
+const (
+	downsampleNearest = "nearest"
+	downsampleMean    = "mean"
+)
+
+// The ?every= bucket size and the ?downsample= mode. A zero interval means
+// every observation is returned.
+type downsampleOptions struct {
+	Every time.Duration
+	Mode  string
+}
+
+// Reads the ?every= bucket size, like 3h or 30m. Zero means every observation
+// is returned.
+func parseDownsampleInterval(r *http.Request) (time.Duration, error) {
+	rawEvery := r.URL.Query().Get("every")
+	if rawEvery == "" {
+		return 0, nil
+	}
+
+	every, everyErr := time.ParseDuration(rawEvery)
+	if everyErr != nil || every <= 0 {
+		return 0, errors.New("Invalid every interval " + rawEvery)
+	}
+	return every, nil
+}
+
+func parseDownsampleOptions(r *http.Request) (downsampleOptions, error) {
+	every, everyErr := parseDownsampleInterval(r)
+	if everyErr != nil {
+		return downsampleOptions{}, everyErr
+	}
+
+	mode := r.URL.Query().Get("downsample")
+	if mode == "" {
+		mode = downsampleNearest
+	} else if mode != downsampleNearest && mode != downsampleMean {
+		return downsampleOptions{}, errors.New("The downsample mode must be nearest or mean")
+	}
+	return downsampleOptions{Every: every, Mode: mode}, nil
+}
+
+func (self downsampleOptions) apply(observations []surfnerd.BuoyDataItem) []surfnerd.BuoyDataItem {
+	if self.Mode == downsampleMean {
+		return meanDownsampleObservations(observations, self.Every)
+	}
+	return downsampleObservations(observations, self.Every)
+}
+
+// Buckets are centered on multiples of the interval, which line up with the
+// clock so 3h gives readings for 00, 03, 06 UTC and so on
+func downsampleBucket(date time.Time, every time.Duration) time.Time {
+	return date.Round(every)
+}
+
+// The observations split into buckets, in the order the buckets first
+// appear, so the result keeps the order of the observations
+func bucketObservations(observations []surfnerd.BuoyDataItem, every time.Duration) ([]time.Time, map[time.Time][]surfnerd.BuoyDataItem) {
+	marks := []time.Time{}
+	buckets := map[time.Time][]surfnerd.BuoyDataItem{}
+	for _, observation := range observations {
+		mark := downsampleBucket(observation.Date, every)
+		if _, seen := buckets[mark]; !seen {
+			marks = append(marks, mark)
+		}
+		buckets[mark] = append(buckets[mark], observation)
+	}
+	return marks, buckets
+}
+
+func nearestObservation(observations []surfnerd.BuoyDataItem, mark time.Time) surfnerd.BuoyDataItem {
+	nearest := observations[0]
+	for _, observation := range observations[1:] {
+		if absDuration(observation.Date.Sub(mark)) < absDuration(nearest.Date.Sub(mark)) {
+			nearest = observation
+		}
+	}
+	return nearest
+}
+
+// Keeps one observation per bucket, the one closest to the bucket's time.
+// The observations can be in either order and the result keeps it.
+func downsampleObservations(observations []surfnerd.BuoyDataItem, every time.Duration) []surfnerd.BuoyDataItem {
+	if every <= 0 || len(observations) == 0 {
+		return observations
+	}
+
+	marks, buckets := bucketObservations(observations, every)
+	downsampled := make([]surfnerd.BuoyDataItem, len(marks))
+	for index, mark := range marks {
+		downsampled[index] = nearestObservation(buckets[mark], mark)
+	}
+	return downsampled
+}
+
+// Averages each bucket into one observation dated at the bucket's time,
+// leaving out missing readings. Directions are averaged around the compass.
+// The swell components and steepness are the nearest observation's, since
+// split swells cannot be averaged.
+func meanDownsampleObservations(observations []surfnerd.BuoyDataItem, every time.Duration) []surfnerd.BuoyDataItem {
+	if every <= 0 || len(observations) == 0 {
+		return observations
+	}
+
+	marks, buckets := bucketObservations(observations, every)
+	downsampled := make([]surfnerd.BuoyDataItem, len(marks))
+	for index, mark := range marks {
+		downsampled[index] = meanObservation(buckets[mark], mark)
+	}
+	return downsampled
+}
+
+func meanObservation(observations []surfnerd.BuoyDataItem, mark time.Time) surfnerd.BuoyDataItem {
+	mean := nearestObservation(observations, mark)
+	mean.Date = mark
+
+	pressureMarker := missingPressureMarker
+	if mean.Units == surfnerd.English {
+		pressureMarker = missingEnglishPressureMarker
+	}
+
+	readings := []struct {
+		Field  func(*surfnerd.BuoyDataItem) *float64
+		Marker float64
+		// Readings that can be negative are checked against the marker by size
+		Signed   bool
+		Circular bool
+		// What the reading is filled with when the whole bucket is missing it
+		Missing float64
+	}{
+		{func(data *surfnerd.BuoyDataItem) *float64 { return &data.WindDirection }, missingDirectionMarker, false, true, missingDirectionMarker},
+		{func(data *surfnerd.BuoyDataItem) *float64 { return &data.WindSpeed }, missingSpeedMarker, false, false, missingSpeedMarker},
+		{func(data *surfnerd.BuoyDataItem) *float64 { return &data.WindGust }, missingSpeedMarker, false, false, missingSpeedMarker},
+		{func(data *surfnerd.BuoyDataItem) *float64 { return &data.WaveSummary.WaveHeight }, missingHeightMarker, false, false, missingHeightMarker},
+		{func(data *surfnerd.BuoyDataItem) *float64 { return &data.WaveSummary.Period }, missingPeriodMarker, false, false, missingPeriodMarker},
+		{func(data *surfnerd.BuoyDataItem) *float64 { return &data.WaveSummary.Direction }, missingDirectionMarker, false, true, missingDirectionMarker},
+		{func(data *surfnerd.BuoyDataItem) *float64 { return &data.AveragePeriod }, missingPeriodMarker, false, false, missingPeriodMarker},
+		{func(data *surfnerd.BuoyDataItem) *float64 { return &data.Pressure }, pressureMarker, false, false, pressureMarker},
+		{func(data *surfnerd.BuoyDataItem) *float64 { return &data.PressureTendency }, missingSpeedMarker, true, false, missingPressureMarker},
+		{func(data *surfnerd.BuoyDataItem) *float64 { return &data.AirTemperature }, missingTemperatureMarker, false, false, missingTemperatureMarker},
+		{func(data *surfnerd.BuoyDataItem) *float64 { return &data.WaterTemperature }, missingTemperatureMarker, false, false, missingTemperatureMarker},
+		{func(data *surfnerd.BuoyDataItem) *float64 { return &data.DewpointTemperature }, missingTemperatureMarker, false, false, missingTemperatureMarker},
+		{func(data *surfnerd.BuoyDataItem) *float64 { return &data.Visibility }, missingVisibilityMarker, false, false, missingVisibilityMarker},
+		{func(data *surfnerd.BuoyDataItem) *float64 { return &data.WaterLevel }, missingHeightMarker, true, false, missingHeightMarker},
+	}
+
+	for _, reading := range readings {
+		values := []float64{}
+		for index := range observations {
+			value := *reading.Field(&observations[index])
+			checked := value
+			if reading.Signed {
+				checked = math.Abs(value)
+			}
+			if isValidReading(checked, reading.Marker) {
+				values = append(values, value)
+			}
+		}
+
+		switch {
+		case len(values) == 0:
+			*reading.Field(&mean) = reading.Missing
+		case reading.Circular:
+			*reading.Field(&mean) = meanDirection(values, nil)
+		default:
+			*reading.Field(&mean) = meanValue(values)
+		}
+	}
+
+	if isValidReading(mean.WaveSummary.Direction, missingDirectionMarker) {
+		mean.WaveSummary.CompassDirection = CompassDirection(mean.WaveSummary.Direction)
+	}
+	mean.WaveSpectra = meanWaveSpectra(observations, mean.WaveSpectra)
+	return mean
+}
+
+// Averages the energy of each band, and its direction weighted by the
+// energy, over the observations sharing the template's frequencies
+func meanWaveSpectra(observations []surfnerd.BuoyDataItem, template surfnerd.BuoySpectraItem) surfnerd.BuoySpectraItem {
+	bands := len(template.Frequencies)
+	if bands == 0 {
+		return template
+	}
+
+	spectra := template
+	spectra.Energies = make([]float64, bands)
+	spectra.Angles = make([]float64, bands)
+	for band := 0; band < bands; band++ {
+		energies, angles := []float64{}, []float64{}
+		for _, observation := range observations {
+			other := observation.WaveSpectra
+			if len(other.Frequencies) != bands || band >= len(other.Energies) || band >= len(other.Angles) {
+				continue
+			}
+			energies = append(energies, other.Energies[band])
+			angles = append(angles, other.Angles[band])
+		}
+		if len(energies) == 0 {
+			continue
+		}
+		spectra.Energies[band] = meanValue(energies)
+		spectra.Angles[band] = meanDirection(angles, energies)
+	}
+	return spectra
+}
+
+func meanValue(values []float64) float64 {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total / float64(len(values))
+}
+
+// The mean of the directions in degrees, weighted when the weights are given
+func meanDirection(directions, weights []float64) float64 {
+	x, y := 0.0, 0.0
+	for index, direction := range directions {
+		weight := 1.0
+		if weights != nil {
+			weight = weights[index]
+		}
+		radians := direction * math.Pi / 180.0
+		x += weight * math.Sin(radians)
+		y += weight * math.Cos(radians)
+	}
+	// Rounded before wrapping so a mean just shy of north comes out as 0
+	return math.Mod(ToFixedPoint(math.Atan2(x, y)*180.0/math.Pi+360.0, 1), 360.0)
+}
@@ -0,0 +1,216 @@
+package buoyfinder
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+)
+
+// Every API response is wrapped in the same envelope so clients can handle
+// the data, metadata, and errors the same way no matter the endpoint
+type ResponseEnvelope struct {
+	Data   interface{}     `json:"data"`
+	Meta   *ResponseMeta   `json:"meta"`
+	Errors []ResponseError `json:"errors"`
+}
+
+type ResponseError struct {
+	Status  int
+	Message string
+}
+
+// What the request asked for, as understood by the server
+type RequestEcho struct {
+	Path     string
+	Station  string             `json:",omitempty"`
+	Location *surfnerd.Location `json:",omitempty"`
+	Date     *time.Time         `json:",omitempty"`
+}
+
+// Timing and freshness details so clients can decide whether the data is
+// recent enough to show. Fetches is empty when everything came from the cache.
+type ResponseMeta struct {
+	Request         RequestEcho
+	DurationMS      float64
+	ObservationDate *time.Time        `json:",omitempty"`
+	AgeMinutes      *float64          `json:",omitempty"`
+	Fetches         []UpstreamFetch   `json:",omitempty"`
+	Links           map[string]string `json:",omitempty"`
+}
+
+func newResponseEnvelope(r *http.Request, client *http.Client, data interface{}) *ResponseEnvelope {
+	meta := &ResponseMeta{
+		Request: newRequestEcho(r),
+		Links: map[string]string{
+			"self": r.URL.RequestURI(),
+		},
+	}
+	if client != nil {
+		meta.DurationMS = ToFixedPoint(requestDuration(client).Seconds()*1000.0, 1)
+		meta.Fetches = recordedFetches(client)
+	}
+
+	if meta.Request.Station != "" {
+		meta.addStationLinks(meta.Request.Station)
+	}
+
+	return &ResponseEnvelope{
+		Data:   data,
+		Meta:   meta,
+		Errors: []ResponseError{},
+	}
+}
+
+// Where to go next for the station, so clients can follow links rather than
+// build the routes themselves
+func (self *ResponseMeta) addStationLinks(stationID string) {
+	self.Links["station"] = "/api/stationinfo/" + stationID
+	self.Links["latest"] = "/api/latest/" + stationID
+	self.Links["waveHeightChart"] = "/api/charts/waveheight/" + stationID + ".png"
+	self.Links["windChart"] = "/api/charts/wind/" + stationID + ".png"
+	self.Links["summaryChart"] = "/api/charts/summary/" + stationID + ".png"
+	self.Links["webView"] = "/buoy/" + stationID
+}
+
+func newRequestEcho(r *http.Request) RequestEcho {
+	vars := mux.Vars(r)
+	echo := RequestEcho{
+		Path:    r.URL.Path,
+		Station: vars["station"],
+	}
+
+	latitude, latitudeErr := strconv.ParseFloat(vars["lat"], 64)
+	longitude, longitudeErr := strconv.ParseFloat(vars["lon"], 64)
+	if latitudeErr == nil && longitudeErr == nil {
+		location := surfnerd.NewLocationForLatLong(latitude, longitude)
+		echo.Location = &location
+	}
+
+	if epoch, epochErr := strconv.ParseInt(vars["epoch"], 10, 64); epochErr == nil {
+		date := time.Unix(epoch, 0).UTC()
+		echo.Date = &date
+	}
+
+	return echo
+}
+
+// Records which station the data came from and how old the observation is
+func (self *ResponseEnvelope) SetObservation(stationID string, observationDate time.Time) {
+	if self.Meta.Request.Station == "" && stationID != "" {
+		self.Meta.Request.Station = stationID
+		self.Meta.addStationLinks(stationID)
+	}
+	if observationDate.IsZero() {
+		return
+	}
+	if stationID != "" {
+		epoch := strconv.FormatInt(observationDate.Unix(), 10)
+		self.Meta.Links["history"] = "/api/around/" + stationID + "/" + epoch + "?window=24h"
+		self.Meta.Links["permalink"] = "/buoy/" + stationID + "/" + epoch
+	}
+
+	age := ToFixedPoint(time.Since(observationDate).Minutes(), 1)
+	self.Meta.ObservationDate = &observationDate
+	self.Meta.AgeMinutes = &age
+}
+
+func writeDataResponse(w http.ResponseWriter, r *http.Request, client *http.Client, data interface{}) {
+	writeEnvelope(w, r, http.StatusOK, newResponseEnvelope(r, client, data))
+}
+
+func writeErrorResponse(w http.ResponseWriter, r *http.Request, status int, err error) {
+	envelope := newResponseEnvelope(r, nil, nil)
+	envelope.Errors = append(envelope.Errors, ResponseError{
+		Status:  status,
+		Message: err.Error(),
+	})
+	writeEnvelope(w, r, status, envelope)
+}
+
+// ?compact=true leaves out the indentation, which is most of the size of the
+// spectra arrays
+func parseCompactJSON(r *http.Request) bool {
+	compact, _ := strconv.ParseBool(r.URL.Query().Get("compact"))
+	return compact
+}
+
+// ?verbose=true keeps the zero valued fields that are otherwise left out, for
+// clients that expect every field to be there
+func parseVerboseJSON(r *http.Request) bool {
+	verbose, _ := strconv.ParseBool(r.URL.Query().Get("verbose"))
+	return verbose
+}
+
+func newEnvelopeEncoder(w io.Writer, r *http.Request) *json.Encoder {
+	encoder := json.NewEncoder(w)
+	if !parseCompactJSON(r) {
+		encoder.SetIndent("", "    ")
+	}
+	return encoder
+}
+
+// Holds back the status until the first write, so an encoding error can
+// still be answered with a 500
+type deferredStatusWriter struct {
+	http.ResponseWriter
+	status  int
+	written bool
+}
+
+func (self *deferredStatusWriter) Write(b []byte) (int, error) {
+	if !self.written {
+		self.ResponseWriter.WriteHeader(self.status)
+		self.written = true
+	}
+	return self.ResponseWriter.Write(b)
+}
+
+func writeEnvelope(w http.ResponseWriter, r *http.Request, status int, envelope *ResponseEnvelope) {
+	// The precision and time rewrites work on the whole encoding, so only
+	// JSON responses without them are encoded straight to the response
+	precision, timeFormat := parseResponsePrecision(r), parseTimeFormat(r)
+	if wantsMsgpack(r) {
+		encoded, encodeErr := json.Marshal(envelope)
+		if encodeErr != nil {
+			http.Error(w, encodeErr.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeMsgpack(w, status, rewriteEnvelopeJSON(encoded, precision, timeFormat))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if precision < 0 && timeFormat == "" {
+		streamed := &deferredStatusWriter{ResponseWriter: w, status: status}
+		if encodeErr := newEnvelopeEncoder(streamed, r).Encode(envelope); encodeErr != nil && !streamed.written {
+			http.Error(w, encodeErr.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	encoded := bytes.Buffer{}
+	if encodeErr := newEnvelopeEncoder(&encoded, r).Encode(envelope); encodeErr != nil {
+		http.Error(w, encodeErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(status)
+	w.Write(rewriteEnvelopeJSON(encoded.Bytes(), precision, timeFormat))
+}
+
+func rewriteEnvelopeJSON(encoded []byte, precision int, timeFormat string) []byte {
+	if precision >= 0 {
+		encoded = roundJSONNumbers(encoded, precision)
+	}
+	if timeFormat != "" {
+		encoded = formatJSONTimes(encoded, timeFormat)
+	}
+	return encoded
+}
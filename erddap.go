@@ -0,0 +1,56 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/appengine/memcache"
+)
+
+type erddapGridResponse struct {
+	Table struct {
+		ColumnNames []string        `json:"columnNames"`
+		Rows        [][]interface{} `json:"rows"`
+	} `json:"table"`
+}
+
+// Reads a single grid cell from an ERDDAP griddap dataset, keyed by column
+// name. Missing values, like cells over land, come back as nil.
+func fetchERDDAPRow(ctx context.Context, client *http.Client, datasetURL, query string, expiration time.Duration) (map[string]interface{}, error) {
+	cacheKey := "erddap:" + datasetURL + "?" + query
+	response := erddapGridResponse{}
+	if _, cacheErr := memcache.JSON.Get(ctx, cacheKey, &response); cacheErr != nil {
+		resp, fetchErr := client.Get(datasetURL + "?" + query)
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.New("ERDDAP could not be reached: " + resp.Status)
+		}
+		if decodeErr := json.NewDecoder(resp.Body).Decode(&response); decodeErr != nil {
+			return nil, decodeErr
+		}
+
+		memcache.JSON.Set(ctx, &memcache.Item{
+			Key:        cacheKey,
+			Object:     response,
+			Expiration: expiration,
+		})
+	}
+
+	if len(response.Table.Rows) == 0 {
+		return nil, errors.New("ERDDAP returned no data for the location")
+	}
+	row := map[string]interface{}{}
+	for index, name := range response.Table.ColumnNames {
+		if index < len(response.Table.Rows[0]) {
+			row[name] = response.Table.Rows[0][index]
+		}
+	}
+	return row, nil
+}
@@ -0,0 +1,402 @@
+package buoyfinder
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/buoyfinder/archive"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+// NDBC only keeps the last 45 days of realtime data online, so anything older
+// is read from the archive. Exports are capped at the same span so a range
+// of archived years stays inside the request deadline.
+const maxExportRange = 45 * 24 * time.Hour
+
+func exportNetCDFHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := vars["station"]
+
+	start, end, rangeError := parseExportRange(r)
+	if rangeError != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, rangeError)
+		return
+	}
+
+	downsample, downsampleError := parseDownsampleOptions(r)
+	if downsampleError != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, downsampleError)
+		return
+	}
+
+	requestedBuoy, requestedBuoyError := fetchBuoyWithID(ctx, client, stationID)
+	if requestedBuoyError != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, requestedBuoyError)
+		return
+	}
+
+	observations, fetchBuoyError := fetchObservationRange(ctx, client, requestedBuoy, start, end, parseSpectraSmoothing(r))
+	if fetchBuoyError != nil {
+		writeErrorResponse(w, r, waveFetchErrorStatus(fetchBuoyError, http.StatusInternalServerError), fetchBuoyError)
+		return
+	}
+	observations = downsample.apply(observations)
+
+	// CDF-1 reads a dimension of length zero as the record dimension, so an
+	// empty range has no valid file to send
+	if len(observations) == 0 {
+		writeErrorResponse(w, r, http.StatusNotFound, errors.New("Station "+stationID+" has no observations in the requested range"))
+		return
+	}
+
+	netcdf := createObservationNetCDF(requestedBuoy, observations)
+
+	w.Header().Set("Content-Type", "application/x-netcdf")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+stationID+".nc\"")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	netcdf.WriteTo(w)
+}
+
+func exportParquetHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := vars["station"]
+
+	start, end, rangeError := parseExportRange(r)
+	if rangeError != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, rangeError)
+		return
+	}
+
+	downsample, downsampleError := parseDownsampleOptions(r)
+	if downsampleError != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, downsampleError)
+		return
+	}
+
+	requestedBuoy := &surfnerd.Buoy{StationID: stationID}
+	observations, fetchBuoyError := fetchObservationRange(ctx, client, requestedBuoy, start, end, parseSpectraSmoothing(r))
+	if fetchBuoyError != nil {
+		writeErrorResponse(w, r, waveFetchErrorStatus(fetchBuoyError, http.StatusInternalServerError), fetchBuoyError)
+		return
+	}
+	observations = downsample.apply(observations)
+
+	// The footer is written last so the file has to be built before any of it
+	// is sent, otherwise a failure would leave the client a truncated file
+	parquetFile := &bytes.Buffer{}
+	if parquetError := writeObservationsParquet(parquetFile, stationID, observations); parquetError != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, parquetError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.apache.parquet")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+stationID+".parquet\"")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	parquetFile.WriteTo(w)
+}
+
+// Reads the start and end query parameters as unix epochs. The end defaults to
+// now and the start to a day before the end.
+func parseExportRange(r *http.Request) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+
+	end := time.Now()
+	if rawEnd := query.Get("end"); rawEnd != "" {
+		epoch, epochErr := strconv.ParseInt(rawEnd, 10, 64)
+		if epochErr != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid end date")
+		}
+		end = time.Unix(epoch, 0)
+	}
+
+	start := end.Add(-24 * time.Hour)
+	if rawStart := query.Get("start"); rawStart != "" {
+		epoch, epochErr := strconv.ParseInt(rawStart, 10, 64)
+		if epochErr != nil {
+			return time.Time{}, time.Time{}, errors.New("Invalid start date")
+		}
+		start = time.Unix(epoch, 0)
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, errors.New("The start date must be before the end date")
+	}
+	if end.Sub(start) > maxExportRange {
+		return time.Time{}, time.Time{}, errors.New("Only 45 days of data can be exported at a time")
+	}
+
+	return start, end, nil
+}
+
+// The observations in the range, oldest first. The realtime files cover the
+// last 45 days and the archive everything before, without spectra.
+func fetchObservationRange(ctx context.Context, client *http.Client, buoy *surfnerd.Buoy, start, end time.Time, smoothing int) ([]surfnerd.BuoyDataItem, error) {
+	observations, realtimeStart, archivedErr := fetchArchivedObservationRange(ctx, client, buoy.StationID, start, end)
+	if archivedErr != nil {
+		return nil, archivedErr
+	}
+
+	if end.After(realtimeStart) {
+		realtime, fetchErr := fetchDetailedWaveBuoyDataRange(client, buoy, realtimeStart, end, smoothing)
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		observations = append(observations, realtime...)
+	}
+
+	sort.Slice(observations, func(i, j int) bool {
+		return observations[i].Date.Before(observations[j].Date)
+	})
+	return observations, nil
+}
+
+// Like fetchObservationRange, but with the meteorological readings of the
+// realtime standard data in place of the spectra
+func fetchStandardObservationRange(ctx context.Context, client *http.Client, stationID string, start, end time.Time) ([]surfnerd.BuoyDataItem, error) {
+	observations, realtimeStart, archivedErr := fetchArchivedObservationRange(ctx, client, stationID, start, end)
+	if archivedErr != nil {
+		return nil, archivedErr
+	}
+
+	if end.After(realtimeStart) {
+		realtime, fetchErr := fetchStandardObservationsSince(client, stationID, realtimeStart)
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		for _, item := range realtime {
+			if !item.Date.After(end) {
+				observations = append(observations, item)
+			}
+		}
+	}
+
+	sort.Slice(observations, func(i, j int) bool {
+		return observations[i].Date.Before(observations[j].Date)
+	})
+	return observations, nil
+}
+
+// The archived observations in the part of the range before the realtime
+// files, along with where the realtime files take over
+func fetchArchivedObservationRange(ctx context.Context, client *http.Client, stationID string, start, end time.Time) ([]surfnerd.BuoyDataItem, time.Time, error) {
+	realtimeStart := time.Now().Add(-maxExportRange)
+	if !start.Before(realtimeStart) {
+		return []surfnerd.BuoyDataItem{}, start, nil
+	}
+
+	archiveEnd := end
+	if archiveEnd.After(realtimeStart) {
+		archiveEnd = realtimeStart
+	}
+
+	observations := []surfnerd.BuoyDataItem{}
+	for year := start.UTC().Year(); year <= archiveEnd.UTC().Year(); year++ {
+		archived, archivedErr := fetchYearObservations(ctx, client, stationID, year)
+		if archivedErr == archive.ErrNotArchived {
+			continue
+		} else if archivedErr != nil {
+			return nil, realtimeStart, archivedErr
+		}
+		for _, observation := range archived {
+			if !observation.Date.Before(start) && observation.Date.Before(archiveEnd) {
+				observations = append(observations, newArchivedBuoyData(observation))
+			}
+		}
+	}
+	return observations, realtimeStart, nil
+}
+
+// Fetches the wave data covering the given range and returns the observations
+// inside it, oldest first
+func fetchDetailedWaveBuoyDataRange(client *http.Client, buoy *surfnerd.Buoy, start, end time.Time, smoothing int) ([]surfnerd.BuoyDataItem, error) {
+	count := historyCountSince(start)
+	fetchBuoyError := fetchDetailedWaveBuoyData(client, buoy, count, smoothing)
+	if fetchBuoyError != nil {
+		return nil, fetchBuoyError
+	}
+
+	observations := []surfnerd.BuoyDataItem{}
+	for _, item := range buoy.BuoyData {
+		if item.Date.Before(start) || item.Date.After(end) {
+			continue
+		}
+		observations = append(observations, item)
+	}
+
+	sort.Slice(observations, func(i, j int) bool {
+		return observations[i].Date.Before(observations[j].Date)
+	})
+
+	return observations, nil
+}
+
+// Lays the observations out as a CF-1.6 timeseries with the energy and
+// direction spectra as (time, frequency) variables. Ranges with no spectra,
+// like archived ones, leave the frequency dimension out since a zero length
+// would make it a second record dimension. The observations must not be empty.
+func createObservationNetCDF(buoy *surfnerd.Buoy, observations []surfnerd.BuoyDataItem) *NetCDFFile {
+	// Archived observations have no spectra, so the frequencies come from the
+	// first one that does
+	frequencies := []float64{}
+	for _, observation := range observations {
+		if len(observation.WaveSpectra.Frequencies) > 0 {
+			frequencies = observation.WaveSpectra.Frequencies
+			break
+		}
+	}
+
+	netcdf := &NetCDFFile{}
+	timeDim := netcdf.AddDimension("time", len(observations))
+
+	netcdf.AddAttribute("Conventions", "CF-1.6")
+	netcdf.AddAttribute("featureType", "timeSeries")
+	netcdf.AddAttribute("title", "NDBC Station "+buoy.StationID+" wave observations")
+	netcdf.AddAttribute("source", "National Data Buoy Center realtime and historical data")
+	netcdf.AddAttribute("station_id", buoy.StationID)
+
+	latitude, longitude := 0.0, 0.0
+	if buoy.Location != nil {
+		latitude, longitude = buoy.Latitude, buoy.Longitude
+		netcdf.AddAttribute("station_name", buoy.LocationName)
+	}
+
+	netcdf.AddVariable(NetCDFVariable{
+		Name: "latitude",
+		Attributes: []NetCDFAttribute{
+			{"standard_name", "latitude"},
+			{"units", "degrees_north"},
+		},
+		Data: []float64{latitude},
+	})
+	netcdf.AddVariable(NetCDFVariable{
+		Name: "longitude",
+		Attributes: []NetCDFAttribute{
+			{"standard_name", "longitude"},
+			{"units", "degrees_east"},
+		},
+		Data: []float64{longitude},
+	})
+	times := make([]float64, len(observations))
+	heights := make([]float64, len(observations))
+	periods := make([]float64, len(observations))
+	directions := make([]float64, len(observations))
+	energies := make([]float64, 0, len(observations)*len(frequencies))
+	angles := make([]float64, 0, len(observations)*len(frequencies))
+	for index, observation := range observations {
+		observation.ChangeUnits(surfnerd.Metric)
+
+		times[index] = float64(observation.Date.Unix())
+		heights[index] = observation.WaveSummary.WaveHeight
+		periods[index] = observation.WaveSummary.Period
+		directions[index] = observation.WaveSummary.Direction
+		energies = appendSpectraRow(energies, observation.WaveSpectra.Energies, len(frequencies))
+		angles = appendSpectraRow(angles, observation.WaveSpectra.Angles, len(frequencies))
+	}
+
+	netcdf.AddVariable(NetCDFVariable{
+		Name:       "time",
+		Dimensions: []int{timeDim},
+		Attributes: []NetCDFAttribute{
+			{"standard_name", "time"},
+			{"units", "seconds since 1970-01-01 00:00:00 UTC"},
+			{"calendar", "gregorian"},
+		},
+		Data: times,
+	})
+	netcdf.AddVariable(NetCDFVariable{
+		Name:       "wave_height",
+		Dimensions: []int{timeDim},
+		Attributes: []NetCDFAttribute{
+			{"standard_name", "sea_surface_wave_significant_height"},
+			{"units", "m"},
+			{"coordinates", "time latitude longitude"},
+		},
+		Data: heights,
+	})
+	netcdf.AddVariable(NetCDFVariable{
+		Name:       "dominant_period",
+		Dimensions: []int{timeDim},
+		Attributes: []NetCDFAttribute{
+			{"standard_name", "sea_surface_wave_period_at_variance_spectral_density_maximum"},
+			{"units", "s"},
+			{"coordinates", "time latitude longitude"},
+		},
+		Data: periods,
+	})
+	netcdf.AddVariable(NetCDFVariable{
+		Name:       "mean_wave_direction",
+		Dimensions: []int{timeDim},
+		Attributes: []NetCDFAttribute{
+			{"standard_name", "sea_surface_wave_from_direction"},
+			{"units", "degree"},
+			{"coordinates", "time latitude longitude"},
+		},
+		Data: directions,
+	})
+	if len(frequencies) == 0 {
+		return netcdf
+	}
+
+	frequencyDim := netcdf.AddDimension("frequency", len(frequencies))
+	netcdf.AddVariable(NetCDFVariable{
+		Name:       "frequency",
+		Dimensions: []int{frequencyDim},
+		Attributes: []NetCDFAttribute{
+			{"long_name", "wave frequency"},
+			{"units", "Hz"},
+		},
+		Data: frequencies,
+	})
+	netcdf.AddVariable(NetCDFVariable{
+		Name:       "spectral_energy",
+		Dimensions: []int{timeDim, frequencyDim},
+		Attributes: []NetCDFAttribute{
+			{"standard_name", "sea_surface_wave_variance_spectral_density"},
+			{"units", "m2 s"},
+			{"_FillValue", NetCDFFillValue},
+			{"coordinates", "time frequency latitude longitude"},
+		},
+		Data: energies,
+	})
+	netcdf.AddVariable(NetCDFVariable{
+		Name:       "spectral_direction",
+		Dimensions: []int{timeDim, frequencyDim},
+		Attributes: []NetCDFAttribute{
+			{"standard_name", "sea_surface_wave_from_direction"},
+			{"units", "degree"},
+			{"_FillValue", NetCDFFillValue},
+			{"coordinates", "time frequency latitude longitude"},
+		},
+		Data: angles,
+	})
+
+	return netcdf
+}
+
+// Pads or truncates a spectra row to the exported frequency count so a station
+// changing its frequency bins mid range still produces a rectangular variable
+func appendSpectraRow(data []float64, row []float64, length int) []float64 {
+	for index := 0; index < length; index++ {
+		if index < len(row) {
+			data = append(data, row[index])
+		} else {
+			data = append(data, NetCDFFillValue)
+		}
+	}
+	return data
+}
@@ -0,0 +1,253 @@
+package buoyfinder
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/buoyfinder/archive"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/memcache"
+)
+
+const maxExtremeEvents = 10
+
+// Peaks closer together than this are counted as the same storm, so one long
+// blow does not fill the whole list
+const extremeEventSeparation = 48 * time.Hour
+
+// A past year never changes once it is computed
+const extremesCacheExpiration = 7 * 24 * time.Hour
+
+// The biggest events the station recorded in a year, in metric, with links to
+// the buoy view of each one
+type AnnualExtremes struct {
+	StationID    string
+	Year         int
+	Observations int
+	WaveHeight   []ExtremeEvent
+	WindSpeed    []ExtremeEvent
+	WindGust     []ExtremeEvent
+
+	MaxAirTemperature   *ExtremeEvent `json:",omitempty"`
+	MinAirTemperature   *ExtremeEvent `json:",omitempty"`
+	MaxWaterTemperature *ExtremeEvent `json:",omitempty"`
+	MinWaterTemperature *ExtremeEvent `json:",omitempty"`
+}
+
+type ExtremeEvent struct {
+	Date  time.Time
+	Value float64
+	// The period and direction the waves came in at, for wave events
+	DominantPeriod    float64 `json:",omitempty"`
+	MeanWaveDirection float64 `json:",omitempty"`
+	Link              string
+}
+
+func annualExtremesHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 55*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+
+	year, yearErr := strconv.Atoi(vars["year"])
+	if yearErr != nil || year < archive.FirstYear || year >= time.Now().UTC().Year() {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("The year must be a past year since "+strconv.Itoa(archive.FirstYear)))
+		return
+	}
+
+	cacheKey := "extremes:" + stationID + ":" + strconv.Itoa(year)
+	extremes := AnnualExtremes{}
+	if _, cacheErr := memcache.Gob.Get(ctx, cacheKey, &extremes); cacheErr != nil {
+		observations, fetchErr := fetchYearObservations(ctx, client, stationID, year)
+		if fetchErr == archive.ErrNotArchived {
+			writeErrorResponse(w, r, http.StatusNotFound, errors.New("Station "+stationID+" has no archive for "+strconv.Itoa(year)))
+			return
+		} else if fetchErr != nil {
+			writeErrorResponse(w, r, http.StatusBadGateway, fetchErr)
+			return
+		}
+
+		extremes = findAnnualExtremes(stationID, year, observations)
+		memcache.Gob.Set(ctx, &memcache.Item{
+			Key:        cacheKey,
+			Object:     extremes,
+			Expiration: extremesCacheExpiration,
+		})
+	}
+
+	// The links depend on the host the request came in on, so they are not
+	// part of what is cached
+	baseURL := requestBaseURL(r)
+	for _, events := range [][]ExtremeEvent{extremes.WaveHeight, extremes.WindSpeed, extremes.WindGust} {
+		for index := range events {
+			events[index].Link = buoyPermalink(baseURL, stationID, events[index].Date)
+		}
+	}
+	for _, event := range []*ExtremeEvent{extremes.MaxAirTemperature, extremes.MinAirTemperature, extremes.MaxWaterTemperature, extremes.MinWaterTemperature} {
+		if event != nil {
+			event.Link = buoyPermalink(baseURL, stationID, event.Date)
+		}
+	}
+
+	writeDataResponse(w, r, nil, extremes)
+}
+
+func buoyPermalink(baseURL, stationID string, date time.Time) string {
+	return baseURL + "/buoy/" + stationID + "/" + strconv.FormatInt(date.Unix(), 10)
+}
+
+// Permalinks carry the date of an observation, so the nearest one being any
+// further away means the observation is no longer available
+const permalinkTolerance = time.Hour
+
+func isPermalinkMatch(timeDiff time.Duration) bool {
+	return absDuration(timeDiff) <= permalinkTolerance
+}
+
+// The archived observation nearest the date, which is ErrNotArchived when
+// the station has none from that year
+func fetchArchivedConditions(ctx context.Context, client *http.Client, stationID string, date time.Time) (*surfnerd.BuoyDataItem, error) {
+	observations, fetchErr := fetchYearObservations(ctx, client, stationID, date.UTC().Year())
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	if len(observations) == 0 {
+		return nil, archive.ErrNotArchived
+	}
+
+	nearest := observations[0]
+	for _, observation := range observations[1:] {
+		if absDuration(observation.Date.Sub(date)) < absDuration(nearest.Date.Sub(date)) {
+			nearest = observation
+		}
+	}
+
+	data := newArchivedBuoyData(nearest)
+	return &data, nil
+}
+
+// The archived readings as a metric observation. The archive only has the
+// standard meteorological data, so the rest is marked missing.
+func newArchivedBuoyData(observation archive.Observation) surfnerd.BuoyDataItem {
+	data := newMissingObservation(observation.Date)
+	data.WindDirection = observation.WindDirection
+	data.WindSpeed = observation.WindSpeed
+	data.WindGust = observation.WindGust
+	data.WaveSummary.WaveHeight = observation.WaveHeight
+	data.WaveSummary.Period = observation.DominantPeriod
+	data.WaveSummary.Direction = observation.MeanWaveDirection
+	data.AveragePeriod = observation.AveragePeriod
+	data.Pressure = observation.Pressure
+	data.AirTemperature = observation.AirTemperature
+	data.WaterTemperature = observation.WaterTemperature
+	data.DewpointTemperature = observation.DewPoint
+	return data
+}
+
+// Reads the year from the backfilled observations, downloading it from NDBC
+// when the station has not been backfilled
+func fetchYearObservations(ctx context.Context, client *http.Client, stationID string, year int) ([]archive.Observation, error) {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	query := datastore.NewQuery(archivedObservationKind).
+		Filter("StationID =", stationID).
+		Filter("Date >=", start).
+		Filter("Date <", start.AddDate(1, 0, 0))
+
+	observations := []archive.Observation{}
+	if _, queryErr := query.GetAll(ctx, &observations); queryErr != nil {
+		return nil, queryErr
+	}
+	if len(observations) > 0 {
+		return observations, nil
+	}
+	return archive.Fetch(client, stationID, year)
+}
+
+func findAnnualExtremes(stationID string, year int, observations []archive.Observation) AnnualExtremes {
+	extremes := AnnualExtremes{
+		StationID:    stationID,
+		Year:         year,
+		Observations: len(observations),
+	}
+
+	extremes.WaveHeight = largestEvents(observations, missingHeightMarker, func(observation archive.Observation) float64 { return observation.WaveHeight })
+	for index, event := range extremes.WaveHeight {
+		for _, observation := range observations {
+			if !observation.Date.Equal(event.Date) {
+				continue
+			}
+			if isValidReading(observation.DominantPeriod, missingPeriodMarker) {
+				extremes.WaveHeight[index].DominantPeriod = observation.DominantPeriod
+			}
+			if isValidReading(observation.MeanWaveDirection, missingDirectionMarker) {
+				extremes.WaveHeight[index].MeanWaveDirection = observation.MeanWaveDirection
+			}
+			break
+		}
+	}
+	extremes.WindSpeed = largestEvents(observations, missingSpeedMarker, func(observation archive.Observation) float64 { return observation.WindSpeed })
+	extremes.WindGust = largestEvents(observations, missingSpeedMarker, func(observation archive.Observation) float64 { return observation.WindGust })
+
+	extremes.MaxAirTemperature, extremes.MinAirTemperature = temperatureExtremes(observations, func(observation archive.Observation) float64 { return observation.AirTemperature })
+	extremes.MaxWaterTemperature, extremes.MinWaterTemperature = temperatureExtremes(observations, func(observation archive.Observation) float64 { return observation.WaterTemperature })
+
+	return extremes
+}
+
+// The highest readings, largest first, keeping only the peak of each storm
+func largestEvents(observations []archive.Observation, marker float64, reading func(archive.Observation) float64) []ExtremeEvent {
+	candidates := []ExtremeEvent{}
+	for _, observation := range observations {
+		if value := reading(observation); isValidReading(value, marker) {
+			candidates = append(candidates, ExtremeEvent{Date: observation.Date, Value: value})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Value > candidates[j].Value
+	})
+
+	events := []ExtremeEvent{}
+	for _, candidate := range candidates {
+		separate := true
+		for _, event := range events {
+			if absDuration(candidate.Date.Sub(event.Date)) < extremeEventSeparation {
+				separate = false
+				break
+			}
+		}
+		if separate {
+			events = append(events, candidate)
+			if len(events) == maxExtremeEvents {
+				break
+			}
+		}
+	}
+	return events
+}
+
+func temperatureExtremes(observations []archive.Observation, reading func(archive.Observation) float64) (*ExtremeEvent, *ExtremeEvent) {
+	var max, min *ExtremeEvent
+	for _, observation := range observations {
+		value := reading(observation)
+		if !isValidReading(value, missingTemperatureMarker) {
+			continue
+		}
+		if max == nil || value > max.Value {
+			max = &ExtremeEvent{Date: observation.Date, Value: value}
+		}
+		if min == nil || value < min.Value {
+			min = &ExtremeEvent{Date: observation.Date, Value: value}
+		}
+	}
+	return max, min
+}
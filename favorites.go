@@ -0,0 +1,186 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/datastore"
+)
+
+const favoritesKind = "Favorites"
+
+// Each favorite is another set of requests to NDBC for the latest endpoint
+const maxFavorites = 25
+
+var errTooManyFavorites = errors.New("Only 25 stations can be saved as favorites")
+
+// The stations a user has saved, stored under the user's id
+type Favorites struct {
+	Stations []string
+	Updated  time.Time
+}
+
+type FavoriteRequest struct {
+	StationID string
+}
+
+// The latest conditions for each favorite. Errors holds the stations that
+// could not be fetched.
+type FavoritesLatest struct {
+	Buoys  []ClosestBuoy
+	Errors map[string]string `json:",omitempty"`
+}
+
+func listFavoritesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	favorites, favoritesErr := fetchRequestFavorites(ctx, w, r)
+	if favoritesErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, favoritesErr)
+		return
+	}
+
+	writeDataResponse(w, r, nil, favorites)
+}
+
+func addFavoriteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	request := FavoriteRequest{}
+	if decodeErr := json.NewDecoder(r.Body).Decode(&request); decodeErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("Invalid request body: "+decodeErr.Error()))
+		return
+	}
+	stationID := strings.ToUpper(strings.TrimSpace(request.StationID))
+	if stationID == "" {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("A station id is required"))
+		return
+	}
+
+	favorites, updateErr := updateRequestFavorites(ctx, w, r, func(favorites *Favorites) error {
+		if containsString(favorites.Stations, stationID) {
+			return nil
+		}
+		if len(favorites.Stations) >= maxFavorites {
+			return errTooManyFavorites
+		}
+		favorites.Stations = append(favorites.Stations, stationID)
+		return nil
+	})
+	if updateErr == errTooManyFavorites {
+		writeErrorResponse(w, r, http.StatusBadRequest, updateErr)
+		return
+	} else if updateErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, updateErr)
+		return
+	}
+
+	writeDataResponse(w, r, nil, favorites)
+}
+
+func deleteFavoriteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+	stationID := strings.ToUpper(mux.Vars(r)["station"])
+
+	favorites, updateErr := updateRequestFavorites(ctx, w, r, func(favorites *Favorites) error {
+		stations := []string{}
+		for _, favorite := range favorites.Stations {
+			if favorite != stationID {
+				stations = append(stations, favorite)
+			}
+		}
+		favorites.Stations = stations
+		return nil
+	})
+	if updateErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, updateErr)
+		return
+	}
+
+	writeDataResponse(w, r, nil, favorites)
+}
+
+func latestFavoritesHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	favorites, favoritesErr := fetchRequestFavorites(ctx, w, r)
+	if favoritesErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, favoritesErr)
+		return
+	}
+
+	buoys, errs := fetchLatestBuoys(ctx, client, favorites.Stations)
+
+	latest := FavoritesLatest{Buoys: []ClosestBuoy{}}
+	for index, buoy := range buoys {
+		if buoy == nil {
+			if latest.Errors == nil {
+				latest.Errors = map[string]string{}
+			}
+			latest.Errors[favorites.Stations[index]] = errs[index].Error()
+			continue
+		}
+		latest.Buoys = append(latest.Buoys, *buoy)
+	}
+
+	if wantsInflux(r) {
+		lines := ""
+		for _, buoy := range latest.Buoys {
+			lines += influxLine(buoy.BuoyStationID, buoy.BuoyData)
+		}
+		writeInflux(w, lines)
+		return
+	}
+
+	writeDataResponse(w, r, client, &latest)
+}
+
+func fetchRequestFavorites(ctx context.Context, w http.ResponseWriter, r *http.Request) (*Favorites, error) {
+	userID, userIDErr := requestUserID(ctx, w, r)
+	if userIDErr != nil {
+		return nil, userIDErr
+	}
+
+	favorites := &Favorites{Stations: []string{}}
+	getErr := datastore.Get(ctx, datastore.NewKey(ctx, favoritesKind, userID, 0, nil), favorites)
+	if getErr != nil && getErr != datastore.ErrNoSuchEntity {
+		return nil, getErr
+	}
+	return favorites, nil
+}
+
+// Applies the change to the user's favorites inside a transaction so two tabs
+// adding favorites at once do not drop one of them
+func updateRequestFavorites(ctx context.Context, w http.ResponseWriter, r *http.Request, change func(*Favorites) error) (*Favorites, error) {
+	userID, userIDErr := requestUserID(ctx, w, r)
+	if userIDErr != nil {
+		return nil, userIDErr
+	}
+
+	favorites := &Favorites{}
+	key := datastore.NewKey(ctx, favoritesKind, userID, 0, nil)
+	updateErr := datastore.RunInTransaction(ctx, func(tx context.Context) error {
+		favorites = &Favorites{Stations: []string{}}
+		if getErr := datastore.Get(tx, key, favorites); getErr != nil && getErr != datastore.ErrNoSuchEntity {
+			return getErr
+		}
+		if changeErr := change(favorites); changeErr != nil {
+			return changeErr
+		}
+		favorites.Updated = time.Now()
+		_, putErr := datastore.Put(tx, key, favorites)
+		return putErr
+	}, nil)
+	if updateErr != nil {
+		return nil, updateErr
+	}
+	return favorites, nil
+}
@@ -0,0 +1,140 @@
+package buoyfinder
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/appengine/urlfetch"
+)
+
+// A single upstream request made while serving a response
+type UpstreamFetch struct {
+	URL        string
+	StatusCode int `json:",omitempty"`
+	DurationMS float64
+	Error      string `json:",omitempty"`
+}
+
+// The most that is read of an upstream response. The 45 day realtime files
+// are at most a couple of megabytes and chart images far less, so anything
+// bigger is junk from a misbehaving server.
+const (
+	maxNDBCResponseSize  = 8 << 20
+	maxChartResponseSize = 4 << 20
+)
+
+var errResponseTooLarge = errors.New("The upstream response was too large")
+
+// Reads the body of a successful response, failing instead of truncating when
+// it is over the limit
+func readUpstreamBody(resp *http.Response, limit int64) ([]byte, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("The upstream server responded with " + resp.Status)
+	}
+
+	contents, readErr := ioutil.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if readErr != nil {
+		return nil, readErr
+	}
+	if int64(len(contents)) > limit {
+		return nil, errResponseTooLarge
+	}
+	return contents, nil
+}
+
+// Reads one of NDBC's data files. Outages and missing files are sometimes
+// answered with an html page and a 200, which the parsers would take as
+// garbled data.
+func readNDBCBody(resp *http.Response) ([]byte, error) {
+	if contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); contentType == "text/html" {
+		return nil, errors.New("NDBC responded with an html page instead of data")
+	}
+	return readUpstreamBody(resp, maxNDBCResponseSize)
+}
+
+// Wraps the urlfetch transport to keep track of what was fetched upstream and
+// how long it took
+type fetchRecorder struct {
+	transport http.RoundTripper
+	// The handler's context, which bounds the wait for an outbound slot
+	ctx     context.Context
+	start   time.Time
+	mutex   sync.Mutex
+	fetches []UpstreamFetch
+}
+
+func (self *fetchRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := self.throttledRoundTrip(req)
+
+	fetch := UpstreamFetch{
+		URL:        req.URL.String(),
+		DurationMS: ToFixedPoint(time.Since(start).Seconds()*1000.0, 1),
+	}
+	if err != nil {
+		fetch.Error = err.Error()
+	} else {
+		fetch.StatusCode = resp.StatusCode
+	}
+
+	self.mutex.Lock()
+	self.fetches = append(self.fetches, fetch)
+	self.mutex.Unlock()
+
+	return resp, err
+}
+
+// Waits for a free slot before fetching from the upstreams that throttle.
+// urlfetch reads the whole body before returning, so the slot covers the
+// download too.
+func (self *fetchRecorder) throttledRoundTrip(req *http.Request) (*http.Response, error) {
+	if !isThrottledHost(req.URL.Hostname()) {
+		return self.transport.RoundTrip(req)
+	}
+
+	if slotErr := acquireOutboundSlot(self.ctx); slotErr != nil {
+		return nil, slotErr
+	}
+	defer releaseOutboundSlot()
+	return self.transport.RoundTrip(req)
+}
+
+// Creates the client every handler uses to talk to NDBC and the chart
+// exporter
+func newFetchClient(ctx context.Context) *http.Client {
+	return &http.Client{
+		Transport: &fetchRecorder{
+			transport: &urlfetch.Transport{Context: ctx},
+			ctx:       ctx,
+			start:     time.Now(),
+		},
+	}
+}
+
+// How long the client has been around, which is how long the handler that
+// created it has been running
+func requestDuration(client *http.Client) time.Duration {
+	recorder, ok := client.Transport.(*fetchRecorder)
+	if !ok {
+		return 0
+	}
+	return time.Since(recorder.start)
+}
+
+// The upstream requests the client has made so far
+func recordedFetches(client *http.Client) []UpstreamFetch {
+	recorder, ok := client.Transport.(*fetchRecorder)
+	if !ok {
+		return nil
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return append([]UpstreamFetch{}, recorder.fetches...)
+}
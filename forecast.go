@@ -0,0 +1,121 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+)
+
+// Open-Meteo serves the global wave model output as json for any point, so
+// there is no need to read the model grids ourselves
+const marineForecastURL = "https://marine-api.open-meteo.com/v1/marine"
+
+// How far ahead the model runs reach
+const marineForecastDays = 7
+
+var errOutsideForecast = errors.New("The time is outside of the forecast")
+
+// The model conditions at a point for one hour, in meters, seconds, and
+// degrees. Readings the model has nothing for, like points on land, are null.
+type ForecastConditions struct {
+	Date           time.Time
+	Source         string
+	WaveHeight     *float64
+	WavePeriod     *float64
+	WaveDirection  *float64
+	SwellHeight    *float64
+	SwellPeriod    *float64
+	SwellDirection *float64
+}
+
+type marineForecastResponse struct {
+	Hourly struct {
+		Time           []int64    `json:"time"`
+		WaveHeight     []*float64 `json:"wave_height"`
+		WavePeriod     []*float64 `json:"wave_period"`
+		WaveDirection  []*float64 `json:"wave_direction"`
+		SwellHeight    []*float64 `json:"swell_wave_height"`
+		SwellPeriod    []*float64 `json:"swell_wave_period"`
+		SwellDirection []*float64 `json:"swell_wave_direction"`
+	} `json:"hourly"`
+}
+
+// The forecast hour closest to the date at the location
+func fetchMarineForecast(client *http.Client, location surfnerd.Location, date time.Time) (*ForecastConditions, error) {
+	hours, fetchErr := fetchMarineForecastHours(client, location, 1)
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	closest := -1
+	for index, hour := range hours {
+		if closest < 0 || absDuration(hour.Date.Sub(date)) < absDuration(hours[closest].Date.Sub(date)) {
+			closest = index
+		}
+	}
+	if closest < 0 || absDuration(hours[closest].Date.Sub(date)) > time.Hour {
+		return nil, errOutsideForecast
+	}
+	return &hours[closest], nil
+}
+
+// Every forecast hour at the location, from pastDays ago to the end of the
+// model run. The past hours are the ones the model forecast at the time.
+func fetchMarineForecastHours(client *http.Client, location surfnerd.Location, pastDays int) ([]ForecastConditions, error) {
+	query := url.Values{}
+	query.Set("latitude", strconv.FormatFloat(location.Latitude, 'f', 4, 64))
+	query.Set("longitude", strconv.FormatFloat(location.Longitude, 'f', 4, 64))
+	query.Set("hourly", "wave_height,wave_period,wave_direction,swell_wave_height,swell_wave_period,swell_wave_direction")
+	query.Set("timeformat", "unixtime")
+	query.Set("past_days", strconv.Itoa(pastDays))
+	query.Set("forecast_days", strconv.Itoa(marineForecastDays))
+
+	resp, fetchErr := client.Get(marineForecastURL + "?" + query.Encode())
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("The forecast could not be fetched: " + resp.Status)
+	}
+
+	forecast := marineForecastResponse{}
+	if decodeErr := json.NewDecoder(resp.Body).Decode(&forecast); decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	hourly := forecast.Hourly
+	hours := []ForecastConditions{}
+	for index, epoch := range hourly.Time {
+		value := func(values []*float64) *float64 {
+			if index >= len(values) {
+				return nil
+			}
+			return values[index]
+		}
+		hours = append(hours, ForecastConditions{
+			Date:           time.Unix(epoch, 0).UTC(),
+			Source:         "Open-Meteo Marine",
+			WaveHeight:     value(hourly.WaveHeight),
+			WavePeriod:     value(hourly.WavePeriod),
+			WaveDirection:  value(hourly.WaveDirection),
+			SwellHeight:    value(hourly.SwellHeight),
+			SwellPeriod:    value(hourly.SwellPeriod),
+			SwellDirection: value(hourly.SwellDirection),
+		})
+	}
+	return hours, nil
+}
+
+func absDuration(duration time.Duration) time.Duration {
+	if duration < 0 {
+		return -duration
+	}
+	return duration
+}
@@ -0,0 +1,132 @@
+// Package geo computes distances and bearings between points on the earth.
+package geo
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+type Algorithm string
+
+const (
+	// Great circle distance on a sphere. Fast, but off by up to half a
+	// percent since the earth is flattened at the poles.
+	Haversine Algorithm = "haversine"
+	// Geodesic distance on the WGS-84 ellipsoid, accurate to millimeters
+	Vincenty Algorithm = "vincenty"
+)
+
+const DefaultAlgorithm = Haversine
+
+const (
+	MeanEarthRadiusKM = 6371.0088
+
+	// WGS-84 ellipsoid
+	wgs84SemiMajorAxisKM = 6378.137
+	wgs84Flattening      = 1 / 298.257223563
+	wgs84SemiMinorAxisKM = wgs84SemiMajorAxisKM * (1 - wgs84Flattening)
+)
+
+var ErrVincentyNoConvergence = errors.New("Vincenty formula failed to converge")
+
+// Parses an algorithm name, falling back to the default for anything unknown
+func ParseAlgorithm(name string) Algorithm {
+	switch Algorithm(strings.ToLower(name)) {
+	case Vincenty:
+		return Vincenty
+	case Haversine:
+		return Haversine
+	}
+	return DefaultAlgorithm
+}
+
+// Distance in kilometers between the two points using the given algorithm.
+// Vincenty falls back to haversine for the nearly antipodal points it can't
+// solve.
+func Distance(algorithm Algorithm, lat1, lon1, lat2, lon2 float64) float64 {
+	if algorithm == Vincenty {
+		if distance, err := VincentyDistance(lat1, lon1, lat2, lon2); err == nil {
+			return distance
+		}
+	}
+	return HaversineDistance(lat1, lon1, lat2, lon2)
+}
+
+func HaversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := toRadians(lat1)
+	phi2 := toRadians(lat2)
+	dPhi := phi2 - phi1
+	dLambda := toRadians(lon2 - lon1)
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return MeanEarthRadiusKM * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// Solves the inverse geodesic problem with Vincenty's iterative formula
+func VincentyDistance(lat1, lon1, lat2, lon2 float64) (float64, error) {
+	a, b, f := wgs84SemiMajorAxisKM, wgs84SemiMinorAxisKM, wgs84Flattening
+
+	L := toRadians(lon2 - lon1)
+	U1 := math.Atan((1 - f) * math.Tan(toRadians(lat1)))
+	U2 := math.Atan((1 - f) * math.Tan(toRadians(lat2)))
+	sinU1, cosU1 := math.Sin(U1), math.Cos(U1)
+	sinU2, cosU2 := math.Sin(U2), math.Cos(U2)
+
+	lambda := L
+	var sinSigma, cosSigma, sigma, cos2Alpha, cos2SigmaM float64
+	converged := false
+	for iteration := 0; iteration < 200; iteration++ {
+		sinLambda, cosLambda := math.Sin(lambda), math.Cos(lambda)
+		sinSigma = math.Sqrt(math.Pow(cosU2*sinLambda, 2) + math.Pow(cosU1*sinU2-sinU1*cosU2*cosLambda, 2))
+		if sinSigma == 0 {
+			// Coincident points
+			return 0, nil
+		}
+		cosSigma = sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma = math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cos2Alpha = 1 - sinAlpha*sinAlpha
+		cos2SigmaM = 0
+		if cos2Alpha != 0 {
+			// Both points on the equator otherwise
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cos2Alpha
+		}
+		C := f / 16 * cos2Alpha * (4 + f*(4-3*cos2Alpha))
+		previousLambda := lambda
+		lambda = L + (1-C)*f*sinAlpha*(sigma+C*sinSigma*(cos2SigmaM+C*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-previousLambda) < 1e-12 {
+			converged = true
+			break
+		}
+	}
+	if !converged {
+		return 0, ErrVincentyNoConvergence
+	}
+
+	u2 := cos2Alpha * (a*a - b*b) / (b * b)
+	A := 1 + u2/16384*(4096+u2*(-768+u2*(320-175*u2)))
+	B := u2 / 1024 * (256 + u2*(-128+u2*(74-47*u2)))
+	deltaSigma := B * sinSigma * (cos2SigmaM + B/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-B/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+
+	return b * A * (sigma - deltaSigma), nil
+}
+
+// Initial bearing in degrees from the first point towards the second
+func InitialBearing(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := toRadians(lat1)
+	phi2 := toRadians(lat2)
+	dLambda := toRadians(lon2 - lon1)
+
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+	return math.Mod(toDegrees(math.Atan2(y, x))+360.0, 360.0)
+}
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180.0
+}
+
+func toDegrees(radians float64) float64 {
+	return radians * 180.0 / math.Pi
+}
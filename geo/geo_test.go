@@ -0,0 +1,81 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+// Degrees, minutes, and seconds as decimal degrees
+func dms(degrees, minutes, seconds float64) float64 {
+	if degrees < 0 {
+		return degrees - minutes/60 - seconds/3600
+	}
+	return degrees + minutes/60 + seconds/3600
+}
+
+func TestVincentyDistance(t *testing.T) {
+	cases := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		// Expected distance and tolerance in meters
+		meters, tolerance float64
+	}{
+		// Vincenty's own test line, from his 1975 paper
+		{"Flinders Peak to Buninyong", dms(-37, 57, 3.72030), dms(144, 25, 29.52440), dms(-37, 39, 10.15610), dms(143, 55, 35.38390), 54972.271, 0.001},
+		// The WGS-84 meridian arc from the equator to the pole
+		{"Quarter meridian", 0, 0, 90, 0, 10001965.729, 0.001},
+		{"Coincident points", 41.5, -71.3, 41.5, -71.3, 0, 0},
+	}
+
+	for _, c := range cases {
+		distance, err := VincentyDistance(c.lat1, c.lon1, c.lat2, c.lon2)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+			continue
+		}
+		if meters := distance * 1000; math.Abs(meters-c.meters) > c.tolerance {
+			t.Errorf("%s: got %.4f m, want %.3f m", c.name, meters, c.meters)
+		}
+	}
+}
+
+func TestVincentyDistanceNearlyAntipodal(t *testing.T) {
+	if _, err := VincentyDistance(0, 0, 0.5, 179.7); err != ErrVincentyNoConvergence {
+		t.Errorf("got error %v, want %v", err, ErrVincentyNoConvergence)
+	}
+
+	// Distance falls back to haversine instead of failing
+	if distance := Distance(Vincenty, 0, 0, 0.5, 179.7); distance != HaversineDistance(0, 0, 0.5, 179.7) {
+		t.Errorf("got %.3f km, want the haversine distance", distance)
+	}
+}
+
+// The sphere is too short along high latitude meridians, where the ellipsoid
+// is flattest, so the two disagree by kilometers
+func TestHaversineDivergesAtHighLatitude(t *testing.T) {
+	// The WGS-84 meridian arc from 70 to 80 degrees north
+	const arcMeters = 8885139.872 - 7768980.728
+
+	vincenty, err := VincentyDistance(70, 0, 80, 0)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if meters := vincenty * 1000; math.Abs(meters-arcMeters) > 0.01 {
+		t.Errorf("vincenty: got %.4f m, want %.3f m", meters, arcMeters)
+	}
+
+	haversine := HaversineDistance(70, 0, 80, 0)
+	if difference := vincenty - haversine; difference < 4 {
+		t.Errorf("got haversine %.3f km and vincenty %.3f km, want them over 4 km apart", haversine, vincenty)
+	}
+}
+
+func TestHaversineDistance(t *testing.T) {
+	// One degree of a great circle on the mean sphere
+	if distance := HaversineDistance(0, 0, 0, 1); math.Abs(distance-MeanEarthRadiusKM*math.Pi/180) > 1e-9 {
+		t.Errorf("got %.6f km for one degree of longitude", distance)
+	}
+	if distance := HaversineDistance(41.5, -71.3, 41.5, -71.3); distance != 0 {
+		t.Errorf("got %.6f km for coincident points", distance)
+	}
+}
@@ -1,6 +1,10 @@
 package buoyfinder
 
-import "github.com/lucasb-eyer/go-colorful"
+import (
+	"net/http"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
 
 // This table contains the "keypoints" of the colorgradient you want to generate.
 // The position of each keypoint has to live in the range [0,1]
@@ -27,6 +31,13 @@ func (self Gradient) GetInterpolatedColorFor(t float64) colorful.Color {
 	return self[len(self)-1].Col
 }
 
+// Looks up the color a fraction of the way along the gradient, for scales that
+// are not measured in the same units as the keypoints
+func (self Gradient) GetInterpolatedColorForFraction(fraction float64) colorful.Color {
+	first, last := self[0].Pos, self[len(self)-1].Pos
+	return self.GetInterpolatedColorFor(first + (last-first)*fraction)
+}
+
 // This is a very nice thing Golang forces you to do!
 // It is necessary so that we can write out the literal of the colortable below.
 func MustParseHex(s string) colorful.Color {
@@ -55,3 +66,47 @@ func NewGradient() Gradient {
 
 	return keypoints
 }
+
+// The palettes that can be picked with ?palette=. Viridis and cividis stay
+// readable for the common forms of color blindness, cividis even for people
+// who cannot tell red from green at all.
+var gradientPalettes = map[string]func() Gradient{
+	"spectral": NewGradient,
+	"viridis":  NewViridisGradient,
+	"cividis":  NewCividisGradient,
+}
+
+const defaultPalette = "spectral"
+
+// The palette name from ?palette=, falling back to the default for anything
+// unknown
+func parsePalette(r *http.Request) string {
+	if palette := r.URL.Query().Get("palette"); gradientPalettes[palette] != nil {
+		return palette
+	}
+	return defaultPalette
+}
+
+func parseGradient(r *http.Request) Gradient {
+	return gradientPalettes[parsePalette(r)]()
+}
+
+func NewViridisGradient() Gradient {
+	return newEvenGradient("#440154", "#482878", "#3e4989", "#31688e", "#26828e", "#1f9e89", "#35b779", "#6ece58", "#b5de2b", "#fde725")
+}
+
+func NewCividisGradient() Gradient {
+	return newEvenGradient("#00224e", "#123570", "#3b496c", "#575d6d", "#707173", "#8a8779", "#a69d75", "#c4b56c", "#e4cf5b", "#fee838")
+}
+
+// Spreads the colors evenly over the same range as the default gradient so the
+// palettes can be swapped for each other
+func newEvenGradient(hexes ...string) Gradient {
+	first, last := 3.0, 13.5
+	keypoints := make(Gradient, len(hexes))
+	for index, hex := range hexes {
+		keypoints[index].Col = MustParseHex(hex)
+		keypoints[index].Pos = first + (last-first)*float64(index)/float64(len(hexes)-1)
+	}
+	return keypoints
+}
@@ -0,0 +1,246 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/datastore"
+)
+
+// Searching with an empty target would otherwise list every field of every
+// station
+const maxGrafanaSearchResults = 100
+
+// Queries before the realtime files read a year of the archive at a time
+const maxGrafanaRange = 366 * 24 * time.Hour
+
+type grafanaRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+type grafanaQueryRequest struct {
+	Range   grafanaRange `json:"range"`
+	Targets []struct {
+		Target string `json:"target"`
+	} `json:"targets"`
+	MaxDataPoints int `json:"maxDataPoints"`
+}
+
+type grafanaTimeSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+type grafanaAnnotationRequest struct {
+	Range      grafanaRange `json:"range"`
+	Annotation struct {
+		Name  string `json:"name"`
+		Query string `json:"query"`
+	} `json:"annotation"`
+}
+
+type grafanaAnnotation struct {
+	Annotation interface{} `json:"annotation"`
+	Time       int64       `json:"time"`
+	TimeEnd    int64       `json:"timeEnd,omitempty"`
+	IsRegion   bool        `json:"isRegion,omitempty"`
+	Title      string      `json:"title"`
+	Text       string      `json:"text"`
+	Tags       []string    `json:"tags"`
+}
+
+// Grafana checks the datasource by requesting its root
+func grafanaTestHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write([]byte("OK"))
+}
+
+// Lists the STATION:field targets of the active stations whose id starts with
+// the search
+func grafanaSearchHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	search := struct {
+		Target string `json:"target"`
+	}{}
+	json.NewDecoder(r.Body).Decode(&search)
+
+	stations, stationsError := fetchStations(ctx, client)
+	if stationsError != nil {
+		http.Error(w, stationsError.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// A search can name a field too, like 44097:wv
+	searchStation, searchField := strings.ToUpper(search.Target), ""
+	if split := strings.Index(searchStation, ":"); split >= 0 {
+		searchStation, searchField = searchStation[:split], strings.ToLower(searchStation[split+1:])
+	}
+
+	targets := []string{}
+	for _, station := range stations.Stations {
+		stationID := strings.ToUpper(station.StationID)
+		if station.Active == "n" || !strings.HasPrefix(stationID, searchStation) {
+			continue
+		}
+		for _, field := range observationFields {
+			if strings.HasPrefix(field, searchField) {
+				targets = append(targets, stationID+":"+field)
+			}
+		}
+		if len(targets) >= maxGrafanaSearchResults {
+			targets = targets[:maxGrafanaSearchResults]
+			break
+		}
+	}
+	sort.Strings(targets)
+
+	writeGrafanaResponse(w, targets)
+}
+
+// Answers with a time series of the field for each STATION:field target over
+// the range, from the realtime standard data and the archive before it.
+// Ranges with more observations than maxDataPoints are averaged down to it.
+func grafanaQueryHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	query := grafanaQueryRequest{}
+	if decodeErr := json.NewDecoder(r.Body).Decode(&query); decodeErr != nil {
+		http.Error(w, decodeErr.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.Range.To.IsZero() {
+		query.Range.To = time.Now()
+	}
+	if query.Range.From.IsZero() || !query.Range.From.Before(query.Range.To) {
+		http.Error(w, "The range must start before it ends", http.StatusBadRequest)
+		return
+	}
+	if query.Range.To.Sub(query.Range.From) > maxGrafanaRange {
+		http.Error(w, "Only a year of data can be queried at a time", http.StatusBadRequest)
+		return
+	}
+
+	// Targets of the same station share one fetch
+	observations := map[string][]surfnerd.BuoyDataItem{}
+	series := []grafanaTimeSeries{}
+	for _, target := range query.Targets {
+		stationID, field, targetErr := parseGrafanaTarget(target.Target)
+		if targetErr != nil {
+			http.Error(w, targetErr.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if _, ok := observations[stationID]; !ok {
+			stationObservations, fetchErr := fetchStandardObservationRange(ctx, client, stationID, query.Range.From, query.Range.To)
+			if fetchErr != nil {
+				http.Error(w, fetchErr.Error(), http.StatusBadGateway)
+				return
+			}
+			for index := range stationObservations {
+				stationObservations[index].ChangeUnits(surfnerd.Metric)
+			}
+			if query.MaxDataPoints > 0 && len(stationObservations) > query.MaxDataPoints {
+				every := grafanaDownsampleInterval(query.Range.To.Sub(query.Range.From), query.MaxDataPoints)
+				stationObservations = meanDownsampleObservations(stationObservations, every)
+			}
+			observations[stationID] = stationObservations
+		}
+
+		timeSeries := grafanaTimeSeries{Target: target.Target, Datapoints: [][2]float64{}}
+		for _, item := range observations[stationID] {
+			for _, reading := range validReadings(item) {
+				if reading.Field == field {
+					timeSeries.Datapoints = append(timeSeries.Datapoints, [2]float64{reading.Value, float64(item.Date.Unix() * 1000)})
+				}
+			}
+		}
+		series = append(series, timeSeries)
+	}
+
+	writeGrafanaResponse(w, series)
+}
+
+// The bucket size that averages the range down to at most points
+// observations. Buckets are centered on multiples of the size, so a range
+// can touch one more bucket than it spans.
+func grafanaDownsampleInterval(span time.Duration, points int) time.Duration {
+	if points < 2 {
+		return span + time.Second
+	}
+	return span / time.Duration(points-1)
+}
+
+// Marks the outages of the station in the annotation query over the range
+func grafanaAnnotationsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	request := grafanaAnnotationRequest{}
+	if decodeErr := json.NewDecoder(r.Body).Decode(&request); decodeErr != nil {
+		http.Error(w, decodeErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stationID := strings.ToUpper(strings.TrimSpace(request.Annotation.Query))
+	outages := []Outage{}
+	if _, outagesErr := datastore.NewQuery(outageKind).Filter("StationID =", stationID).GetAll(ctx, &outages); outagesErr != nil {
+		http.Error(w, outagesErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	annotations := []grafanaAnnotation{}
+	for _, outage := range outages {
+		end := outage.End
+		if outage.Ongoing {
+			end = time.Now()
+		}
+		if outage.Start.After(request.Range.To) || end.Before(request.Range.From) {
+			continue
+		}
+
+		annotations = append(annotations, grafanaAnnotation{
+			Annotation: request.Annotation,
+			Time:       outage.Start.Unix() * 1000,
+			TimeEnd:    end.Unix() * 1000,
+			IsRegion:   true,
+			Title:      "Station " + stationID + " " + outage.BuoyStatus(),
+			Text:       "Last observed " + outage.Start.UTC().Format("01/02/2006 15:04 UTC"),
+			Tags:       []string{stationID, outage.BuoyStatus()},
+		})
+	}
+
+	writeGrafanaResponse(w, annotations)
+}
+
+func parseGrafanaTarget(target string) (string, string, error) {
+	parts := strings.Split(target, ":")
+	if len(parts) != 2 || parts[0] == "" || !containsString(observationFields, strings.ToLower(parts[1])) {
+		return "", "", errors.New("Targets look like 44097:wvht, not " + target)
+	}
+	return strings.ToUpper(parts[0]), strings.ToLower(parts[1]), nil
+}
+
+// Grafana expects bare JSON rather than the api envelope
+func writeGrafanaResponse(w http.ResponseWriter, v interface{}) {
+	encoded, encodeErr := json.Marshal(v)
+	if encodeErr != nil {
+		http.Error(w, encodeErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(encoded)
+}
@@ -0,0 +1,51 @@
+package buoyfinder
+
+import (
+	"github.com/mpiannucci/surfnerd"
+)
+
+// Waves on the lakes are all seas raised by the local wind, so reports there
+// call them seas instead of swell
+const lakeWaveTerm = "seas"
+
+// The Great Lakes as rough boxes around each lake. The boxes take in some of
+// the shore, which suits lookups from towns on the lake, and the smaller lakes
+// are listed first so they win where the boxes overlap.
+var greatLakes = []struct {
+	Name                     string
+	South, West, North, East float64
+}{
+	{"Lake St. Clair", 42.3, -83.0, 42.7, -82.4},
+	{"Lake Erie", 41.35, -83.5, 42.9, -78.85},
+	{"Lake Ontario", 43.15, -79.9, 44.25, -76.05},
+	{"Lake Michigan", 41.6, -88.1, 46.1, -84.75},
+	{"Lake Huron", 43.0, -84.75, 46.3, -79.6},
+	{"Lake Superior", 46.4, -92.2, 49.0, -84.3},
+}
+
+// The lake the location is on, or an empty string for the ocean
+func greatLakeAt(location surfnerd.Location) string {
+	if !hasLocation(location) {
+		return ""
+	}
+
+	for _, lake := range greatLakes {
+		if location.Latitude >= lake.South && location.Latitude <= lake.North && location.Longitude >= lake.West && location.Longitude <= lake.East {
+			return lake.Name
+		}
+	}
+	return ""
+}
+
+// Lakes have no tides to speak of, and tide stations on them only publish
+// water levels, so tide predictions are left out there
+func hasTides(location surfnerd.Location) bool {
+	return greatLakeAt(location) == ""
+}
+
+func (self *ClosestBuoy) SetWaterBody() {
+	self.WaterBody = greatLakeAt(self.BuoyLocation)
+	if self.WaterBody != "" {
+		self.WaveTerm = lakeWaveTerm
+	}
+}
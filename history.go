@@ -0,0 +1,84 @@
+package buoyfinder
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+)
+
+// Some stations report every half hour, so a history of an hour can be two
+// observations deep
+const observationsPerHour = 2
+
+// NDBC keeps 45 days of realtime data, which caps how far back a request can
+// usefully reach
+const maxHistoryHours = 45 * 24
+const maxHistoryCount = maxHistoryHours * observationsPerHour
+
+// How many observations to read for a request. ?count= asks for an exact
+// number of observations and ?hours= for enough to cover that many hours.
+// Without either, enough are read to reach just past the requested date so
+// there is an observation on both sides of it.
+func parseHistoryCount(r *http.Request, requestedDate time.Time) (int, error) {
+	query := r.URL.Query()
+
+	if rawCount := query.Get("count"); rawCount != "" {
+		count, countErr := strconv.Atoi(rawCount)
+		if countErr != nil || count < 1 || count > maxHistoryCount {
+			return 0, errors.New("The count must be between 1 and " + strconv.Itoa(maxHistoryCount))
+		}
+		return count, nil
+	}
+
+	if rawHours := query.Get("hours"); rawHours != "" {
+		hours, hoursErr := strconv.Atoi(rawHours)
+		if hoursErr != nil || hours < 1 || hours > maxHistoryHours {
+			return 0, errors.New("The hours must be between 1 and " + strconv.Itoa(maxHistoryHours))
+		}
+		return hours * observationsPerHour, nil
+	}
+
+	return historyCountSince(requestedDate), nil
+}
+
+// Whether a date response should include every observation read to find the
+// requested one, with ?history=true. They are already downloaded, so the
+// window comes for free.
+func parseIncludeHistory(r *http.Request) bool {
+	includeHistory, _ := strconv.ParseBool(r.URL.Query().Get("history"))
+	return includeHistory
+}
+
+// Enough observations to reach back past the date. Dates in the future only
+// need the latest observation.
+func historyCountSince(date time.Time) int {
+	hours := time.Since(date).Hours()
+	if hours < 0 {
+		return 1
+	}
+
+	count := int(hours*observationsPerHour) + observationsPerHour
+	if count > maxHistoryCount {
+		return maxHistoryCount
+	}
+	return count
+}
+
+// The standard observations since the date, newest first
+func fetchStandardObservationsSince(client *http.Client, stationID string, since time.Time) ([]surfnerd.BuoyDataItem, error) {
+	buoy := &surfnerd.Buoy{StationID: stationID}
+	if fetchErr := fetchStandardBuoyData(client, buoy, historyCountSince(since)); fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	observations := []surfnerd.BuoyDataItem{}
+	for _, item := range buoy.BuoyData {
+		if !item.Date.Before(since) {
+			observations = append(observations, item)
+		}
+	}
+	return observations, nil
+}
@@ -0,0 +1,140 @@
+package buoyfinder
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/log"
+)
+
+// The service key IFTTT sends with every request to the service api
+const iftttSecretKey = "ifttt"
+
+const iftttSampleStation = "44097"
+
+type iftttTriggerRequest struct {
+	TriggerFields map[string]string `json:"triggerFields"`
+	// IFTTT leaves the limit out when it wants the default, and sends 0 when
+	// it wants none
+	Limit *int `json:"limit"`
+}
+
+func writeIFTTTResponse(w http.ResponseWriter, status int, body interface{}) {
+	encoded, encodeErr := json.Marshal(body)
+	if encodeErr != nil {
+		http.Error(w, encodeErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(encoded)
+}
+
+func writeIFTTTError(w http.ResponseWriter, status int, err error) {
+	writeIFTTTResponse(w, status, map[string]interface{}{
+		"errors": []map[string]string{{"message": err.Error()}},
+	})
+}
+
+// Whether the request carries our IFTTT service key
+func validIFTTTServiceKey(ctx context.Context, w http.ResponseWriter, r *http.Request) bool {
+	serviceKey, keyErr := fetchSecret(ctx, iftttSecretKey)
+	if keyErr != nil {
+		log.Errorf(ctx, "The IFTTT service key is not set up: %v", keyErr)
+		writeIFTTTError(w, http.StatusServiceUnavailable, errors.New("IFTTT is not set up"))
+		return false
+	}
+	if subtle.ConstantTimeCompare(serviceKey, []byte(r.Header.Get("IFTTT-Service-Key"))) != 1 {
+		writeIFTTTError(w, http.StatusUnauthorized, errors.New("Invalid service key"))
+		return false
+	}
+	return true
+}
+
+func iftttStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+	if !validIFTTTServiceKey(ctx, w, r) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// The trigger fields IFTTT's endpoint tests fill in
+func iftttTestSetupHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+	if !validIFTTTServiceKey(ctx, w, r) {
+		return
+	}
+
+	writeIFTTTResponse(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"samples": map[string]interface{}{
+				"triggers": map[string]interface{}{
+					newObservationTrigger:   map[string]string{"station": iftttSampleStation, "units": UnitsEnglish},
+					thresholdCrossedTrigger: map[string]string{"station": iftttSampleStation, "units": UnitsEnglish, "min_height": "1", "min_period": "1"},
+				},
+			},
+		},
+	})
+}
+
+// Serves both triggers, which take the same fields as the polling endpoints
+func iftttTriggerHandler(trigger string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctxParent := appengine.NewContext(r)
+		ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+		client := newFetchClient(ctx)
+
+		if !validIFTTTServiceKey(ctx, w, r) {
+			return
+		}
+
+		request := iftttTriggerRequest{}
+		if decodeErr := json.NewDecoder(r.Body).Decode(&request); decodeErr != nil {
+			writeIFTTTError(w, http.StatusBadRequest, errors.New("Invalid request body: "+decodeErr.Error()))
+			return
+		}
+		if request.TriggerFields == nil {
+			writeIFTTTError(w, http.StatusBadRequest, errors.New("The trigger fields are missing"))
+			return
+		}
+
+		stationID := strings.ToUpper(strings.TrimSpace(request.TriggerFields["station"]))
+		if stationID == "" {
+			writeIFTTTError(w, http.StatusBadRequest, errors.New("A station id is required"))
+			return
+		}
+		units := parseTriggerUnits(request.TriggerFields["units"])
+
+		limit := defaultTriggerLimit
+		if request.Limit != nil && *request.Limit >= 0 && *request.Limit < limit {
+			limit = *request.Limit
+		}
+
+		var events []TriggerEvent
+		var eventsErr error
+		if trigger == thresholdCrossedTrigger {
+			threshold, thresholdErr := parseTriggerThreshold(request.TriggerFields["min_height"], request.TriggerFields["min_period"], units)
+			if thresholdErr != nil {
+				writeIFTTTError(w, http.StatusBadRequest, thresholdErr)
+				return
+			}
+			events, eventsErr = thresholdCrossedEvents(client, requestBaseURL(r), stationID, units, threshold, limit)
+		} else {
+			events, eventsErr = newObservationEvents(client, requestBaseURL(r), stationID, units, limit)
+		}
+		if eventsErr != nil {
+			writeIFTTTError(w, http.StatusBadGateway, eventsErr)
+			return
+		}
+
+		writeIFTTTResponse(w, http.StatusOK, map[string]interface{}{"data": events})
+	}
+}
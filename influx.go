@@ -0,0 +1,38 @@
+package buoyfinder
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mpiannucci/surfnerd"
+)
+
+const influxContentType = "text/plain; charset=utf-8"
+
+var influxTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+func wantsInflux(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "influx"
+}
+
+// The observation as an InfluxDB line, with the fields named after the ndbc
+// columns. Missing readings are left out and the timestamp is in seconds, so
+// it is written with precision=s.
+func influxLine(stationID string, data surfnerd.BuoyDataItem) string {
+	fields := []string{}
+	for _, reading := range validReadings(data) {
+		fields = append(fields, reading.Field+"="+strconv.FormatFloat(reading.Value, 'f', -1, 64))
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+
+	return "buoy,station=" + influxTagEscaper.Replace(stationID) + " " + strings.Join(fields, ",") + " " + strconv.FormatInt(data.Date.Unix(), 10) + "\n"
+}
+
+func writeInflux(w http.ResponseWriter, lines string) {
+	w.Header().Set("Content-Type", influxContentType)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write([]byte(lines))
+}
@@ -0,0 +1,85 @@
+package buoyfinder
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+)
+
+// Interpolating across a bigger hole in the data than this would just be
+// making readings up
+const maxInterpolationGap = 3 * time.Hour
+
+func parseInterpolate(r *http.Request) bool {
+	interpolate, _ := strconv.ParseBool(r.URL.Query().Get("interpolate"))
+	return interpolate
+}
+
+// Finds the conditions at the date. When interpolate is set and the date falls
+// between two observations, the scalar readings are linearly interpolated
+// between them and the last return value is true. Everything else, like the
+// spectra and swell components, comes from the nearest observation.
+func findConditionsForDate(buoy *surfnerd.Buoy, date time.Time, interpolate bool) (surfnerd.BuoyDataItem, time.Duration, bool) {
+	nearest, timeDiff := buoy.FindConditionsForDateAndTime(date)
+	if !interpolate || timeDiff == 0 {
+		return nearest, timeDiff, false
+	}
+
+	var before, after *surfnerd.BuoyDataItem
+	for index := range buoy.BuoyData {
+		item := &buoy.BuoyData[index]
+		if !item.Date.After(date) && (before == nil || item.Date.After(before.Date)) {
+			before = item
+		}
+		if !item.Date.Before(date) && (after == nil || item.Date.Before(after.Date)) {
+			after = item
+		}
+	}
+	if before == nil || after == nil || after.Date.Sub(before.Date) > maxInterpolationGap {
+		return nearest, timeDiff, false
+	}
+
+	fraction := float64(date.Sub(before.Date)) / float64(after.Date.Sub(before.Date))
+	pressureMarker := missingPressureMarker
+	if nearest.Units == surfnerd.English {
+		pressureMarker = missingEnglishPressureMarker
+	}
+
+	interpolated := nearest
+	interpolated.Date = date
+	interpolated.WindSpeed = interpolateReading(nearest.WindSpeed, before.WindSpeed, after.WindSpeed, fraction, missingSpeedMarker)
+	interpolated.WindGust = interpolateReading(nearest.WindGust, before.WindGust, after.WindGust, fraction, missingSpeedMarker)
+	interpolated.WindDirection = interpolateDirection(nearest.WindDirection, before.WindDirection, after.WindDirection, fraction)
+	interpolated.WaveSummary.WaveHeight = interpolateReading(nearest.WaveSummary.WaveHeight, before.WaveSummary.WaveHeight, after.WaveSummary.WaveHeight, fraction, missingHeightMarker)
+	interpolated.WaveSummary.Period = interpolateReading(nearest.WaveSummary.Period, before.WaveSummary.Period, after.WaveSummary.Period, fraction, missingPeriodMarker)
+	interpolated.WaveSummary.Direction = interpolateDirection(nearest.WaveSummary.Direction, before.WaveSummary.Direction, after.WaveSummary.Direction, fraction)
+	interpolated.AveragePeriod = interpolateReading(nearest.AveragePeriod, before.AveragePeriod, after.AveragePeriod, fraction, missingPeriodMarker)
+	interpolated.Pressure = interpolateReading(nearest.Pressure, before.Pressure, after.Pressure, fraction, pressureMarker)
+	interpolated.AirTemperature = interpolateReading(nearest.AirTemperature, before.AirTemperature, after.AirTemperature, fraction, missingTemperatureMarker)
+	interpolated.WaterTemperature = interpolateReading(nearest.WaterTemperature, before.WaterTemperature, after.WaterTemperature, fraction, missingTemperatureMarker)
+	interpolated.DewpointTemperature = interpolateReading(nearest.DewpointTemperature, before.DewpointTemperature, after.DewpointTemperature, fraction, missingTemperatureMarker)
+
+	return interpolated, 0, true
+}
+
+// Falls back to the nearest reading when either side of the gap is missing
+func interpolateReading(nearest, before, after, fraction, missingMarker float64) float64 {
+	if !isValidReading(before, missingMarker) || !isValidReading(after, missingMarker) {
+		return nearest
+	}
+	return before + (after-before)*fraction
+}
+
+// Interpolates along the shorter way around the compass so 350 and 10 give 0
+// instead of 180
+func interpolateDirection(nearest, before, after, fraction float64) float64 {
+	if !isValidReading(before, missingDirectionMarker) || !isValidReading(after, missingDirectionMarker) {
+		return nearest
+	}
+
+	delta := math.Mod(after-before+540.0, 360.0) - 180.0
+	return math.Mod(before+delta*fraction+360.0, 360.0)
+}
@@ -0,0 +1,61 @@
+package buoyfinder
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+)
+
+// The uppercased, deduplicated station ids from a comma separated
+// ?stations= list
+func parseStationList(r *http.Request, maxStations int) ([]string, error) {
+	stationIDs := []string{}
+	for _, stationID := range strings.Split(r.URL.Query().Get("stations"), ",") {
+		if stationID = strings.ToUpper(strings.TrimSpace(stationID)); stationID != "" && !containsString(stationIDs, stationID) {
+			stationIDs = append(stationIDs, stationID)
+		}
+	}
+	if len(stationIDs) == 0 || len(stationIDs) > maxStations {
+		return nil, errors.New("Between 1 and " + strconv.Itoa(maxStations) + " stations can be requested")
+	}
+	return stationIDs, nil
+}
+
+// Fetches the latest metric conditions of every station at the same time. A
+// station that could not be fetched has a nil buoy and its error.
+func fetchLatestBuoys(ctx context.Context, client *http.Client, stationIDs []string) ([]*ClosestBuoy, []error) {
+	var wg sync.WaitGroup
+	now := time.Now()
+	buoys := make([]*ClosestBuoy, len(stationIDs))
+	errs := make([]error, len(stationIDs))
+	for index, stationID := range stationIDs {
+		wg.Add(1)
+		go func(index int, stationID string) {
+			defer wg.Done()
+			buoy := &surfnerd.Buoy{StationID: stationID}
+			if fetchErr := fetchLatestBuoyData(client, buoy); fetchErr != nil {
+				errs[index] = fetchErr
+				return
+			}
+
+			buoyData, timeDiff := buoy.FindConditionsForDateAndTime(now)
+			buoyData.ChangeUnits(surfnerd.Metric)
+			buoys[index] = &ClosestBuoy{
+				RequestedDate: now,
+				TimeDiffFound: timeDiff,
+				BuoyStationID: stationID,
+				BuoyStatus:    fetchBuoyStatus(ctx, stationID),
+				BuoyData:      buoyData,
+			}
+		}(index, stationID)
+	}
+	wg.Wait()
+
+	return buoys, errs
+}
@@ -0,0 +1,306 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/log"
+	"google.golang.org/appengine/memcache"
+)
+
+const nwsAPIURL = "https://api.weather.gov"
+
+// The NWS api turns away requests that do not say who is asking
+const nwsUserAgent = "buoyfinder (https://buoyfinder.appspot.com)"
+
+// Zone boundaries almost never move, while forecasts are issued a few times a
+// day and warnings can be issued at any time
+const (
+	marineZoneCacheExpiration     = 24 * time.Hour
+	marineForecastCacheExpiration = time.Hour
+	marineWarningsCacheExpiration = 5 * time.Minute
+)
+
+var errNoMarineZone = errors.New("The location is not in an NWS marine forecast zone")
+
+// A coastal or offshore forecast zone, like ANZ235
+type MarineZone struct {
+	ID   string
+	Name string
+	Type string
+}
+
+type MarineForecastPeriod struct {
+	Name     string
+	Forecast string
+}
+
+// An active advisory or warning for the zone, like a small craft advisory or
+// a gale warning
+type MarineWarning struct {
+	Event    string
+	Severity string
+	Headline string
+	Onset    time.Time
+	Expires  time.Time
+}
+
+// The parts of a warning a client needs to badge a station
+type ConditionsWarning struct {
+	Type     string
+	Severity string
+	Expires  time.Time
+}
+
+type MarineConditions struct {
+	RequestedLocation surfnerd.Location
+	Zone              MarineZone
+	Forecast          []MarineForecastPeriod
+	Warnings          []MarineWarning
+	ClosestBuoy       *ClosestBuoy `json:",omitempty"`
+	Errors            []string     `json:",omitempty"`
+}
+
+func marineConditionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	latitude, _ := strconv.ParseFloat(vars["lat"], 64)
+	longitude, _ := strconv.ParseFloat(vars["lon"], 64)
+	requestedLocation := surfnerd.NewLocationForLatLong(latitude, longitude)
+
+	closestBuoy, closestError := fetchClosestBuoy(ctx, client, requestedLocation, parseClosestBuoyOptions(r))
+	stationID := ""
+	if closestError == nil {
+		stationID = closestBuoy.StationID
+	}
+	writeMarineConditions(ctx, w, r, client, requestedLocation, stationID)
+}
+
+// The marine conditions at the station's mooring
+func stationMarineConditionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+
+	buoy, buoyErr := fetchBuoyWithID(ctx, client, stationID)
+	if buoyErr != nil {
+		writeErrorResponse(w, r, http.StatusNotFound, buoyErr)
+		return
+	}
+	if buoy.Location == nil {
+		writeErrorResponse(w, r, http.StatusNotFound, errors.New("Station "+stationID+" has no location"))
+		return
+	}
+	writeMarineConditions(ctx, w, r, client, *buoy.Location, stationID)
+}
+
+// Looks up the zone and then its forecast, warnings, and the buoy's latest
+// conditions. Only a missing zone fails the request, the rest are left out
+// with their errors listed.
+func writeMarineConditions(ctx context.Context, w http.ResponseWriter, r *http.Request, client *http.Client, location surfnerd.Location, stationID string) {
+	zone, zoneErr := fetchMarineZone(ctx, client, location)
+	if zoneErr == errNoMarineZone {
+		writeErrorResponse(w, r, http.StatusNotFound, zoneErr)
+		return
+	} else if zoneErr != nil {
+		writeErrorResponse(w, r, http.StatusBadGateway, zoneErr)
+		return
+	}
+
+	conditions := MarineConditions{
+		RequestedLocation: location,
+		Zone:              *zone,
+		Forecast:          []MarineForecastPeriod{},
+		Warnings:          []MarineWarning{},
+	}
+
+	if forecast, forecastErr := fetchMarineZoneForecast(ctx, client, *zone); forecastErr != nil {
+		conditions.Errors = append(conditions.Errors, "Forecast: "+forecastErr.Error())
+	} else {
+		conditions.Forecast = forecast
+	}
+
+	if warnings, warningsErr := fetchMarineWarnings(ctx, client, zone.ID); warningsErr != nil {
+		conditions.Errors = append(conditions.Errors, "Warnings: "+warningsErr.Error())
+	} else {
+		conditions.Warnings = warnings
+	}
+
+	if stationID != "" {
+		buoys, errs := fetchLatestBuoys(ctx, client, []string{stationID})
+		if buoys[0] == nil {
+			conditions.Errors = append(conditions.Errors, "Observation: "+errs[0].Error())
+		} else {
+			conditions.ClosestBuoy = buoys[0]
+		}
+	}
+
+	writeDataResponse(w, r, client, &conditions)
+}
+
+// Decodes the json at the NWS api path, keeping it in the cache for the
+// expiration
+func fetchNWSJSON(ctx context.Context, client *http.Client, path string, expiration time.Duration, into interface{}) error {
+	cacheKey := "nws:" + path
+	if _, cacheErr := memcache.JSON.Get(ctx, cacheKey, into); cacheErr == nil {
+		return nil
+	}
+
+	req, reqErr := http.NewRequest("GET", nwsAPIURL+path, nil)
+	if reqErr != nil {
+		return reqErr
+	}
+	req.Header.Set("User-Agent", nwsUserAgent)
+	req.Header.Set("Accept", "application/geo+json")
+
+	resp, fetchErr := client.Do(req)
+	if fetchErr != nil {
+		return fetchErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("The NWS could not be reached: " + resp.Status)
+	}
+	if decodeErr := json.NewDecoder(resp.Body).Decode(into); decodeErr != nil {
+		return decodeErr
+	}
+
+	memcache.JSON.Set(ctx, &memcache.Item{
+		Key:        cacheKey,
+		Object:     into,
+		Expiration: expiration,
+	})
+	return nil
+}
+
+func fetchMarineZone(ctx context.Context, client *http.Client, location surfnerd.Location) (*MarineZone, error) {
+	// Rounded so nearby requests share a cache entry
+	point := strconv.FormatFloat(location.Latitude, 'f', 3, 64) + "," + strconv.FormatFloat(location.Longitude, 'f', 3, 64)
+	query := url.Values{}
+	query.Set("point", point)
+	query.Set("type", "coastal,offshore")
+
+	response := struct {
+		Features []struct {
+			Properties struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+				Type string `json:"type"`
+			} `json:"properties"`
+		} `json:"features"`
+	}{}
+	if fetchErr := fetchNWSJSON(ctx, client, "/zones?"+query.Encode(), marineZoneCacheExpiration, &response); fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	// Points near the coast sit in both, and the coastal zone is the one
+	// with the more detailed forecast
+	var zone *MarineZone
+	for _, feature := range response.Features {
+		if zone == nil || feature.Properties.Type == "coastal" {
+			zone = &MarineZone{ID: feature.Properties.ID, Name: feature.Properties.Name, Type: feature.Properties.Type}
+		}
+	}
+	if zone == nil {
+		return nil, errNoMarineZone
+	}
+	return zone, nil
+}
+
+func fetchMarineZoneForecast(ctx context.Context, client *http.Client, zone MarineZone) ([]MarineForecastPeriod, error) {
+	response := struct {
+		Properties struct {
+			Periods []struct {
+				Name             string `json:"name"`
+				DetailedForecast string `json:"detailedForecast"`
+			} `json:"periods"`
+		} `json:"properties"`
+	}{}
+	if fetchErr := fetchNWSJSON(ctx, client, "/zones/"+zone.Type+"/"+zone.ID+"/forecast", marineForecastCacheExpiration, &response); fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	periods := []MarineForecastPeriod{}
+	for _, period := range response.Properties.Periods {
+		periods = append(periods, MarineForecastPeriod{Name: period.Name, Forecast: period.DetailedForecast})
+	}
+	return periods, nil
+}
+
+func fetchMarineWarnings(ctx context.Context, client *http.Client, zoneID string) ([]MarineWarning, error) {
+	response := struct {
+		Features []struct {
+			Properties struct {
+				Event    string    `json:"event"`
+				Severity string    `json:"severity"`
+				Headline string    `json:"headline"`
+				Onset    time.Time `json:"onset"`
+				Expires  time.Time `json:"expires"`
+			} `json:"properties"`
+		} `json:"features"`
+	}{}
+	if fetchErr := fetchNWSJSON(ctx, client, "/alerts/active?zone="+url.QueryEscape(zoneID), marineWarningsCacheExpiration, &response); fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	warnings := []MarineWarning{}
+	for _, feature := range response.Features {
+		properties := feature.Properties
+		warnings = append(warnings, MarineWarning{
+			Event:    properties.Event,
+			Severity: properties.Severity,
+			Headline: properties.Headline,
+			Onset:    properties.Onset,
+			Expires:  properties.Expires,
+		})
+	}
+	return warnings, nil
+}
+
+// The active warnings for the marine zone the station's mooring is in. Stations
+// outside of the NWS zones, and lookups that fail, have none.
+func fetchStationWarnings(ctx context.Context, client *http.Client, container *ClosestBuoy) []ConditionsWarning {
+	location := container.BuoyLocation
+	if !hasLocation(location) {
+		buoy, buoyErr := fetchBuoyWithID(ctx, client, container.BuoyStationID)
+		if buoyErr != nil || buoy.Location == nil {
+			return nil
+		}
+		location = *buoy.Location
+	}
+
+	zone, zoneErr := fetchMarineZone(ctx, client, location)
+	if zoneErr != nil {
+		if zoneErr != errNoMarineZone {
+			log.Warningf(ctx, "Could not find the marine zone for %s: %v", container.BuoyStationID, zoneErr)
+		}
+		return nil
+	}
+	warnings, warningsErr := fetchMarineWarnings(ctx, client, zone.ID)
+	if warningsErr != nil {
+		log.Warningf(ctx, "Could not fetch the warnings for %s: %v", zone.ID, warningsErr)
+		return nil
+	}
+
+	flags := []ConditionsWarning{}
+	for _, warning := range warnings {
+		flags = append(flags, ConditionsWarning{Type: warning.Event, Severity: warning.Severity, Expires: warning.Expires})
+	}
+	return flags
+}
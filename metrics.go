@@ -0,0 +1,84 @@
+package buoyfinder
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+// Each station is another request to NDBC on every scrape
+const maxMetricsStations = 25
+
+// The gauges exported for each station, in metric units
+var buoyGauges = []struct {
+	Name   string
+	Help   string
+	Marker float64
+	Value  func(surfnerd.BuoyDataItem) float64
+}{
+	{"buoy_wave_height_meters", "Significant wave height", missingHeightMarker, func(data surfnerd.BuoyDataItem) float64 { return data.WaveSummary.WaveHeight }},
+	{"buoy_dominant_period_seconds", "Dominant wave period", missingPeriodMarker, func(data surfnerd.BuoyDataItem) float64 { return data.WaveSummary.Period }},
+	{"buoy_wave_direction_degrees", "Mean wave direction", missingDirectionMarker, func(data surfnerd.BuoyDataItem) float64 { return data.WaveSummary.Direction }},
+	{"buoy_wind_speed_meters_per_second", "Wind speed", missingSpeedMarker, func(data surfnerd.BuoyDataItem) float64 { return data.WindSpeed }},
+	{"buoy_wind_gust_meters_per_second", "Wind gust", missingSpeedMarker, func(data surfnerd.BuoyDataItem) float64 { return data.WindGust }},
+	{"buoy_wind_direction_degrees", "Wind direction", missingDirectionMarker, func(data surfnerd.BuoyDataItem) float64 { return data.WindDirection }},
+	{"buoy_water_temperature_celsius", "Water temperature", missingTemperatureMarker, func(data surfnerd.BuoyDataItem) float64 { return data.WaterTemperature }},
+	{"buoy_air_temperature_celsius", "Air temperature", missingTemperatureMarker, func(data surfnerd.BuoyDataItem) float64 { return data.AirTemperature }},
+}
+
+// Exposes the latest conditions of the ?stations= as Prometheus gauges. Missing
+// readings are left out rather than reported as the ndbc markers.
+func buoyMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	stationIDs, stationsErr := parseStationList(r, maxMetricsStations)
+	if stationsErr != nil {
+		http.Error(w, stationsErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	buoys, _ := fetchLatestBuoys(ctx, client, stationIDs)
+
+	metrics := bytes.Buffer{}
+	writeMetricHeader(&metrics, "buoy_up", "Whether the latest observation of the station could be fetched")
+	for index, stationID := range stationIDs {
+		up := 0
+		if buoys[index] != nil {
+			up = 1
+		}
+		fmt.Fprintf(&metrics, "buoy_up{station=%q} %d\n", stationID, up)
+	}
+
+	writeMetricHeader(&metrics, "buoy_observation_timestamp_seconds", "When the latest observation was made")
+	for _, buoy := range buoys {
+		if buoy != nil && !buoy.BuoyData.Date.IsZero() {
+			fmt.Fprintf(&metrics, "buoy_observation_timestamp_seconds{station=%q} %d\n", buoy.BuoyStationID, buoy.BuoyData.Date.Unix())
+		}
+	}
+
+	for _, gauge := range buoyGauges {
+		writeMetricHeader(&metrics, gauge.Name, gauge.Help)
+		for _, buoy := range buoys {
+			if buoy == nil {
+				continue
+			}
+			if value := gauge.Value(buoy.BuoyData); isValidReading(value, gauge.Marker) {
+				fmt.Fprintf(&metrics, "%s{station=%q} %g\n", gauge.Name, buoy.BuoyStationID, value)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(metrics.Bytes())
+}
+
+func writeMetricHeader(metrics *bytes.Buffer, name, help string) {
+	fmt.Fprintf(metrics, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
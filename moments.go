@@ -0,0 +1,33 @@
+package buoyfinder
+
+import (
+	"math"
+
+	"github.com/mpiannucci/surfnerd"
+)
+
+// The nth moment of the energy spectrum, the sum of E(f) f^n df over the
+// bands. The zeroth moment is the variance of the sea surface.
+func spectralMoment(spectra surfnerd.BuoySpectraItem, n float64) float64 {
+	moment := 0.0
+	for index, frequency := range spectra.Frequencies {
+		if index >= len(spectra.Energies) || frequency <= 0 {
+			continue
+		}
+		moment += spectra.Energies[index] * math.Pow(frequency, n) * spectralBandwidth(spectra.Frequencies, index)
+	}
+	return moment
+}
+
+// The width of the band around the frequency, halfway to each neighbor
+func spectralBandwidth(frequencies []float64, index int) float64 {
+	switch {
+	case len(frequencies) < 2:
+		return 0
+	case index == 0:
+		return frequencies[1] - frequencies[0]
+	case index == len(frequencies)-1:
+		return frequencies[index] - frequencies[index-1]
+	}
+	return (frequencies[index+1] - frequencies[index-1]) / 2
+}
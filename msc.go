@@ -0,0 +1,274 @@
+package buoyfinder
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+)
+
+// The Meteorological Service of Canada publishes each hourly buoy report as
+// its own SWOB-ML file on the datamart, in a directory per day and station
+const (
+	mscStationListURL = "https://dd.weather.gc.ca/observations/doc/swob-xml_marine_station_list.csv"
+	mscSWOBURL        = "https://dd.weather.gc.ca/observations/swob-ml/marine/moored-buoys/"
+)
+
+var mscSWOBFileRegex = regexp.MustCompile(`href="([^"]+-swob\.xml)"`)
+
+// The SWOB element names each reading can be reported under, in order of
+// preference. They vary between buoy generations.
+var mscElementNames = map[string][]string{
+	"wind_speed":        {"avg_wnd_spd_pst10mts", "avg_wnd_spd_10m_pst10mts", "wnd_spd"},
+	"wind_direction":    {"avg_wnd_dir_pst10mts", "avg_wnd_dir_10m_pst10mts", "wnd_dir"},
+	"wind_gust":         {"max_wnd_gst_spd_pst10mts", "max_wnd_spd_pst10mts", "max_wnd_spd_10m_pst10mts"},
+	"pressure":          {"mslp", "stn_pres"},
+	"air_temperature":   {"avg_air_temp_pst10mts", "air_temp"},
+	"water_temperature": {"avg_sfc_wtr_temp_pst10mts", "sfc_wtr_temp", "avg_sea_sfc_temp_pst1hr"},
+	"dewpoint":          {"dwpt_temp", "avg_dwpt_temp_pst10mts"},
+	"wave_height":       {"sig_wav_ht_pst20mts", "avg_wav_ht_pst20mts", "sig_wav_ht"},
+	"dominant_period":   {"pk_wav_pd_pst20mts", "wav_pk_pd_pst20mts", "pk_wav_pd"},
+	"average_period":    {"avg_wav_pd_pst20mts", "avg_wav_pd"},
+}
+
+// Environment Canada's moored buoys in the Atlantic, the Pacific, and the
+// Great Lakes
+type mscProvider struct{}
+
+func (mscProvider) Prefix() string {
+	return "MSC"
+}
+
+func (mscProvider) Capabilities() []string {
+	return []string{CapabilityWaves, CapabilityMeteorology}
+}
+
+func (self mscProvider) FetchStations(client *http.Client) ([]surfnerd.Buoy, error) {
+	resp, fetchErr := client.Get(mscStationListURL)
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("The MSC station list could not be fetched: " + resp.Status)
+	}
+
+	reader := csv.NewReader(resp.Body)
+	reader.FieldsPerRecord = -1
+	rows, readErr := reader.ReadAll()
+	if readErr != nil {
+		return nil, readErr
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("The MSC station list is empty")
+	}
+
+	columns := map[string]int{}
+	for index, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = index
+	}
+	column := func(row []string, name string) string {
+		if index, ok := columns[name]; ok && index < len(row) {
+			return strings.TrimSpace(row[index])
+		}
+		return ""
+	}
+
+	stations := []surfnerd.Buoy{}
+	for _, row := range rows[1:] {
+		// The marine list also has ships and coastal stations
+		if !strings.Contains(strings.ToLower(column(row, "dataset/network")), "buoy") {
+			continue
+		}
+
+		stationID := column(row, "wmo_id")
+		if stationID == "" {
+			stationID = column(row, "msc_id")
+		}
+		latitude, latitudeErr := strconv.ParseFloat(column(row, "latitude"), 64)
+		longitude, longitudeErr := strconv.ParseFloat(column(row, "longitude"), 64)
+		if stationID == "" || latitudeErr != nil || longitudeErr != nil {
+			continue
+		}
+
+		stations = append(stations, surfnerd.Buoy{
+			StationID: self.Prefix() + strings.ToUpper(stationID),
+			Owner:     "Environment and Climate Change Canada",
+			PGM:       "MSC",
+			Type:      "buoy",
+			Active:    "y",
+			Location: &surfnerd.Location{
+				Latitude:     latitude,
+				Longitude:    longitude,
+				LocationName: column(row, "name"),
+			},
+		})
+	}
+	return stations, nil
+}
+
+func (mscProvider) FetchObservations(client *http.Client, stationID string, count int) ([]surfnerd.BuoyDataItem, error) {
+	// Reports are hourly, so only the last two days are read
+	files := []string{}
+	now := time.Now().UTC()
+	for _, day := range []time.Time{now, now.AddDate(0, 0, -1)} {
+		dayFiles, listErr := fetchMSCFileList(client, day, stationID)
+		if listErr != nil {
+			return nil, listErr
+		}
+		files = append(files, dayFiles...)
+	}
+
+	// The file names start with the observation time so they sort by it
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	if len(files) > count {
+		files = files[:count]
+	}
+
+	observations := make([]*surfnerd.BuoyDataItem, len(files))
+	wg := sync.WaitGroup{}
+	for index, file := range files {
+		wg.Add(1)
+		go func(index int, file string) {
+			defer wg.Done()
+			if observation, fetchErr := fetchMSCObservation(client, file); fetchErr == nil {
+				observations[index] = observation
+			}
+		}(index, file)
+	}
+	wg.Wait()
+
+	items := []surfnerd.BuoyDataItem{}
+	for _, observation := range observations {
+		if observation != nil {
+			items = append(items, *observation)
+		}
+	}
+	return items, nil
+}
+
+// The SWOB files the station reported on the day, or none if it did not
+func fetchMSCFileList(client *http.Client, day time.Time, stationID string) ([]string, error) {
+	dayURL := mscSWOBURL + day.Format("20060102") + "/" + stationID + "/"
+	resp, fetchErr := client.Get(dayURL)
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return []string{}, nil
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("The MSC observations for station " + stationID + " could not be listed: " + resp.Status)
+	}
+
+	contents, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	files := []string{}
+	for _, match := range mscSWOBFileRegex.FindAllStringSubmatch(string(contents), -1) {
+		files = append(files, dayURL+match[1])
+	}
+	return files, nil
+}
+
+type swobCollection struct {
+	Members []swobMember `xml:"member"`
+}
+
+type swobMember struct {
+	SamplingTime string        `xml:"Observation>samplingTime>TimeInstant>timePosition"`
+	Elements     []swobElement `xml:"Observation>result>elements>element"`
+}
+
+type swobElement struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+	Unit  string `xml:"uom,attr"`
+}
+
+func fetchMSCObservation(client *http.Client, fileURL string) (*surfnerd.BuoyDataItem, error) {
+	resp, fetchErr := client.Get(fileURL)
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("The MSC observation could not be fetched: " + resp.Status)
+	}
+
+	collection := swobCollection{}
+	if decodeErr := xml.NewDecoder(resp.Body).Decode(&collection); decodeErr != nil {
+		return nil, decodeErr
+	}
+	if len(collection.Members) == 0 {
+		return nil, errors.New("The MSC observation is empty")
+	}
+	return parseSWOBObservation(collection.Members[0])
+}
+
+// Normalizes the SWOB elements into a metric observation
+func parseSWOBObservation(member swobMember) (*surfnerd.BuoyDataItem, error) {
+	date, dateErr := time.Parse(time.RFC3339, strings.TrimSpace(member.SamplingTime))
+	if dateErr != nil {
+		return nil, dateErr
+	}
+
+	elements := map[string]swobElement{}
+	for _, element := range member.Elements {
+		elements[element.Name] = element
+	}
+	value := func(reading string, marker float64) float64 {
+		for _, name := range mscElementNames[reading] {
+			element, ok := elements[name]
+			if !ok {
+				continue
+			}
+			parsed, parseErr := strconv.ParseFloat(element.Value, 64)
+			if parseErr != nil {
+				continue
+			}
+			return toMetricSWOBValue(parsed, element.Unit)
+		}
+		return marker
+	}
+
+	observation := newMissingObservation(date.UTC())
+	observation.WindSpeed = value("wind_speed", missingSpeedMarker)
+	observation.WindDirection = value("wind_direction", missingDirectionMarker)
+	observation.WindGust = value("wind_gust", missingSpeedMarker)
+	observation.Pressure = value("pressure", missingPressureMarker)
+	observation.AirTemperature = value("air_temperature", missingTemperatureMarker)
+	observation.WaterTemperature = value("water_temperature", missingTemperatureMarker)
+	observation.DewpointTemperature = value("dewpoint", missingTemperatureMarker)
+	observation.WaveSummary.WaveHeight = value("wave_height", missingHeightMarker)
+	observation.WaveSummary.Period = value("dominant_period", missingPeriodMarker)
+	observation.AveragePeriod = value("average_period", missingPeriodMarker)
+	return &observation, nil
+}
+
+// SWOB reports wind in km/h and pressure in kPa at some stations
+func toMetricSWOBValue(value float64, unit string) float64 {
+	switch strings.ToLower(unit) {
+	case "km/h":
+		return value / 3.6
+	case "knots", "kt":
+		return value / metersPerSecondToKnots
+	case "kpa":
+		return value * 10.0
+	}
+	return value
+}
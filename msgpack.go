@@ -0,0 +1,62 @@
+package buoyfinder
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/vmihailenco/msgpack"
+)
+
+// MessagePack is selected with ?format=msgpack for clients on links where
+// even compressed JSON is too large
+const msgpackContentType = "application/x-msgpack"
+
+func wantsMsgpack(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "msgpack"
+}
+
+// Packs the JSON encoding of a response, so the nulls for missing readings,
+// the QC objects, and the field names are the same in both formats
+func writeMsgpack(w http.ResponseWriter, status int, encoded []byte) {
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var value interface{}
+	if decodeErr := decoder.Decode(&value); decodeErr != nil {
+		http.Error(w, decodeErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	packed := bytes.Buffer{}
+	if packErr := msgpack.NewEncoder(&packed).SortMapKeys(true).UseCompactEncoding(true).Encode(msgpackValue(value)); packErr != nil {
+		http.Error(w, packErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", msgpackContentType)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(status)
+	w.Write(packed.Bytes())
+}
+
+// Swaps the decoded JSON numbers for integers or floats, which would
+// otherwise be packed as strings
+func msgpackValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case json.Number:
+		if integer, intErr := typed.Int64(); intErr == nil {
+			return integer
+		}
+		float, _ := typed.Float64()
+		return float
+	case map[string]interface{}:
+		for key, item := range typed {
+			typed[key] = msgpackValue(item)
+		}
+	case []interface{}:
+		for index, item := range typed {
+			typed[index] = msgpackValue(item)
+		}
+	}
+	return value
+}
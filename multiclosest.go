@@ -0,0 +1,77 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+// Keeps a single request from walking the station list for an entire coast
+const maxClosestBuoyLocations = 50
+
+type ClosestBuoysRequest struct {
+	Locations []surfnerd.Location
+}
+
+// The closest buoy to one of the requested locations. Error is set instead of
+// the buoy fields when no suitable buoy was found.
+type ClosestBuoyMatch struct {
+	RequestedLocation surfnerd.Location
+	BuoyStationID     string             `json:",omitempty"`
+	BuoyStatus        string             `json:",omitempty"`
+	BuoyLocation      *surfnerd.Location `json:",omitempty"`
+	DistanceKM        float64            `json:",omitempty"`
+	DistanceNM        float64            `json:",omitempty"`
+	Bearing           float64            `json:",omitempty"`
+	Error             string             `json:",omitempty"`
+}
+
+func closestBuoysHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	request := ClosestBuoysRequest{}
+	if decodeErr := json.NewDecoder(r.Body).Decode(&request); decodeErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("Invalid request body: "+decodeErr.Error()))
+		return
+	}
+	if len(request.Locations) > maxClosestBuoyLocations {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("Too many locations requested"))
+		return
+	}
+
+	stations, stationsError := fetchAllStations(ctx, client)
+	if stationsError != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, stationsError)
+		return
+	}
+
+	options := parseClosestBuoyOptions(r)
+	fresh := map[string]bool{}
+	matches := make([]ClosestBuoyMatch, len(request.Locations))
+	for index, location := range request.Locations {
+		matches[index].RequestedLocation = location
+
+		closestBuoy, closestError := selectClosestBuoy(ctx, client, stations, location, options, fresh)
+		if closestError != nil {
+			matches[index].Error = closestError.Error()
+			continue
+		}
+		matches[index].BuoyStationID = closestBuoy.StationID
+		matches[index].BuoyStatus = fetchBuoyStatus(ctx, closestBuoy.StationID)
+		matches[index].BuoyLocation = closestBuoy.Location
+
+		distance := distanceWith(options.DistanceAlgorithm, location, *closestBuoy.Location)
+		matches[index].DistanceKM = ToFixedPoint(distance, 2)
+		matches[index].DistanceNM = ToFixedPoint(distance/kmPerNauticalMile, 2)
+		matches[index].Bearing = ToFixedPoint(bearingBetween(location, *closestBuoy.Location), 1)
+	}
+
+	writeDataResponse(w, r, client, &matches)
+}
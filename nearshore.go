@@ -0,0 +1,231 @@
+package buoyfinder
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+const gravity = 9.81
+
+// Waves break once they are about this fraction of the water depth
+const breakerDepthRatio = 0.78
+
+// The buoy spectrum carried into shallower water with linear wave theory.
+// Energies are in m^2/Hz, heights and depths in meters, and directions are
+// where each band comes from. Depths of 0 mean deep water.
+type NearshoreSpectrum struct {
+	StationID   string
+	Date        time.Time
+	BuoyDepth   float64
+	TargetDepth float64
+	ShoreFacing float64
+	Frequencies []float64
+	Energies    []float64
+	Directions  []float64
+	// The significant height of the transformed spectrum
+	WaveHeight float64
+	PeakPeriod float64
+	// Estimated from the refracted deep water height and the peak period
+	BreakerHeight float64
+	// Set when the waves are too big for the target depth and have been
+	// capped at the height they would break at
+	DepthLimited bool `json:",omitempty"`
+}
+
+// Transforms the latest spectrum to ?depth= meters of water off a shoreline
+// facing ?facing= degrees. ?spot= fills in both from the spot instead. The
+// buoy's depth comes from its station page or the bathymetry unless
+// ?buoydepth= overrides it.
+func nearshoreSpectrumHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+
+	targetDepth, depthErr := strconv.ParseFloat(r.URL.Query().Get("depth"), 64)
+	facing, facingErr := strconv.ParseFloat(r.URL.Query().Get("facing"), 64)
+	if spotID := r.URL.Query().Get("spot"); spotID != "" {
+		spot, spotErr := fetchSpot(ctx, spotID)
+		if spotErr == errSpotNotFound {
+			writeErrorResponse(w, r, http.StatusNotFound, spotErr)
+			return
+		} else if spotErr != nil {
+			writeErrorResponse(w, r, http.StatusInternalServerError, spotErr)
+			return
+		}
+		if depthErr != nil && spot.Depth > 0 {
+			targetDepth, depthErr = spot.Depth, nil
+		}
+		if facingErr != nil {
+			facing, facingErr = spot.BeachFacing, nil
+		}
+	}
+
+	if depthErr != nil || targetDepth <= 0 {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("The target depth must be a positive number of meters"))
+		return
+	}
+	if facingErr != nil || !isValidHeading(facing) {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("The shoreline facing must be between 0 and 360 degrees"))
+		return
+	}
+
+	buoy, buoyErr := fetchBuoyWithID(ctx, client, stationID)
+	if buoyErr != nil {
+		writeErrorResponse(w, r, http.StatusNotFound, buoyErr)
+		return
+	}
+
+	buoyDepth, buoyDepthErr := strconv.ParseFloat(r.URL.Query().Get("buoydepth"), 64)
+	if buoyDepthErr != nil || buoyDepth < 0 {
+		buoyDepth = 0
+		if metadata, metadataErr := fetchStationMetadata(ctx, client, buoy); metadataErr == nil {
+			buoyDepth = metadata.WaterDepth
+		}
+		if buoyDepth <= 0 && buoy.Location != nil {
+			if depth, bathymetryErr := fetchWaterDepth(ctx, client, *buoy.Location); bathymetryErr == nil {
+				buoyDepth = depth.Depth
+			}
+		}
+	}
+
+	if fetchErr := fetchDetailedWaveBuoyData(client, buoy, 1, parseSpectraSmoothing(r)); fetchErr != nil {
+		writeErrorResponse(w, r, waveFetchErrorStatus(fetchErr, http.StatusInternalServerError), fetchErr)
+		return
+	}
+	if len(buoy.BuoyData) == 0 || len(buoy.BuoyData[0].WaveSpectra.Energies) == 0 {
+		writeErrorResponse(w, r, http.StatusNotFound, errors.New("Station "+stationID+" has no wave spectra"))
+		return
+	}
+
+	spectrum := transformSpectrum(buoy.BuoyData[0], buoyDepth, targetDepth, facing)
+	spectrum.StationID = stationID
+
+	envelope := newResponseEnvelope(r, client, spectrum)
+	envelope.SetObservation(stationID, spectrum.Date)
+	writeEnvelope(w, r, http.StatusOK, envelope)
+}
+
+// Shoals and refracts each frequency band over straight and parallel depth
+// contours. Energy flux is conserved along the rays, so each band scales by
+// the ratio of group velocities and of ray spacings, and Snell's law turns
+// it towards the shore normal as it slows down. Bands heading away from the
+// shore never arrive.
+func transformSpectrum(data surfnerd.BuoyDataItem, buoyDepth, targetDepth, facing float64) NearshoreSpectrum {
+	spectrum := NearshoreSpectrum{
+		Date:        data.Date,
+		BuoyDepth:   buoyDepth,
+		TargetDepth: targetDepth,
+		ShoreFacing: facing,
+		Frequencies: []float64{},
+		Energies:    []float64{},
+		Directions:  []float64{},
+	}
+
+	frequencies := data.WaveSpectra.Frequencies
+	energies := data.WaveSpectra.Energies
+	angles := data.WaveSpectra.Angles
+
+	nearshoreEnergy, deepEnergy, peakEnergy, peakPeriod := 0.0, 0.0, 0.0, 0.0
+	for index, frequency := range frequencies {
+		if index >= len(energies) || index >= len(angles) || frequency <= 0 {
+			continue
+		}
+
+		energy := energies[index]
+		incidence := normalizeAngle(angles[index] - facing)
+		transformed, direction, deep := 0.0, angles[index], 0.0
+		if math.Abs(incidence) < 90 {
+			shoaling := groupVelocity(frequency, buoyDepth) / groupVelocity(frequency, targetDepth)
+			sinIncidence := math.Sin(incidence*math.Pi/180.0) * phaseVelocity(frequency, targetDepth) / phaseVelocity(frequency, buoyDepth)
+			refracted := math.Asin(math.Max(math.Min(sinIncidence, 1), -1))
+			refraction := math.Cos(incidence*math.Pi/180.0) / math.Cos(refracted)
+
+			transformed = energy * shoaling * refraction
+			direction = math.Mod(facing+refracted*180.0/math.Pi+360.0, 360.0)
+			deep = energy * groupVelocity(frequency, buoyDepth) / groupVelocity(frequency, 0) * refraction
+		}
+
+		bandwidth := spectralBandwidth(frequencies, index)
+		nearshoreEnergy += transformed * bandwidth
+		deepEnergy += deep * bandwidth
+		if transformed > peakEnergy {
+			peakEnergy, peakPeriod = transformed, 1.0/frequency
+		}
+
+		spectrum.Frequencies = append(spectrum.Frequencies, frequency)
+		spectrum.Energies = append(spectrum.Energies, ToFixedPoint(transformed, 4))
+		spectrum.Directions = append(spectrum.Directions, ToFixedPoint(direction, 1))
+	}
+
+	height := 4.0 * math.Sqrt(nearshoreEnergy)
+	if maxHeight := breakerDepthRatio * targetDepth; height > maxHeight {
+		height = maxHeight
+		spectrum.DepthLimited = true
+	}
+	spectrum.WaveHeight = ToFixedPoint(height, 2)
+	spectrum.PeakPeriod = ToFixedPoint(peakPeriod, 1)
+
+	// Komar and Gaylord's breaker height from the refracted deep water height
+	deepHeight := 4.0 * math.Sqrt(deepEnergy)
+	if peakPeriod > 0 && deepHeight > 0 {
+		spectrum.BreakerHeight = ToFixedPoint(0.39*math.Pow(gravity, 0.2)*math.Pow(peakPeriod*deepHeight*deepHeight, 0.4), 2)
+	}
+	return spectrum
+}
+
+// Wraps the angle to between -180 and 180 degrees
+func normalizeAngle(degrees float64) float64 {
+	degrees = math.Mod(degrees+180.0, 360.0)
+	if degrees < 0 {
+		degrees += 360.0
+	}
+	return degrees - 180.0
+}
+
+// Solves the linear dispersion relation, w^2 = gk tanh(kh), for the
+// wavenumber with a few Newton steps from the deep water answer
+func wavenumber(frequency, depth float64) float64 {
+	omega := 2 * math.Pi * frequency
+	deep := omega * omega / gravity
+	if depth <= 0 {
+		return deep
+	}
+
+	k := math.Max(deep, omega/math.Sqrt(gravity*depth))
+	for i := 0; i < 20; i++ {
+		tanh := math.Tanh(k * depth)
+		residual := gravity*k*tanh - omega*omega
+		slope := gravity*tanh + gravity*k*depth*(1-tanh*tanh)
+		step := residual / slope
+		k -= step
+		if math.Abs(step) < 1e-10 {
+			break
+		}
+	}
+	return k
+}
+
+func phaseVelocity(frequency, depth float64) float64 {
+	return 2 * math.Pi * frequency / wavenumber(frequency, depth)
+}
+
+func groupVelocity(frequency, depth float64) float64 {
+	k := wavenumber(frequency, depth)
+	c := 2 * math.Pi * frequency / k
+	if depth <= 0 || k*depth > 20 {
+		return c / 2
+	}
+	return c / 2 * (1 + 2*k*depth/math.Sinh(2*k*depth))
+}
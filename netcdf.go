@@ -0,0 +1,162 @@
+package buoyfinder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// Minimal writer for the NetCDF classic (CDF-1) format. Only fixed size
+// dimensions and double precision variables are supported, which is all the
+// observation exports need.
+
+const (
+	netcdfTagDimension = 0x0A
+	netcdfTagVariable  = 0x0B
+	netcdfTagAttribute = 0x0C
+	netcdfTypeChar     = 2
+	netcdfTypeDouble   = 6
+
+	// The default fill value netcdf uses for doubles
+	NetCDFFillValue = 9.9692099683868690e+36
+)
+
+type NetCDFAttribute struct {
+	Name string
+	// Either a string or a float64
+	Value interface{}
+}
+
+type NetCDFVariable struct {
+	Name       string
+	Dimensions []int
+	Attributes []NetCDFAttribute
+	Data       []float64
+}
+
+type NetCDFDimension struct {
+	Name   string
+	Length int
+}
+
+type NetCDFFile struct {
+	Dimensions []NetCDFDimension
+	Attributes []NetCDFAttribute
+	Variables  []NetCDFVariable
+}
+
+// Adds a dimension and returns the id variables use to reference it
+func (self *NetCDFFile) AddDimension(name string, length int) int {
+	self.Dimensions = append(self.Dimensions, NetCDFDimension{name, length})
+	return len(self.Dimensions) - 1
+}
+
+func (self *NetCDFFile) AddAttribute(name string, value interface{}) {
+	self.Attributes = append(self.Attributes, NetCDFAttribute{name, value})
+}
+
+func (self *NetCDFFile) AddVariable(variable NetCDFVariable) {
+	self.Variables = append(self.Variables, variable)
+}
+
+func (self *NetCDFFile) WriteTo(w io.Writer) (int64, error) {
+	// The header size does not depend on the data offsets, so write it once
+	// to measure it and again with the real offsets filled in
+	header := self.encodeHeader(0)
+	encoded := self.encodeHeader(int32(header.Len()))
+
+	for _, variable := range self.Variables {
+		for _, value := range variable.Data {
+			binary.Write(encoded, binary.BigEndian, math.Float64bits(value))
+		}
+	}
+
+	return encoded.WriteTo(w)
+}
+
+func (self *NetCDFFile) encodeHeader(dataStart int32) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	buf.WriteString("CDF\x01")
+	writeInt32(buf, 0)
+
+	if len(self.Dimensions) == 0 {
+		writeInt32(buf, 0)
+		writeInt32(buf, 0)
+	} else {
+		writeInt32(buf, netcdfTagDimension)
+		writeInt32(buf, int32(len(self.Dimensions)))
+		for _, dim := range self.Dimensions {
+			writeName(buf, dim.Name)
+			writeInt32(buf, int32(dim.Length))
+		}
+	}
+
+	writeAttributes(buf, self.Attributes)
+
+	if len(self.Variables) == 0 {
+		writeInt32(buf, 0)
+		writeInt32(buf, 0)
+		return buf
+	}
+
+	writeInt32(buf, netcdfTagVariable)
+	writeInt32(buf, int32(len(self.Variables)))
+	offset := dataStart
+	for _, variable := range self.Variables {
+		writeName(buf, variable.Name)
+		writeInt32(buf, int32(len(variable.Dimensions)))
+		for _, dimID := range variable.Dimensions {
+			writeInt32(buf, int32(dimID))
+		}
+		writeAttributes(buf, variable.Attributes)
+		writeInt32(buf, netcdfTypeDouble)
+		size := int32(len(variable.Data) * 8)
+		writeInt32(buf, size)
+		writeInt32(buf, offset)
+		offset += size
+	}
+
+	return buf
+}
+
+func writeAttributes(buf *bytes.Buffer, attributes []NetCDFAttribute) {
+	if len(attributes) == 0 {
+		writeInt32(buf, 0)
+		writeInt32(buf, 0)
+		return
+	}
+
+	writeInt32(buf, netcdfTagAttribute)
+	writeInt32(buf, int32(len(attributes)))
+	for _, attribute := range attributes {
+		writeName(buf, attribute.Name)
+		switch value := attribute.Value.(type) {
+		case string:
+			writeInt32(buf, netcdfTypeChar)
+			writeInt32(buf, int32(len(value)))
+			writePadded(buf, []byte(value))
+		case float64:
+			writeInt32(buf, netcdfTypeDouble)
+			writeInt32(buf, 1)
+			binary.Write(buf, binary.BigEndian, math.Float64bits(value))
+		}
+	}
+}
+
+func writeName(buf *bytes.Buffer, name string) {
+	writeInt32(buf, int32(len(name)))
+	writePadded(buf, []byte(name))
+}
+
+// Everything in the header is aligned to 4 bytes
+func writePadded(buf *bytes.Buffer, b []byte) {
+	buf.Write(b)
+	if remainder := len(b) % 4; remainder != 0 {
+		buf.Write(make([]byte, 4-remainder))
+	}
+}
+
+func writeInt32(buf *bytes.Buffer, value int32) {
+	binary.Write(buf, binary.BigEndian, value)
+}
@@ -0,0 +1,264 @@
+package buoyfinder
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/log"
+	"google.golang.org/appengine/memcache"
+)
+
+// One row per station with its newest observation, which makes it possible to
+// check the whole network with a single request
+const latestObservationsURL = "http://www.ndbc.noaa.gov/data/latest_obs/latest_obs.txt"
+
+// Moorings swing around inside their watch circle, which is at most a couple
+// of kilometers, so anything further than this has broken loose
+const adriftDistanceKM = 10.0
+
+const outageKind = "Outage"
+const outagesCacheKey = "outages:current"
+
+const (
+	BuoyStatusOffline = "offline"
+	BuoyStatusAdrift  = "adrift"
+)
+
+// A window where a station was not reporting or was reporting from away from
+// its mooring. Start is the last good observation before the outage.
+type Outage struct {
+	StationID     string
+	Start         time.Time
+	End           time.Time
+	Ongoing       bool
+	Adrift        bool
+	LastLatitude  float64
+	LastLongitude float64
+}
+
+func (self Outage) BuoyStatus() string {
+	if self.Adrift {
+		return BuoyStatusAdrift
+	}
+	return BuoyStatusOffline
+}
+
+type latestObservation struct {
+	Date     time.Time
+	Location surfnerd.Location
+}
+
+func outagesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	days, daysErr := strconv.Atoi(r.URL.Query().Get("days"))
+	if daysErr != nil || days < 0 {
+		days = 7
+	}
+	since := time.Now().AddDate(0, 0, -days)
+
+	outages := []Outage{}
+	if _, ongoingErr := datastore.NewQuery(outageKind).Filter("Ongoing =", true).GetAll(ctx, &outages); ongoingErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, ongoingErr)
+		return
+	}
+	if _, endedErr := datastore.NewQuery(outageKind).Filter("End >=", since).Order("-End").GetAll(ctx, &outages); endedErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, endedErr)
+		return
+	}
+
+	writeDataResponse(w, r, nil, &outages)
+}
+
+// Run by cron, opens an outage for every station that stopped reporting or
+// drifted and closes the outages of stations that are back
+func checkOutagesHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 60*time.Second)
+	client := newFetchClient(ctx)
+
+	stations, stationsError := fetchStations(ctx, client)
+	if stationsError != nil {
+		http.Error(w, stationsError.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	observations, observationsError := fetchLatestObservations(client)
+	if observationsError != nil {
+		http.Error(w, observationsError.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ongoing := []Outage{}
+	ongoingKeys, ongoingErr := datastore.NewQuery(outageKind).Filter("Ongoing =", true).GetAll(ctx, &ongoing)
+	if ongoingErr != nil {
+		http.Error(w, ongoingErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	ongoingByStation := map[string]int{}
+	for index, outage := range ongoing {
+		ongoingByStation[outage.StationID] = index
+	}
+
+	now := time.Now()
+	keys := []*datastore.Key{}
+	changed := []Outage{}
+	current := map[string]Outage{}
+	checked := map[string]bool{}
+	for _, station := range stations.Stations {
+		if station.Active == "n" || station.Location == nil {
+			continue
+		}
+
+		stationID := strings.ToUpper(station.StationID)
+		checked[stationID] = true
+		observation, reported := observations[stationID]
+		offline := !reported || now.Sub(observation.Date) > stationReportingThreshold
+		adrift := reported && distanceBetween(observation.Location, *station.Location) > adriftDistanceKM
+
+		index, hasOutage := ongoingByStation[stationID]
+		switch {
+		case (offline || adrift) && !hasOutage:
+			outage := Outage{
+				StationID:     stationID,
+				Start:         now,
+				Ongoing:       true,
+				Adrift:        adrift,
+				LastLatitude:  observation.Location.Latitude,
+				LastLongitude: observation.Location.Longitude,
+			}
+			if reported {
+				outage.Start = observation.Date
+			}
+			keys = append(keys, datastore.NewIncompleteKey(ctx, outageKind, nil))
+			changed = append(changed, outage)
+			current[stationID] = outage
+		case (offline || adrift) && hasOutage:
+			outage := ongoing[index]
+			if outage.Adrift != adrift {
+				outage.Adrift = adrift
+				outage.LastLatitude = observation.Location.Latitude
+				outage.LastLongitude = observation.Location.Longitude
+				keys = append(keys, ongoingKeys[index])
+				changed = append(changed, outage)
+			}
+			current[stationID] = outage
+		case hasOutage:
+			outage := ongoing[index]
+			outage.Ongoing = false
+			outage.End = observation.Date
+			keys = append(keys, ongoingKeys[index])
+			changed = append(changed, outage)
+		}
+	}
+
+	// Stations that were retired or left the list are no longer checked, so
+	// their outages are closed instead of staying open forever
+	for index, outage := range ongoing {
+		if checked[outage.StationID] {
+			continue
+		}
+		outage.Ongoing = false
+		outage.End = now
+		if observation, reported := observations[outage.StationID]; reported && observation.Date.After(outage.Start) {
+			outage.End = observation.Date
+		}
+		keys = append(keys, ongoingKeys[index])
+		changed = append(changed, outage)
+	}
+
+	if len(changed) > 0 {
+		if putErr := putMultiInBatches(ctx, keys, changed); putErr != nil {
+			http.Error(w, putErr.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	memcache.Gob.Set(ctx, &memcache.Item{
+		Key:    outagesCacheKey,
+		Object: current,
+	})
+
+	log.Infof(ctx, "Outage check updated %d outages, %d stations currently out", len(changed), len(current))
+}
+
+func fetchLatestObservations(client *http.Client) (map[string]latestObservation, error) {
+	observationsResponse, observationsError := client.Get(latestObservationsURL)
+	if observationsError != nil {
+		return nil, observationsError
+	}
+	defer observationsResponse.Body.Close()
+
+	if observationsResponse.StatusCode != http.StatusOK {
+		return nil, errors.New("NDBC responded with " + observationsResponse.Status)
+	}
+
+	observations := map[string]latestObservation{}
+	scanner := bufio.NewScanner(io.LimitReader(observationsResponse.Body, maxNDBCResponseSize))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		latitude, latitudeErr := strconv.ParseFloat(fields[1], 64)
+		longitude, longitudeErr := strconv.ParseFloat(fields[2], 64)
+		date, dateErr := parseRealtimeRowDate(fields[3:8])
+		if latitudeErr != nil || longitudeErr != nil || dateErr != nil {
+			continue
+		}
+
+		observations[strings.ToUpper(fields[0])] = latestObservation{
+			Date:     date,
+			Location: surfnerd.NewLocationForLatLong(latitude, longitude),
+		}
+	}
+
+	return observations, scanner.Err()
+}
+
+// The outages currently open, keyed by the uppercased station id
+func fetchCurrentOutages(ctx context.Context) (map[string]Outage, error) {
+	current := map[string]Outage{}
+	if _, cacheErr := memcache.Gob.Get(ctx, outagesCacheKey, &current); cacheErr == nil {
+		return current, nil
+	}
+
+	ongoing := []Outage{}
+	if _, ongoingErr := datastore.NewQuery(outageKind).Filter("Ongoing =", true).GetAll(ctx, &ongoing); ongoingErr != nil {
+		return nil, ongoingErr
+	}
+	for _, outage := range ongoing {
+		current[outage.StationID] = outage
+	}
+
+	memcache.Gob.Set(ctx, &memcache.Item{
+		Key:    outagesCacheKey,
+		Object: current,
+	})
+
+	return current, nil
+}
+
+// Returns the offline or adrift status of the station, or an empty string if
+// it is reporting normally
+func fetchBuoyStatus(ctx context.Context, stationID string) string {
+	current, currentErr := fetchCurrentOutages(ctx)
+	if currentErr != nil {
+		return ""
+	}
+
+	if outage, ok := current[strings.ToUpper(stationID)]; ok {
+		return outage.BuoyStatus()
+	}
+	return ""
+}
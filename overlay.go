@@ -0,0 +1,175 @@
+package buoyfinder
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+const maxOverlayStations = 4
+
+const defaultOverlayHours = 48
+
+// Overlays the wave height, or the dominant period with ?variable=period, of a
+// few stations on one time axis to follow a swell from one buoy to the next
+func overlayChartHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+
+	stationIDs, stationsErr := parseStationList(r, maxOverlayStations)
+	if stationsErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, stationsErr)
+		return
+	}
+	sort.Strings(stationIDs)
+
+	variable := r.URL.Query().Get("variable")
+	if variable != "period" {
+		variable = "height"
+	}
+
+	hours := parseChartSpan(r, "hours", defaultOverlayHours, maxHistoryHours)
+	palette := parsePalette(r)
+
+	fetchOverlayObservations := func() ([][]surfnerd.BuoyDataItem, error) {
+		since := time.Now().Add(-time.Duration(hours) * time.Hour)
+
+		observations := make([][]surfnerd.BuoyDataItem, len(stationIDs))
+		errs := make([]error, len(stationIDs))
+		wg := sync.WaitGroup{}
+		for index := range stationIDs {
+			wg.Add(1)
+			go func(index int) {
+				defer wg.Done()
+				observations[index], errs[index] = fetchStandardObservationsSince(client, stationIDs[index], since)
+			}(index)
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			if err != nil {
+				return nil, err
+			}
+		}
+		return observations, nil
+	}
+
+	if wantsVegaSpec(r) {
+		writeVegaSpec(w, r, func() (vegaChart, error) {
+			observations, fetchErr := fetchOverlayObservations()
+			if fetchErr != nil {
+				return vegaChart{}, fetchErr
+			}
+			return overlayLines(stationIDs, observations, variable, hours, gradientPalettes[palette]()), nil
+		})
+		return
+	}
+
+	cacheKey := "overlay:" + strings.Join(stationIDs, ",") + ":" + variable + ":" + strconv.Itoa(hours) + ":" + palette
+	writeCachedChart(ctx, w, r, client, cacheKey, vars["format"], func() (Chart, error) {
+		observations, fetchErr := fetchOverlayObservations()
+		if fetchErr != nil {
+			return Chart{}, fetchErr
+		}
+		options, optionsErr := overlayChartOptions(stationIDs, observations, variable, hours, gradientPalettes[palette]())
+		if optionsErr != nil {
+			return Chart{}, optionsErr
+		}
+		lines := overlayLines(stationIDs, observations, variable, hours, gradientPalettes[palette]())
+		return Chart{Options: options, Lines: &lines}, nil
+	})
+}
+
+func overlayLines(stationIDs []string, observations [][]surfnerd.BuoyDataItem, variable string, hours int, gradient Gradient) vegaChart {
+	title, _ := overlayTitles(variable)
+	return vegaChart{
+		Title:    title,
+		Subtitle: "Last " + strconv.Itoa(hours) + " hours",
+		Series:   overlaySeries(stationIDs, observations, variable),
+		Colors:   overlayColors(len(stationIDs), gradient),
+	}
+}
+
+func overlayTitles(variable string) (string, string) {
+	if variable == "period" {
+		return "Dominant Period", "Period (s)"
+	}
+	return "Wave Height", "Wave Height (ft)"
+}
+
+// Spreads the station colors evenly along the gradient
+func overlayColors(count int, gradient Gradient) []string {
+	colors := make([]string, count)
+	for index := range colors {
+		fraction := 0.0
+		if count > 1 {
+			fraction = float64(index) / float64(count-1)
+		}
+		colors[index] = gradient.GetInterpolatedColorForFraction(fraction).Hex()
+	}
+	return colors
+}
+
+func overlaySeries(stationIDs []string, observations [][]surfnerd.BuoyDataItem, variable string) []ChartSeries {
+	_, axisTitle := overlayTitles(variable)
+
+	series := make([]ChartSeries, len(stationIDs))
+	for index, stationID := range stationIDs {
+		series[index] = ChartSeries{Name: "Station " + stationID, XTitle: "Time", YTitle: axisTitle, Points: [][]float64{}}
+		for itemIndex := len(observations[index]) - 1; itemIndex >= 0; itemIndex-- {
+			item := observations[index][itemIndex]
+
+			value := item.WaveSummary.Period
+			if variable == "period" {
+				if !isValidReading(value, missingPeriodMarker) {
+					continue
+				}
+			} else {
+				if !isValidReading(item.WaveSummary.WaveHeight, missingHeightMarker) {
+					continue
+				}
+				item.ChangeUnits(surfnerd.English)
+				value = item.WaveSummary.WaveHeight
+			}
+			series[index].Points = append(series[index].Points, []float64{float64(item.Date.Unix() * 1000), ToFixedPoint(value, 2)})
+		}
+	}
+	return series
+}
+
+func overlayChartOptions(stationIDs []string, observations [][]surfnerd.BuoyDataItem, variable string, hours int, gradient Gradient) (string, error) {
+	title, axisTitle := overlayTitles(variable)
+	colors := overlayColors(len(stationIDs), gradient)
+
+	series := "["
+	count := 0
+	for index, stationSeries := range overlaySeries(stationIDs, observations, variable) {
+		count += len(stationSeries.Points)
+
+		if index > 0 {
+			series += ","
+		}
+		series += "{type: 'line', name: '" + stationSeries.Name + "', color: '" + colors[index] + "', data: " + highchartsPoints(stationSeries.Points) + "}"
+	}
+	series += "]"
+
+	if count == 0 {
+		return "", errors.New("The stations have no wave observations")
+	}
+
+	subtitle := "Last " + strconv.Itoa(hours) + " hours"
+
+	return "{chart: {type: 'line', width: 1200, height: 400}, navigation: {buttonOptions: {enabled: false}}, title: {text: '" + title + "', style: {font: '10px Helvetica, sans-serif'}}, subtitle: {text: '" + subtitle + "', style: {font: '8px Helvetica, sans-serif'}}, credits: {enabled: false}, xAxis: {type: 'datetime', labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1}, yAxis: {labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1, min: 0, title: {text: '" + axisTitle + "'}}, plotOptions: {series: {shadow: false, marker: {enabled: false}}}, series: " + series + "};", nil
+}
@@ -0,0 +1,59 @@
+package buoyfinder
+
+import (
+	"io"
+
+	"github.com/mpiannucci/surfnerd"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// One flattened row of a parquet export. The spectra stay as list columns so
+// each observation is still a single row.
+type ParquetObservation struct {
+	StationID        string    `parquet:"name=station_id, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Date             int64     `parquet:"name=date, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	WaveHeight       float64   `parquet:"name=wave_height, type=DOUBLE"`
+	Period           float64   `parquet:"name=period, type=DOUBLE"`
+	Direction        float64   `parquet:"name=direction, type=DOUBLE"`
+	CompassDirection string    `parquet:"name=compass_direction, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Steepness        string    `parquet:"name=steepness, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AveragePeriod    float64   `parquet:"name=average_period, type=DOUBLE"`
+	Frequencies      []float64 `parquet:"name=frequencies, type=LIST, valuetype=DOUBLE"`
+	Energies         []float64 `parquet:"name=energies, type=LIST, valuetype=DOUBLE"`
+	Angles           []float64 `parquet:"name=angles, type=LIST, valuetype=DOUBLE"`
+}
+
+func NewParquetObservation(stationID string, data surfnerd.BuoyDataItem) ParquetObservation {
+	data.ChangeUnits(surfnerd.Metric)
+
+	return ParquetObservation{
+		StationID:        stationID,
+		Date:             data.Date.UnixNano() / 1e6,
+		WaveHeight:       data.WaveSummary.WaveHeight,
+		Period:           data.WaveSummary.Period,
+		Direction:        data.WaveSummary.Direction,
+		CompassDirection: data.WaveSummary.CompassDirection,
+		Steepness:        data.Steepness,
+		AveragePeriod:    data.AveragePeriod,
+		Frequencies:      data.WaveSpectra.Frequencies,
+		Energies:         data.WaveSpectra.Energies,
+		Angles:           data.WaveSpectra.Angles,
+	}
+}
+
+func writeObservationsParquet(w io.Writer, stationID string, observations []surfnerd.BuoyDataItem) error {
+	parquetWriter, writerErr := writer.NewParquetWriterFromWriter(w, new(ParquetObservation), 1)
+	if writerErr != nil {
+		return writerErr
+	}
+	parquetWriter.CompressionType = parquet.CompressionCodec_SNAPPY
+
+	for _, observation := range observations {
+		if writeErr := parquetWriter.Write(NewParquetObservation(stationID, observation)); writeErr != nil {
+			return writeErr
+		}
+	}
+
+	return parquetWriter.WriteStop()
+}
@@ -0,0 +1,154 @@
+package buoyfinder
+
+import (
+	"math"
+	"sort"
+
+	"github.com/mpiannucci/surfnerd"
+)
+
+// Peaks below this share of the largest one are noise in the tail of the
+// spectrum
+const minPeakEnergyFraction = 0.1
+
+// Two maxima only count as separate peaks when the valley between them dips
+// below this share of the smaller one
+const maxPeakValleyFraction = 0.7
+
+// A sea counts as bimodal when its second peak holds at least this share of
+// the largest one's energy
+const bimodalEnergyFraction = 0.25
+
+// A local maximum of the energy spectrum. WaveHeight is the significant
+// height of the bands between the valleys on either side of the peak.
+type SpectralPeak struct {
+	Frequency        float64
+	Period           float64
+	Direction        float64
+	CompassDirection string
+	Energy           float64
+	WaveHeight       float64
+}
+
+// The significant peaks of the spectrum, largest first. The separations are
+// between the two largest peaks.
+type SpectralPeaks struct {
+	Peaks               []SpectralPeak
+	Bimodal             bool
+	FrequencySeparation float64 `json:",omitempty"`
+	PeriodSeparation    float64 `json:",omitempty"`
+	DirectionSeparation float64 `json:",omitempty"`
+}
+
+func newSpectralPeaks(data surfnerd.BuoyDataItem) *SpectralPeaks {
+	spectra := data.WaveSpectra
+	count := len(spectra.Energies)
+	if len(spectra.Frequencies) < count {
+		count = len(spectra.Frequencies)
+	}
+	if count < 3 {
+		return nil
+	}
+	energies := spectra.Energies[:count]
+
+	maxima := []int{}
+	for index := range energies {
+		left := index == 0 || energies[index] > energies[index-1]
+		right := index == count-1 || energies[index] >= energies[index+1]
+		if left && right && energies[index] > 0 {
+			maxima = append(maxima, index)
+		}
+	}
+
+	// Merge neighboring maxima with only a shallow dip between them into the
+	// larger of the two
+	merged := []int{}
+	for _, index := range maxima {
+		if len(merged) > 0 {
+			previous := merged[len(merged)-1]
+			valley := minEnergyBetween(energies, previous, index)
+			if valley > maxPeakValleyFraction*math.Min(energies[previous], energies[index]) {
+				if energies[index] > energies[previous] {
+					merged[len(merged)-1] = index
+				}
+				continue
+			}
+		}
+		merged = append(merged, index)
+	}
+
+	largest := 0.0
+	for _, index := range merged {
+		largest = math.Max(largest, energies[index])
+	}
+
+	toUnits := 1.0
+	if data.Units == surfnerd.English {
+		toUnits = metersToFeet
+	}
+
+	peaks := &SpectralPeaks{Peaks: []SpectralPeak{}}
+	for position, index := range merged {
+		if energies[index] < minPeakEnergyFraction*largest {
+			continue
+		}
+
+		// The peak's share of the spectrum runs between the valleys to the
+		// neighboring peaks
+		start, end := 0, count-1
+		if position > 0 {
+			start = minEnergyIndexBetween(energies, merged[position-1], index)
+		}
+		if position < len(merged)-1 {
+			end = minEnergyIndexBetween(energies, index, merged[position+1])
+		}
+		m0 := 0.0
+		for band := start; band <= end; band++ {
+			m0 += energies[band] * spectralBandwidth(spectra.Frequencies, band)
+		}
+
+		peak := SpectralPeak{
+			Frequency:  spectra.Frequencies[index],
+			Energy:     energies[index],
+			WaveHeight: ToFixedPoint(4*math.Sqrt(m0)*toUnits, 2),
+			Direction:  missingDirectionMarker,
+		}
+		if peak.Frequency > 0 {
+			peak.Period = ToFixedPoint(1.0/peak.Frequency, 2)
+		}
+		if index < len(spectra.Angles) {
+			peak.Direction = spectra.Angles[index]
+			peak.CompassDirection = CompassDirection(peak.Direction)
+		}
+		peaks.Peaks = append(peaks.Peaks, peak)
+	}
+
+	sort.SliceStable(peaks.Peaks, func(i, j int) bool {
+		return peaks.Peaks[i].Energy > peaks.Peaks[j].Energy
+	})
+
+	if len(peaks.Peaks) >= 2 {
+		first, second := peaks.Peaks[0], peaks.Peaks[1]
+		peaks.Bimodal = second.Energy >= bimodalEnergyFraction*first.Energy
+		peaks.FrequencySeparation = ToFixedPoint(math.Abs(first.Frequency-second.Frequency), 4)
+		peaks.PeriodSeparation = ToFixedPoint(math.Abs(first.Period-second.Period), 2)
+		if isValidReading(first.Direction, missingDirectionMarker) && isValidReading(second.Direction, missingDirectionMarker) {
+			peaks.DirectionSeparation = ToFixedPoint(headingDifference(first.Direction, second.Direction), 1)
+		}
+	}
+	return peaks
+}
+
+func minEnergyIndexBetween(energies []float64, start, end int) int {
+	lowest := start
+	for index := start; index <= end; index++ {
+		if energies[index] < energies[lowest] {
+			lowest = index
+		}
+	}
+	return lowest
+}
+
+func minEnergyBetween(energies []float64, start, end int) float64 {
+	return energies[minEnergyIndexBetween(energies, start, end)]
+}
@@ -0,0 +1,70 @@
+package buoyfinder
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+const maxResponsePrecision = 6
+
+// The number of decimal places from ?precision=, or -1 to leave the numbers
+// as they are
+func parseResponsePrecision(r *http.Request) int {
+	precision, precisionErr := strconv.Atoi(r.URL.Query().Get("precision"))
+	if precisionErr != nil || precision < 0 || precision > maxResponsePrecision {
+		return -1
+	}
+	return precision
+}
+
+// Rounds every decimal number in the encoded JSON to the precision. The
+// numbers are rewritten in place so the field order and formatting are kept,
+// and integers like epochs and durations are left untouched.
+func roundJSONNumbers(encoded []byte, precision int) []byte {
+	rounded := bytes.Buffer{}
+	rounded.Grow(len(encoded))
+
+	inString := false
+	for index := 0; index < len(encoded); index++ {
+		c := encoded[index]
+		switch {
+		case inString:
+			rounded.WriteByte(c)
+			if c == '\\' && index+1 < len(encoded) {
+				index++
+				rounded.WriteByte(encoded[index])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			rounded.WriteByte(c)
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := index
+			for end < len(encoded) && bytes.IndexByte([]byte("+-.eE0123456789"), encoded[end]) >= 0 {
+				end++
+			}
+			rounded.Write(roundJSONNumber(encoded[index:end], precision))
+			index = end - 1
+		default:
+			rounded.WriteByte(c)
+		}
+	}
+
+	return rounded.Bytes()
+}
+
+func roundJSONNumber(number []byte, precision int) []byte {
+	if bytes.IndexAny(number, ".eE") < 0 {
+		return number
+	}
+
+	value, parseErr := strconv.ParseFloat(string(number), 64)
+	// ToFixedPoint goes through an int so huge values would overflow
+	if parseErr != nil || math.Abs(value) > 1e12 {
+		return number
+	}
+	return []byte(strconv.FormatFloat(ToFixedPoint(value, precision), 'f', -1, 64))
+}
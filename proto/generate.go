@@ -0,0 +1,11 @@
+// Package buoyfinderpb holds the Go types and gRPC stubs generated from
+// buoyfinder.proto. The generated files are checked in so the tree builds
+// without protoc. Regenerate them after changing the definition with
+//
+//	go generate ./proto
+//
+// which needs protoc along with protoc-gen-go v1.26 and protoc-gen-go-grpc
+// v1.1.
+package buoyfinderpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative buoyfinder.proto
@@ -0,0 +1,196 @@
+package buoyfinder
+
+import (
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/mpiannucci/surfnerd"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The content type clients send in the Accept header to get responses in the
+// protocol buffers wire format described by proto/buoyfinder.proto
+const protobufContentType = "application/x-protobuf"
+
+func acceptsProtobuf(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), protobufContentType)
+}
+
+// Encodes the container as a buoyfinder.ClosestBuoy message
+func (self ClosestBuoy) ToProtobuf() []byte {
+	b := []byte{}
+	b = appendMessageField(b, 1, encodeLocation(self.RequestedLocation))
+	if !self.RequestedDate.IsZero() {
+		b = appendInt64Field(b, 2, self.RequestedDate.Unix())
+	}
+	b = appendInt64Field(b, 3, int64(self.TimeDiffFound))
+	b = appendStringField(b, 4, self.BuoyStationID)
+	b = appendMessageField(b, 5, encodeLocation(self.BuoyLocation))
+	b = appendMessageField(b, 6, encodeObservation(self.BuoyData))
+	b = appendStringField(b, 7, self.DirectionalSpectraPlot)
+	b = appendStringField(b, 8, self.SpectraDistributionPlot)
+	b = appendStringField(b, 9, self.BuoyStatus)
+	b = appendDoubleField(b, 10, self.DistanceKM)
+	b = appendDoubleField(b, 11, self.DistanceNM)
+	b = appendDoubleField(b, 12, self.Bearing)
+	if self.Interpolated {
+		b = protowire.AppendTag(b, 13, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	return b
+}
+
+// Encodes the station list as a buoyfinder.GetStationsResponse message
+func encodeStations(listing StationsListing) []byte {
+	b := []byte{}
+	for _, station := range listing.Stations {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, encodeStation(station.Buoy, station.Capabilities))
+	}
+	return b
+}
+
+func encodeStation(station surfnerd.Buoy, capabilities []string) []byte {
+	b := []byte{}
+	b = appendStringField(b, 1, station.StationID)
+	if station.Location != nil {
+		b = appendMessageField(b, 2, encodeLocation(*station.Location))
+	}
+	b = appendStringField(b, 3, station.Owner)
+	b = appendStringField(b, 4, station.PGM)
+	b = appendStringField(b, 5, station.Type)
+	if station.Active != "n" {
+		b = protowire.AppendTag(b, 6, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	for _, capability := range capabilities {
+		b = protowire.AppendTag(b, 8, protowire.BytesType)
+		b = protowire.AppendString(b, capability)
+	}
+	b = appendStringField(b, 9, stationClass(station))
+	return b
+}
+
+// Encodes the station and its metadata as a buoyfinder.Station message
+func (self StationInfo) ToProtobuf() []byte {
+	b := encodeStation(*self.Buoy, self.Capabilities)
+	return appendMessageField(b, 7, encodeStationMetadata(self.Metadata))
+}
+
+func encodeStationMetadata(metadata StationMetadata) []byte {
+	b := []byte{}
+	b = appendDoubleField(b, 1, metadata.WaterDepth)
+	b = appendStringField(b, 2, metadata.HullType)
+	b = appendDoubleField(b, 3, metadata.AirTempHeight)
+	b = appendDoubleField(b, 4, metadata.AnemometerHeight)
+	b = appendDoubleField(b, 5, metadata.BarometerElevation)
+	b = appendDoubleField(b, 6, metadata.SeaTempDepth)
+	for _, product := range metadata.Products {
+		b = protowire.AppendTag(b, 7, protowire.BytesType)
+		b = protowire.AppendString(b, product)
+	}
+	return b
+}
+
+func encodeLocation(location surfnerd.Location) []byte {
+	b := []byte{}
+	b = appendDoubleField(b, 1, location.Latitude)
+	b = appendDoubleField(b, 2, location.Longitude)
+	b = appendDoubleField(b, 3, location.Elevation)
+	b = appendStringField(b, 4, location.LocationName)
+	return b
+}
+
+func encodeSwell(swell surfnerd.Swell) []byte {
+	b := []byte{}
+	b = appendDoubleField(b, 1, swell.WaveHeight)
+	b = appendDoubleField(b, 2, swell.Period)
+	b = appendDoubleField(b, 3, swell.Direction)
+	b = appendStringField(b, 4, swell.CompassDirection)
+	b = appendDoubleField(b, 5, swell.MaxEnergy)
+	return b
+}
+
+func encodeWaveSpectra(spectra surfnerd.BuoySpectraItem) []byte {
+	b := []byte{}
+	b = appendPackedDoubleField(b, 1, spectra.Frequencies)
+	b = appendPackedDoubleField(b, 2, spectra.Angles)
+	b = appendPackedDoubleField(b, 3, spectra.Energies)
+	b = appendDoubleField(b, 4, spectra.SeperationFrequency)
+	return b
+}
+
+func encodeObservation(data surfnerd.BuoyDataItem) []byte {
+	data = withCompassDirections(data)
+	b := []byte{}
+	if !data.Date.IsZero() {
+		b = appendInt64Field(b, 1, data.Date.Unix())
+	}
+	b = appendDoubleField(b, 2, data.WindDirection)
+	b = appendDoubleField(b, 3, data.WindSpeed)
+	b = appendDoubleField(b, 4, data.WindGust)
+	b = appendMessageField(b, 5, encodeSwell(data.WaveSummary))
+	for _, swell := range data.SwellComponents {
+		b = protowire.AppendTag(b, 6, protowire.BytesType)
+		b = protowire.AppendBytes(b, encodeSwell(swell))
+	}
+	b = appendStringField(b, 7, data.Steepness)
+	b = appendDoubleField(b, 8, data.AveragePeriod)
+	b = appendMessageField(b, 9, encodeWaveSpectra(data.WaveSpectra))
+	b = appendDoubleField(b, 10, data.Pressure)
+	b = appendDoubleField(b, 11, data.PressureTendency)
+	b = appendDoubleField(b, 12, data.AirTemperature)
+	b = appendDoubleField(b, 13, data.WaterTemperature)
+	b = appendDoubleField(b, 14, data.DewpointTemperature)
+	b = appendDoubleField(b, 15, data.Visibility)
+	b = appendDoubleField(b, 16, data.WaterLevel)
+	b = appendStringField(b, 17, CompassDirection(data.WindDirection))
+	return b
+}
+
+// proto3 leaves default values off the wire, so each of these skips zero values
+
+func appendDoubleField(b []byte, num protowire.Number, value float64) []byte {
+	if value == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(value))
+}
+
+func appendInt64Field(b []byte, num protowire.Number, value int64) []byte {
+	if value == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(value))
+}
+
+func appendStringField(b []byte, num protowire.Number, value string) []byte {
+	if value == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+func appendMessageField(b []byte, num protowire.Number, message []byte) []byte {
+	if len(message) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, message)
+}
+
+func appendPackedDoubleField(b []byte, num protowire.Number, values []float64) []byte {
+	if len(values) == 0 {
+		return b
+	}
+	packed := make([]byte, 0, len(values)*8)
+	for _, value := range values {
+		packed = protowire.AppendFixed64(packed, math.Float64bits(value))
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, packed)
+}
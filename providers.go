@@ -0,0 +1,157 @@
+package buoyfinder
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine/log"
+	"google.golang.org/appengine/memcache"
+)
+
+// Other networks deploy far fewer buoys than NDBC and move them rarely
+const providerStationsCacheExpiration = 24 * time.Hour
+
+// A buoy network other than NDBC. Its station ids carry the provider's prefix
+// so they never collide with NDBC ids, like CDIP028 for CDIP station 028.
+type BuoyProvider interface {
+	Prefix() string
+	// What the provider's stations measure, since they have no NDBC flags
+	Capabilities() []string
+	// The active stations with their ids already prefixed
+	FetchStations(client *http.Client) ([]surfnerd.Buoy, error)
+	// The latest count observations in metric, newest first, for the id
+	// without its prefix. Readings the provider does not have are set to the
+	// missing markers.
+	FetchObservations(client *http.Client, stationID string, count int) ([]surfnerd.BuoyDataItem, error)
+}
+
+// Providers that need an api key load it before their stations are listed,
+// and are left out until it is set up
+type configurableProvider interface {
+	Configure(ctx context.Context) bool
+}
+
+// Providers whose stations move, like drifting buoys, have them listed again
+// sooner than providerStationsCacheExpiration
+type movingProvider interface {
+	StationsCacheExpiration() time.Duration
+}
+
+var buoyProviders = []BuoyProvider{cdipProvider{}, mscProvider{}, sofarProvider{}}
+
+// The provider the station comes from and its id without the prefix. NDBC
+// stations have no provider.
+func stationProvider(stationID string) (BuoyProvider, string) {
+	stationID = strings.ToUpper(stationID)
+	for _, provider := range buoyProviders {
+		if strings.HasPrefix(stationID, provider.Prefix()) && len(stationID) > len(provider.Prefix()) {
+			return provider, strings.TrimPrefix(stationID, provider.Prefix())
+		}
+	}
+	return nil, ""
+}
+
+// The NDBC stations along with every provider's. A provider that cannot be
+// reached is left out rather than failing the lookup.
+func fetchAllStations(ctx context.Context, client *http.Client) (*surfnerd.BuoyStations, error) {
+	stations, stationsError := fetchStations(ctx, client)
+	if stationsError != nil {
+		return nil, stationsError
+	}
+
+	all := &surfnerd.BuoyStations{Stations: append([]surfnerd.Buoy{}, stations.Stations...)}
+	for _, provider := range buoyProviders {
+		if configurable, ok := provider.(configurableProvider); ok && !configurable.Configure(ctx) {
+			continue
+		}
+
+		providerStations, providerErr := fetchProviderStations(ctx, client, provider)
+		if providerErr != nil {
+			log.Warningf(ctx, "Could not fetch the %s stations: %v", provider.Prefix(), providerErr)
+			continue
+		}
+		all.Stations = append(all.Stations, providerStations...)
+	}
+	return all, nil
+}
+
+func fetchProviderStations(ctx context.Context, client *http.Client, provider BuoyProvider) ([]surfnerd.Buoy, error) {
+	cacheKey := "stations:" + provider.Prefix()
+	stations := []surfnerd.Buoy{}
+	if _, cacheErr := memcache.Gob.Get(ctx, cacheKey, &stations); cacheErr == nil {
+		return stations, nil
+	}
+
+	stations, fetchErr := provider.FetchStations(client)
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	expiration := providerStationsCacheExpiration
+	if moving, ok := provider.(movingProvider); ok {
+		expiration = moving.StationsCacheExpiration()
+	}
+	memcache.Gob.Set(ctx, &memcache.Item{
+		Key:        cacheKey,
+		Object:     stations,
+		Expiration: expiration,
+	})
+	return stations, nil
+}
+
+// Fills in the buoy's data from its provider, the same way the NDBC fetches do
+func fetchProviderBuoyData(client *http.Client, provider BuoyProvider, providerStationID string, buoy *surfnerd.Buoy, count int) error {
+	observations, fetchErr := provider.FetchObservations(client, providerStationID, count)
+	if fetchErr != nil {
+		return fetchErr
+	}
+	if len(observations) == 0 {
+		return errors.New("Station " + buoy.StationID + " has no recent observations")
+	}
+	buoy.BuoyData = observations
+	return nil
+}
+
+// When the station last reported, whichever network it is on
+func fetchLatestObservationDate(client *http.Client, stationID string) (time.Time, error) {
+	provider, providerStationID := stationProvider(stationID)
+	if provider == nil {
+		return fetchLatestProductDate(client, stationID, "stdmet")
+	}
+
+	observations, fetchErr := provider.FetchObservations(client, providerStationID, 1)
+	if fetchErr != nil {
+		return time.Time{}, fetchErr
+	}
+	if len(observations) == 0 {
+		return time.Time{}, errors.New("Station " + stationID + " has no recent observations")
+	}
+	return observations[0].Date, nil
+}
+
+// An observation with every reading missing, for providers to fill in what
+// they have
+func newMissingObservation(date time.Time) surfnerd.BuoyDataItem {
+	missingSwell := surfnerd.Swell{WaveHeight: missingHeightMarker, Period: missingPeriodMarker, Direction: missingDirectionMarker, Units: surfnerd.Metric}
+	return surfnerd.BuoyDataItem{
+		Date:                date,
+		WindDirection:       missingDirectionMarker,
+		WindSpeed:           missingSpeedMarker,
+		WindGust:            missingSpeedMarker,
+		WaveSummary:         missingSwell,
+		SwellComponents:     []surfnerd.Swell{},
+		AveragePeriod:       missingPeriodMarker,
+		PressureTendency:    missingPressureMarker,
+		Pressure:            missingPressureMarker,
+		AirTemperature:      missingTemperatureMarker,
+		WaterTemperature:    missingTemperatureMarker,
+		DewpointTemperature: missingTemperatureMarker,
+		Visibility:          missingVisibilityMarker,
+		WaterLevel:          missingHeightMarker,
+		Units:               surfnerd.Metric,
+	}
+}
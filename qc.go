@@ -0,0 +1,271 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+
+	"github.com/mpiannucci/surfnerd"
+)
+
+// NDBC fills missing readings with runs of 9s sized to the field. No real
+// reading comes close to them, so anything at or past the marker is treated as
+// missing. That also keeps working after a unit conversion has scaled the
+// markers up, except for pressure which shrinks going to inches of mercury.
+const (
+	missingSpeedMarker       = 99.0
+	missingHeightMarker      = 99.0
+	missingPeriodMarker      = 99.0
+	missingVisibilityMarker  = 99.0
+	missingDirectionMarker   = 999.0
+	missingTemperatureMarker = 999.0
+	missingPressureMarker    = 9999.0
+	// 9999 hPa in inHg
+	missingEnglishPressureMarker = 295.0
+)
+
+// Whether each reading in a BuoyDataItem is a real value
+type BuoyDataQC struct {
+	WindDirection       bool
+	WindSpeed           bool
+	WindGust            bool
+	WaveHeight          bool
+	Period              bool
+	Direction           bool
+	AveragePeriod       bool
+	Pressure            bool
+	PressureTendency    bool
+	AirTemperature      bool
+	WaterTemperature    bool
+	DewpointTemperature bool
+	Visibility          bool
+	WaterLevel          bool
+}
+
+func NewBuoyDataQC(data surfnerd.BuoyDataItem) BuoyDataQC {
+	pressureMarker := missingPressureMarker
+	if data.Units == surfnerd.English {
+		pressureMarker = missingEnglishPressureMarker
+	}
+
+	return BuoyDataQC{
+		WindDirection:       isValidReading(data.WindDirection, missingDirectionMarker),
+		WindSpeed:           isValidReading(data.WindSpeed, missingSpeedMarker),
+		WindGust:            isValidReading(data.WindGust, missingSpeedMarker),
+		WaveHeight:          isValidReading(data.WaveSummary.WaveHeight, missingHeightMarker),
+		Period:              isValidReading(data.WaveSummary.Period, missingPeriodMarker),
+		Direction:           isValidReading(data.WaveSummary.Direction, missingDirectionMarker),
+		AveragePeriod:       isValidReading(data.AveragePeriod, missingPeriodMarker),
+		Pressure:            isValidReading(data.Pressure, pressureMarker),
+		PressureTendency:    isValidReading(math.Abs(data.PressureTendency), missingSpeedMarker),
+		AirTemperature:      isValidReading(data.AirTemperature, missingTemperatureMarker),
+		WaterTemperature:    isValidReading(data.WaterTemperature, missingTemperatureMarker),
+		DewpointTemperature: isValidReading(data.DewpointTemperature, missingTemperatureMarker),
+		Visibility:          isValidReading(data.Visibility, missingVisibilityMarker),
+		WaterLevel:          isValidReading(math.Abs(data.WaterLevel), missingHeightMarker),
+	}
+}
+
+// The spectra files only carry the waves, so the parser leaves every other
+// reading at zero, which would pass as a real calm and freezing observation
+func withMissingMeteorology(data surfnerd.BuoyDataItem) surfnerd.BuoyDataItem {
+	missing := newMissingObservation(data.Date)
+	data.WindDirection = missing.WindDirection
+	data.WindSpeed = missing.WindSpeed
+	data.WindGust = missing.WindGust
+	data.PressureTendency = missing.PressureTendency
+	data.Pressure = missing.Pressure
+	data.AirTemperature = missing.AirTemperature
+	data.WaterTemperature = missing.WaterTemperature
+	data.DewpointTemperature = missing.DewpointTemperature
+	data.Visibility = missing.Visibility
+	data.WaterLevel = missing.WaterLevel
+	return data
+}
+
+// The latest observation file only has a line for each reading the station
+// reported, so the rest are left at zero by the parser. Only zeros are
+// marked missing, so a label spelled differently can't hide a real reading.
+func withUnreportedLatestReadings(data surfnerd.BuoyDataItem, raw string) surfnerd.BuoyDataItem {
+	missing := newMissingObservation(data.Date)
+	unreported := func(value float64, label string) bool {
+		return value == 0 && !strings.Contains(raw, label)
+	}
+
+	if unreported(data.WindSpeed, "Wind:") {
+		data.WindSpeed, data.WindDirection = missing.WindSpeed, missing.WindDirection
+	}
+	if unreported(data.WindGust, "Gust:") {
+		data.WindGust = missing.WindGust
+	}
+	if unreported(data.WaveSummary.WaveHeight, "Seas:") {
+		data.WaveSummary.WaveHeight = missing.WaveSummary.WaveHeight
+	}
+	if unreported(data.WaveSummary.Period, "Peak Period:") {
+		data.WaveSummary.Period = missing.WaveSummary.Period
+	}
+	if unreported(data.Pressure, "Pres:") {
+		data.Pressure, data.PressureTendency = missing.Pressure, missing.PressureTendency
+	}
+	if unreported(data.AirTemperature, "Air Temp:") {
+		data.AirTemperature = missing.AirTemperature
+	}
+	if unreported(data.WaterTemperature, "Water Temp:") {
+		data.WaterTemperature = missing.WaterTemperature
+	}
+	if unreported(data.DewpointTemperature, "Dew Point:") {
+		data.DewpointTemperature = missing.DewpointTemperature
+	}
+	if unreported(data.Visibility, "Vis") {
+		data.Visibility = missing.Visibility
+	}
+	if unreported(data.WaterLevel, "Tide:") {
+		data.WaterLevel = missing.WaterLevel
+	}
+	return data
+}
+
+func isValidReading(value, missingMarker float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0) && value < missingMarker
+}
+
+// A single reading of an observation, named after its ndbc column
+type observationReading struct {
+	Field string
+	Value float64
+}
+
+var observationFields = []string{"wvht", "dpd", "apd", "mwd", "wspd", "gst", "wdir", "pres", "atmp", "wtmp", "dewp"}
+
+// The readings of the observation that are not missing, in the order of
+// observationFields
+func validReadings(data surfnerd.BuoyDataItem) []observationReading {
+	pressureMarker := missingPressureMarker
+	if data.Units == surfnerd.English {
+		pressureMarker = missingEnglishPressureMarker
+	}
+
+	readings := []struct {
+		Value  float64
+		Marker float64
+	}{
+		{data.WaveSummary.WaveHeight, missingHeightMarker},
+		{data.WaveSummary.Period, missingPeriodMarker},
+		{data.AveragePeriod, missingPeriodMarker},
+		{data.WaveSummary.Direction, missingDirectionMarker},
+		{data.WindSpeed, missingSpeedMarker},
+		{data.WindGust, missingSpeedMarker},
+		{data.WindDirection, missingDirectionMarker},
+		{data.Pressure, pressureMarker},
+		{data.AirTemperature, missingTemperatureMarker},
+		{data.WaterTemperature, missingTemperatureMarker},
+		{data.DewpointTemperature, missingTemperatureMarker},
+	}
+
+	valid := []observationReading{}
+	for index, reading := range readings {
+		if isValidReading(reading.Value, reading.Marker) {
+			valid = append(valid, observationReading{Field: observationFields[index], Value: reading.Value})
+		}
+	}
+	return valid
+}
+
+// Encodes the data with the missing readings as nulls, compass names next to
+// the directions, and a QC object listing which readings are valid
+func qualityControlledJSON(data surfnerd.BuoyDataItem) (json.RawMessage, error) {
+	data = withCompassDirections(data)
+	rawData, rawDataErr := json.Marshal(&data)
+	if rawDataErr != nil {
+		return nil, rawDataErr
+	}
+
+	fields := map[string]interface{}{}
+	if fieldsErr := json.Unmarshal(rawData, &fields); fieldsErr != nil {
+		return nil, fieldsErr
+	}
+
+	qc := NewBuoyDataQC(data)
+	nullIfInvalid(fields, "WindDirection", qc.WindDirection)
+	nullIfInvalid(fields, "WindSpeed", qc.WindSpeed)
+	nullIfInvalid(fields, "WindGust", qc.WindGust)
+	nullIfInvalid(fields, "AveragePeriod", qc.AveragePeriod)
+	nullIfInvalid(fields, "Pressure", qc.Pressure)
+	nullIfInvalid(fields, "PressureTendency", qc.PressureTendency)
+	nullIfInvalid(fields, "AirTemperature", qc.AirTemperature)
+	nullIfInvalid(fields, "WaterTemperature", qc.WaterTemperature)
+	nullIfInvalid(fields, "DewpointTemperature", qc.DewpointTemperature)
+	nullIfInvalid(fields, "Visibility", qc.Visibility)
+	nullIfInvalid(fields, "WaterLevel", qc.WaterLevel)
+	if waveSummary, ok := fields["WaveSummary"].(map[string]interface{}); ok {
+		nullIfInvalid(waveSummary, "WaveHeight", qc.WaveHeight)
+		nullIfInvalid(waveSummary, "Period", qc.Period)
+		nullIfInvalid(waveSummary, "Direction", qc.Direction)
+	}
+	fields["WindCompassDirection"] = CompassDirection(data.WindDirection)
+	fields["QC"] = qc
+
+	return json.Marshal(fields)
+}
+
+func qualityControlledJSONList(items []surfnerd.BuoyDataItem) ([]json.RawMessage, error) {
+	encoded := make([]json.RawMessage, len(items))
+	for index, item := range items {
+		itemData, itemErr := qualityControlledJSON(item)
+		if itemErr != nil {
+			return nil, itemErr
+		}
+		encoded[index] = itemData
+	}
+	return encoded, nil
+}
+
+func nullIfInvalid(fields map[string]interface{}, key string, valid bool) {
+	if _, ok := fields[key]; ok && !valid {
+		fields[key] = nil
+	}
+}
+
+// The spectral height has to be off by both this fraction and this many
+// meters before it counts as a discrepancy, so small seas do not trip it
+const (
+	spectralDiscrepancyFraction = 0.15
+	spectralDiscrepancyMeters   = 0.2
+)
+
+// The significant height integrated from the energy spectrum, 4 sqrt(m0),
+// next to the reported one. They should agree closely, so a big difference
+// points at a sensor or parsing problem.
+type SpectralWaveHeightCheck struct {
+	WaveHeight float64
+	// The spectral height minus the reported one, as a fraction of the
+	// reported one
+	Difference  float64
+	Discrepancy bool `json:",omitempty"`
+}
+
+// Spectra stay in m^2/Hz whatever units the rest of the data is in
+func newSpectralWaveHeightCheck(data surfnerd.BuoyDataItem) *SpectralWaveHeightCheck {
+	reported := data.WaveSummary.WaveHeight
+	if len(data.WaveSpectra.Energies) == 0 || !isValidReading(reported, missingHeightMarker) || reported <= 0 {
+		return nil
+	}
+
+	m0 := spectralMoment(data.WaveSpectra, 0)
+	if m0 <= 0 {
+		return nil
+	}
+	spectral := 4 * math.Sqrt(m0)
+	reportedMeters := reported
+	if data.Units == surfnerd.English {
+		spectral *= metersToFeet
+		reportedMeters /= metersToFeet
+	}
+
+	difference := (spectral - reported) / reported
+	return &SpectralWaveHeightCheck{
+		WaveHeight:  ToFixedPoint(spectral, 2),
+		Difference:  ToFixedPoint(difference, 3),
+		Discrepancy: math.Abs(difference) > spectralDiscrepancyFraction && math.Abs(difference*reportedMeters) > spectralDiscrepancyMeters,
+	}
+}
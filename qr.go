@@ -0,0 +1,47 @@
+package buoyfinder
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const defaultQRSize = 256
+const maxQRSize = 1024
+
+// Renders a QR code that opens the station's buoy page, meant for printing on
+// signs so it uses the highest error correction to survive weathering
+func stationQRHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+
+	size, sizeErr := strconv.Atoi(r.URL.Query().Get("size"))
+	if sizeErr != nil || size <= 0 {
+		size = defaultQRSize
+	} else if size > maxQRSize {
+		size = maxQRSize
+	}
+
+	pageURL := requestBaseURL(r) + "/buoy/" + stationID
+	png, qrErr := qrcode.Encode(pageURL, qrcode.High, size)
+	if qrErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, qrErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Write(png)
+}
+
+// The scheme and host the request came in on, for building absolute links
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,96 @@
+package buoyfinder
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/memcache"
+)
+
+// The realtime files are regenerated as new observations come in, which is
+// at most every half hour or so
+const rawProductCacheExpiration = 10 * time.Minute
+
+// Memcache refuses items bigger than 1MB, so the biggest spectra files are
+// always proxied straight through
+const rawProductCacheLimit = 1000000
+
+// The raw text products that can be proxied, looked up by either the product
+// name or the file extension
+var rawProducts = map[string]string{
+	"stdmet":             "txt",
+	"txt":                "txt",
+	"spectral_summary":   "spec",
+	"spec":               "spec",
+	"spectra":            "data_spec",
+	"data_spec":          "data_spec",
+	"spectral_direction": "swdir",
+	"swdir":              "swdir",
+}
+
+func rawProductHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+	extension, ok := rawProducts[strings.ToLower(strings.TrimPrefix(vars["product"], "."))]
+	if !ok {
+		writeErrorResponse(w, r, http.StatusNotFound, errors.New("Unknown product "+vars["product"]))
+		return
+	}
+
+	contents, status, fetchErr := fetchRawProduct(ctx, client, stationID, extension)
+	if fetchErr != nil {
+		writeErrorResponse(w, r, status, fetchErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "public, max-age=600")
+	w.Write(contents)
+}
+
+// Returns the contents of the realtime file, along with the status code to
+// respond with when it could not be fetched
+func fetchRawProduct(ctx context.Context, client *http.Client, stationID, extension string) ([]byte, int, error) {
+	cacheKey := "raw:" + stationID + "." + extension
+	if item, cacheErr := memcache.Get(ctx, cacheKey); cacheErr == nil {
+		return item.Value, http.StatusOK, nil
+	}
+
+	productResponse, productError := client.Get(realtimeDataURL + stationID + "." + extension)
+	if productError != nil {
+		return nil, http.StatusBadGateway, productError
+	}
+	defer productResponse.Body.Close()
+
+	if productResponse.StatusCode == http.StatusNotFound {
+		return nil, http.StatusNotFound, errors.New("Station " + stationID + " does not publish " + extension + " data")
+	}
+	if productResponse.StatusCode != http.StatusOK {
+		return nil, http.StatusBadGateway, errors.New("NDBC responded with " + productResponse.Status)
+	}
+
+	contents, readErr := readNDBCBody(productResponse)
+	if readErr != nil {
+		return nil, http.StatusBadGateway, readErr
+	}
+
+	if len(contents) < rawProductCacheLimit {
+		memcache.Set(ctx, &memcache.Item{
+			Key:        cacheKey,
+			Value:      contents,
+			Expiration: rawProductCacheExpiration,
+		})
+	}
+
+	return contents, http.StatusOK, nil
+}
@@ -0,0 +1,170 @@
+package buoyfinder
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/buoyfinder/geo"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+// Only the nearest wave buoys are considered, and none so far away that
+// they are measuring a different coastline's swell
+const (
+	maxRecommendationCandidates = 10
+	maxRecommendationDistanceKM = 500.0
+	defaultRecommendationCount  = 3
+)
+
+// Distance scores halve this far from the spot
+const recommendationDistanceScaleKM = 50.0
+
+// Deep enough that a 12 second groundswell has not started to feel the
+// bottom, so the buoy sees the swell before the coast has bent it
+const deepWaterDepth = 112.0
+
+// How well a nearby buoy stands in for the spot. Each score is from 0 to 1
+// and Score is their product.
+type BuoyRecommendation struct {
+	StationID     string
+	LocationName  string `json:",omitempty"`
+	DistanceKM    float64
+	Bearing       float64
+	InSwellWindow bool
+	WaterDepth    float64 `json:",omitempty"`
+	DistanceScore float64
+	ExposureScore float64
+	DepthScore    float64
+	Score         float64
+}
+
+func recommendedSpotBuoysHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	spot, spotErr := fetchSpot(ctx, mux.Vars(r)["spot"])
+	if spotErr == errSpotNotFound {
+		writeErrorResponse(w, r, http.StatusNotFound, spotErr)
+		return
+	} else if spotErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, spotErr)
+		return
+	}
+
+	count, countErr := strconv.Atoi(r.URL.Query().Get("count"))
+	if countErr != nil || count <= 0 {
+		count = defaultRecommendationCount
+	} else if count > maxRecommendationCandidates {
+		count = maxRecommendationCandidates
+	}
+
+	recommendations, recommendErr := recommendSpotBuoys(ctx, client, spot, parseClosestBuoyOptions(r).DistanceAlgorithm)
+	if recommendErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, recommendErr)
+		return
+	} else if len(recommendations) == 0 {
+		writeErrorResponse(w, r, http.StatusNotFound, errors.New("There are no wave buoys near the spot"))
+		return
+	}
+
+	if len(recommendations) > count {
+		recommendations = recommendations[:count]
+	}
+	writeDataResponse(w, r, client, recommendations)
+}
+
+// Scores the nearby wave buoys, best first. The nearest buoy is often tucked
+// behind a headland or sitting in a bay, where it sees little of the swell
+// the spot gets.
+func recommendSpotBuoys(ctx context.Context, client *http.Client, spot *Spot, algorithm geo.Algorithm) ([]BuoyRecommendation, error) {
+	stations, stationsError := fetchAllStations(ctx, client)
+	if stationsError != nil {
+		return nil, stationsError
+	}
+
+	candidates := sortedActiveStations(stations, spot.Location, algorithm, CapabilityWaves)
+	if len(candidates) > maxRecommendationCandidates {
+		candidates = candidates[:maxRecommendationCandidates]
+	}
+
+	recommendations := []BuoyRecommendation{}
+	for _, buoy := range candidates {
+		distance := distanceWith(algorithm, spot.Location, *buoy.Location)
+		if distance > maxRecommendationDistanceKM {
+			break
+		}
+
+		bearing := bearingBetween(spot.Location, *buoy.Location)
+		recommendations = append(recommendations, BuoyRecommendation{
+			StationID:     buoy.StationID,
+			LocationName:  buoy.LocationName,
+			DistanceKM:    ToFixedPoint(distance, 2),
+			Bearing:       ToFixedPoint(bearing, 1),
+			InSwellWindow: spot.InSwellWindow(bearing),
+			DistanceScore: ToFixedPoint(1.0/(1.0+distance/recommendationDistanceScaleKM), 3),
+			ExposureScore: ToFixedPoint(spotExposureScore(spot, bearing), 3),
+		})
+	}
+
+	// Depths are only on the station pages, and a buoy without one is
+	// scored as if it were halfway to deep water
+	var wg sync.WaitGroup
+	for index := range recommendations {
+		wg.Add(1)
+		go func(recommendation *BuoyRecommendation) {
+			defer wg.Done()
+			recommendation.DepthScore = 0.5
+			buoy := stations.FindBuoyByID(recommendation.StationID)
+			if buoy == nil {
+				return
+			}
+			metadata, metadataErr := fetchStationMetadata(ctx, client, buoy)
+			if metadataErr != nil || metadata.WaterDepth <= 0 {
+				return
+			}
+			recommendation.WaterDepth = metadata.WaterDepth
+			recommendation.DepthScore = ToFixedPoint(math.Max(math.Min(metadata.WaterDepth/deepWaterDepth, 1.0), 0.2), 3)
+		}(&recommendations[index])
+	}
+	wg.Wait()
+
+	for index := range recommendations {
+		recommendation := &recommendations[index]
+		recommendation.Score = ToFixedPoint(recommendation.DistanceScore*recommendation.ExposureScore*recommendation.DepthScore, 4)
+	}
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Score > recommendations[j].Score
+	})
+	return recommendations, nil
+}
+
+// Buoys out in the swell window see the same swell the spot does. Outside it
+// the score falls off with how far the bearing is from the window, and any
+// buoy behind the beach is barely exposed at all.
+func spotExposureScore(spot *Spot, bearing float64) float64 {
+	if spot.InSwellWindow(bearing) {
+		return 1.0
+	}
+
+	outside := math.Min(headingDifference(bearing, spot.SwellWindowStart), headingDifference(bearing, spot.SwellWindowEnd))
+	window := math.Max(0.1, math.Cos(outside*math.Pi/180.0))
+	alignment := (1.0 + math.Cos(headingDifference(bearing, spot.BeachFacing)*math.Pi/180.0)) / 2.0
+	return math.Max(window*alignment, 0.05)
+}
+
+// The smaller angle between the two headings, from 0 to 180 degrees
+func headingDifference(a, b float64) float64 {
+	difference := math.Mod(math.Abs(a-b), 360.0)
+	if difference > 180.0 {
+		return 360.0 - difference
+	}
+	return difference
+}
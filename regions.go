@@ -0,0 +1,267 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/user"
+)
+
+const regionKind = "Region"
+
+const (
+	maxRegionStations = 100
+	maxRegionVertices = 100
+)
+
+var errRegionNotFound = errors.New("Could not find the requested region")
+var errRegionExists = errors.New("A region with that name already exists")
+
+// A named area for regional summaries and maps, either a polygon that takes in
+// every active station inside it or a fixed list of stations
+type Region struct {
+	ID           string `datastore:"-"`
+	Name         string
+	Polygon      []surfnerd.Location `json:",omitempty"`
+	BuoyStations []string            `json:",omitempty"`
+}
+
+func (self Region) validate() error {
+	switch {
+	case strings.TrimSpace(self.Name) == "":
+		return errors.New("The region needs a name")
+	case len(self.Polygon) > 0 && len(self.BuoyStations) > 0:
+		return errors.New("A region is either a polygon or a list of stations, not both")
+	case len(self.Polygon) == 0 && len(self.BuoyStations) == 0:
+		return errors.New("A region needs a polygon or a list of stations")
+	case len(self.Polygon) > 0 && (len(self.Polygon) < 3 || len(self.Polygon) > maxRegionVertices):
+		return errors.New("A region polygon needs between 3 and 100 points")
+	case len(self.BuoyStations) > maxRegionStations:
+		return errors.New("A region can list at most 100 stations")
+	}
+	for _, vertex := range self.Polygon {
+		if vertex.Latitude < -90 || vertex.Latitude > 90 || vertex.Longitude < -180 || vertex.Longitude > 180 {
+			return errors.New("Invalid region polygon point")
+		}
+	}
+	return nil
+}
+
+// Whether the location is inside the polygon, by counting how many edges a
+// ray from it crosses. Polygons are not expected to cross the antimeridian.
+func (self Region) Contains(location surfnerd.Location) bool {
+	inside := false
+	for index, previous := 0, len(self.Polygon)-1; index < len(self.Polygon); previous, index = index, index+1 {
+		a, b := self.Polygon[index], self.Polygon[previous]
+		if (a.Latitude > location.Latitude) != (b.Latitude > location.Latitude) &&
+			location.Longitude < (b.Longitude-a.Longitude)*(location.Latitude-a.Latitude)/(b.Latitude-a.Latitude)+a.Longitude {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// The ids of the region's stations. Polygon regions take in the active
+// stations inside them at the time, so they pick up new moorings.
+func (self Region) StationIDs(stations *surfnerd.BuoyStations) []string {
+	if len(self.Polygon) == 0 {
+		return self.BuoyStations
+	}
+
+	stationIDs := []string{}
+	for _, station := range stations.Stations {
+		if station.Active == "n" || station.Location == nil {
+			continue
+		}
+		if self.Contains(*station.Location) {
+			stationIDs = append(stationIDs, strings.ToUpper(station.StationID))
+		}
+	}
+	return stationIDs
+}
+
+func listRegionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	regions := []Region{}
+	keys, regionsErr := datastore.NewQuery(regionKind).Order("Name").GetAll(ctx, &regions)
+	if regionsErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, regionsErr)
+		return
+	}
+	for index, key := range keys {
+		regions[index].ID = key.StringID()
+	}
+
+	writeDataResponse(w, r, nil, &regions)
+}
+
+func getRegionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	region, regionErr := fetchRegion(ctx, mux.Vars(r)["region"])
+	if regionErr == errRegionNotFound {
+		writeErrorResponse(w, r, http.StatusNotFound, regionErr)
+		return
+	} else if regionErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, regionErr)
+		return
+	}
+
+	writeDataResponse(w, r, nil, region)
+}
+
+// The stations currently in the region, which is what the summaries and maps
+// of a region are built from
+func regionStationsHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	region, regionErr := fetchRegion(ctx, mux.Vars(r)["region"])
+	if regionErr == errRegionNotFound {
+		writeErrorResponse(w, r, http.StatusNotFound, regionErr)
+		return
+	} else if regionErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, regionErr)
+		return
+	}
+
+	stations, stationsErr := fetchStations(ctx, client)
+	if stationsErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, stationsErr)
+		return
+	}
+
+	stationIDs := region.StationIDs(stations)
+	writeDataResponse(w, r, client, &stationIDs)
+}
+
+func createRegionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+	if !user.IsAdmin(ctx) {
+		writeErrorResponse(w, r, http.StatusForbidden, errors.New("Only admins can add regions"))
+		return
+	}
+
+	region, decodeErr := decodeRegion(r)
+	if decodeErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, decodeErr)
+		return
+	}
+	region.ID = newSpotID(region.Name)
+	if region.ID == "" {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("The region name needs at least one letter or number"))
+		return
+	}
+
+	key := datastore.NewKey(ctx, regionKind, region.ID, 0, nil)
+	putErr := datastore.RunInTransaction(ctx, func(tx context.Context) error {
+		if getErr := datastore.Get(tx, key, &Region{}); getErr != datastore.ErrNoSuchEntity {
+			if getErr == nil {
+				return errRegionExists
+			}
+			return getErr
+		}
+		_, putErr := datastore.Put(tx, key, region)
+		return putErr
+	}, nil)
+	if putErr == errRegionExists {
+		writeErrorResponse(w, r, http.StatusConflict, putErr)
+		return
+	} else if putErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, putErr)
+		return
+	}
+
+	writeEnvelope(w, r, http.StatusCreated, newResponseEnvelope(r, nil, region))
+}
+
+func updateRegionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+	if !user.IsAdmin(ctx) {
+		writeErrorResponse(w, r, http.StatusForbidden, errors.New("Only admins can edit regions"))
+		return
+	}
+
+	regionID := mux.Vars(r)["region"]
+	if _, regionErr := fetchRegion(ctx, regionID); regionErr == errRegionNotFound {
+		writeErrorResponse(w, r, http.StatusNotFound, regionErr)
+		return
+	} else if regionErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, regionErr)
+		return
+	}
+
+	region, decodeErr := decodeRegion(r)
+	if decodeErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, decodeErr)
+		return
+	}
+
+	// Like spots, the id survives a rename
+	region.ID = regionID
+	if _, putErr := datastore.Put(ctx, datastore.NewKey(ctx, regionKind, regionID, 0, nil), region); putErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, putErr)
+		return
+	}
+
+	writeDataResponse(w, r, nil, region)
+}
+
+func deleteRegionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+	if !user.IsAdmin(ctx) {
+		writeErrorResponse(w, r, http.StatusForbidden, errors.New("Only admins can delete regions"))
+		return
+	}
+
+	key := datastore.NewKey(ctx, regionKind, mux.Vars(r)["region"], 0, nil)
+	if deleteErr := datastore.Delete(ctx, key); deleteErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, deleteErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func fetchRegion(ctx context.Context, regionID string) (*Region, error) {
+	region := &Region{}
+	getErr := datastore.Get(ctx, datastore.NewKey(ctx, regionKind, regionID, 0, nil), region)
+	if getErr == datastore.ErrNoSuchEntity {
+		return nil, errRegionNotFound
+	} else if getErr != nil {
+		return nil, getErr
+	}
+
+	region.ID = regionID
+	return region, nil
+}
+
+func decodeRegion(r *http.Request) (*Region, error) {
+	region := &Region{}
+	if decodeErr := json.NewDecoder(r.Body).Decode(region); decodeErr != nil {
+		return nil, errors.New("Invalid request body: " + decodeErr.Error())
+	}
+
+	stationIDs := []string{}
+	for _, stationID := range region.BuoyStations {
+		if stationID = strings.ToUpper(strings.TrimSpace(stationID)); stationID != "" && !containsString(stationIDs, stationID) {
+			stationIDs = append(stationIDs, stationID)
+		}
+	}
+	region.BuoyStations = stationIDs
+
+	if validateErr := region.validate(); validateErr != nil {
+		return nil, validateErr
+	}
+	return region, nil
+}
@@ -0,0 +1,235 @@
+package buoyfinder
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpiannucci/buoyfinder/geo"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+)
+
+var errPlaceNotFound = errors.New("No spot or station goes by that name")
+
+var compassWords = map[byte]string{'N': "north", 'E': "east", 'S': "south", 'W': "west"}
+
+// A short plain text report of the conditions at a place, for voice
+// assistants and chat. The place can be a spot, a station id, or part of a
+// station's name.
+func fetchPlaceReport(ctx context.Context, client *http.Client, place string) (string, error) {
+	place = strings.TrimSpace(place)
+	if place == "" {
+		return "", errPlaceNotFound
+	}
+
+	if spot, spotErr := fetchSpot(ctx, newSpotID(place)); spotErr == nil {
+		conditions, conditionsErr := fetchSpotConditions(ctx, client, spot, 0)
+		if conditionsErr != nil {
+			return "", conditionsErr
+		}
+		return spotReport(conditions), nil
+	} else if spotErr != errSpotNotFound {
+		return "", spotErr
+	}
+
+	stations, stationsError := fetchStations(ctx, client)
+	if stationsError != nil {
+		return "", stationsError
+	}
+
+	var named *surfnerd.Buoy
+	for index := range stations.Stations {
+		station := &stations.Stations[index]
+		if station.Location == nil || station.Active == "n" {
+			continue
+		}
+		if strings.EqualFold(station.StationID, place) || strings.Contains(strings.ToLower(station.LocationName), strings.ToLower(place)) {
+			named = station
+			break
+		}
+	}
+	if named == nil {
+		return "", errPlaceNotFound
+	}
+
+	// The named station may not measure waves or may be offline, so report
+	// from the closest buoy that is reporting
+	closest, closestErr := fetchClosestBuoy(ctx, client, *named.Location, ClosestBuoyOptions{
+		MaxAge:            stationReportingThreshold,
+		DistanceAlgorithm: geo.DefaultAlgorithm,
+	})
+	if closestErr != nil {
+		return "", closestErr
+	}
+
+	buoys, errs := fetchLatestBuoys(ctx, client, []string{closest.StationID})
+	if buoys[0] == nil {
+		return "", errs[0]
+	}
+	buoys[0].BuoyLocation = *closest.Location
+	buoys[0].SetWaterBody()
+	return buoyReport(named.LocationName, buoys[0]), nil
+}
+
+// Answers a text message asking about conditions: a station id, a spot or
+// station name, or closest followed by a latitude and longitude
+func replyToTextQuery(ctx context.Context, client *http.Client, query string) string {
+	query = strings.TrimSpace(query)
+	fields := strings.Fields(strings.Replace(query, ",", " ", -1))
+	if len(fields) == 0 || strings.EqualFold(fields[0], "help") {
+		return textQueryHelp
+	}
+
+	if strings.EqualFold(fields[0], "closest") {
+		if len(fields) != 3 {
+			return textQueryHelp
+		}
+		latitude, latitudeErr := strconv.ParseFloat(fields[1], 64)
+		longitude, longitudeErr := strconv.ParseFloat(fields[2], 64)
+		if latitudeErr != nil || longitudeErr != nil {
+			return textQueryHelp
+		}
+
+		closest, closestErr := fetchClosestBuoy(ctx, client, surfnerd.NewLocationForLatLong(latitude, longitude), ClosestBuoyOptions{
+			MaxAge:            stationReportingThreshold,
+			DistanceAlgorithm: geo.DefaultAlgorithm,
+		})
+		if closestErr != nil {
+			return "No buoy found: " + closestErr.Error()
+		}
+		return fetchCompactReport(ctx, client, closest.StationID)
+	}
+
+	if _, stationErr := fetchBuoyWithID(ctx, client, query); stationErr == nil {
+		return fetchCompactReport(ctx, client, query)
+	}
+
+	report, reportErr := fetchPlaceReport(ctx, client, query)
+	if reportErr != nil {
+		return "Sorry, " + reportErr.Error()
+	}
+	return report
+}
+
+const textQueryHelp = "Send a station id like 44097, a spot name, or closest 41.3,-71.5"
+
+func fetchCompactReport(ctx context.Context, client *http.Client, stationID string) string {
+	buoys, errs := fetchLatestBuoys(ctx, client, []string{strings.ToUpper(stationID)})
+	if buoys[0] == nil {
+		return "Station " + strings.ToUpper(stationID) + " is not answering: " + errs[0].Error()
+	}
+	return compactReport(buoys[0])
+}
+
+// The conditions in as few characters as possible for sms and satellite
+// messengers, like 44097 1450Z: 4.3ft 9s SSE, wind W 12kt G15, water 64F
+func compactReport(buoy *ClosestBuoy) string {
+	data := buoy.BuoyData
+	report := buoy.BuoyStationID + " " + data.Date.UTC().Format("1504") + "Z:"
+
+	if isValidReading(data.WaveSummary.WaveHeight, missingHeightMarker) {
+		report += fmt.Sprintf(" %.1fft", data.WaveSummary.WaveHeight*metersToFeet)
+		if isValidReading(data.WaveSummary.Period, missingPeriodMarker) {
+			report += fmt.Sprintf(" %.0fs", data.WaveSummary.Period)
+		}
+		if direction := CompassDirection(data.WaveSummary.Direction); direction != "" {
+			report += " " + direction
+		}
+	} else {
+		report += " no waves"
+	}
+
+	if isValidReading(data.WindSpeed, missingSpeedMarker) {
+		report += ", wind"
+		if direction := CompassDirection(data.WindDirection); direction != "" {
+			report += " " + direction
+		}
+		report += fmt.Sprintf(" %.0fkt", data.WindSpeed*metersPerSecondToKnots)
+		if isValidReading(data.WindGust, missingSpeedMarker) {
+			report += fmt.Sprintf(" G%.0f", data.WindGust*metersPerSecondToKnots)
+		}
+	}
+
+	if isValidReading(data.WaterTemperature, missingTemperatureMarker) {
+		report += fmt.Sprintf(", water %.0fF", data.WaterTemperature*9.0/5.0+32.0)
+	}
+	return report
+}
+
+// The buoy's metric conditions read out in feet and knots
+func buoyReport(place string, buoy *ClosestBuoy) string {
+	data := buoy.BuoyData
+	report := "Station " + buoy.BuoyStationID
+	if place != "" {
+		report += " near " + place
+	}
+
+	if !isValidReading(data.WaveSummary.WaveHeight, missingHeightMarker) {
+		report += " is not reporting waves right now."
+	} else {
+		report += fmt.Sprintf(" is reporting %.1f feet", data.WaveSummary.WaveHeight*metersToFeet)
+		if buoy.WaveTerm != "" {
+			report += " of " + buoy.WaveTerm
+		}
+		if isValidReading(data.WaveSummary.Period, missingPeriodMarker) {
+			report += fmt.Sprintf(" at %.0f seconds", data.WaveSummary.Period)
+		}
+		if direction := spokenDirection(data.WaveSummary.Direction); direction != "" {
+			report += " from the " + direction
+		}
+		report += "."
+	}
+
+	if isValidReading(data.WindSpeed, missingSpeedMarker) {
+		report += fmt.Sprintf(" Wind is %.0f knots", data.WindSpeed*metersPerSecondToKnots)
+		if direction := spokenDirection(data.WindDirection); direction != "" {
+			report += " from the " + direction
+		}
+		report += "."
+	}
+
+	if !data.Date.IsZero() {
+		report += " " + spokenAge(data.Date)
+	}
+	return report
+}
+
+func spotReport(conditions *SpotConditions) string {
+	report := fmt.Sprintf("%s is %.1f feet at %.0f seconds", conditions.Spot.Name, conditions.WaveHeight*metersToFeet, conditions.Period)
+	if direction := spokenDirection(conditions.Direction); direction != "" {
+		report += " from the " + direction
+	}
+	report += ", rated " + strings.ToLower(conditions.Rating) + "."
+	if !conditions.Date.IsZero() {
+		report += " " + spokenAge(conditions.Date)
+	}
+	return report
+}
+
+// The compass point spelled out, like south southeast for SSE
+func spokenDirection(degrees float64) string {
+	point := CompassDirection(degrees)
+	switch len(point) {
+	case 1:
+		return compassWords[point[0]]
+	case 2:
+		return compassWords[point[0]] + compassWords[point[1]]
+	case 3:
+		return compassWords[point[0]] + " " + compassWords[point[1]] + compassWords[point[2]]
+	}
+	return ""
+}
+
+func spokenAge(date time.Time) string {
+	minutes := int(time.Since(date).Minutes())
+	if minutes < 2 {
+		return "That was just now."
+	} else if minutes < 120 {
+		return fmt.Sprintf("That was %d minutes ago.", minutes)
+	}
+	return fmt.Sprintf("That was %d hours ago.", minutes/60)
+}
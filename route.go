@@ -0,0 +1,157 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+// Every leg is a closest buoy lookup and a forecast request
+const maxRouteWaypoints = 25
+
+type RouteWaypoint struct {
+	Location surfnerd.Location
+	ETA      time.Time
+}
+
+type RouteRequest struct {
+	Waypoints []RouteWaypoint
+}
+
+// The conditions along one leg of a passage, judged at its midpoint halfway
+// between the two ETAs. Observed is the latest from the buoy closest to the
+// midpoint, Forecast is the model at the midpoint for the mid leg time.
+type RouteLeg struct {
+	From           RouteWaypoint
+	To             RouteWaypoint
+	Midpoint       surfnerd.Location
+	MidpointETA    time.Time
+	DistanceNM     float64
+	Bearing        float64
+	BuoyStationID  string                 `json:",omitempty"`
+	BuoyLocation   *surfnerd.Location     `json:",omitempty"`
+	BuoyDistanceNM float64                `json:",omitempty"`
+	Observed       *surfnerd.BuoyDataItem `json:",omitempty"`
+	Forecast       *ForecastConditions    `json:",omitempty"`
+	Errors         []string               `json:",omitempty"`
+}
+
+func (self RouteRequest) validate() error {
+	if len(self.Waypoints) < 2 || len(self.Waypoints) > maxRouteWaypoints {
+		return errors.New("A route needs between 2 and 25 waypoints")
+	}
+	for index, waypoint := range self.Waypoints {
+		if waypoint.Location.Latitude < -90 || waypoint.Location.Latitude > 90 || waypoint.Location.Longitude < -180 || waypoint.Location.Longitude > 180 {
+			return errors.New("Invalid waypoint location")
+		}
+		if waypoint.ETA.IsZero() {
+			return errors.New("Every waypoint needs an ETA")
+		}
+		if index > 0 && waypoint.ETA.Before(self.Waypoints[index-1].ETA) {
+			return errors.New("The waypoint ETAs must be in order")
+		}
+	}
+	return nil
+}
+
+// Legs are short enough for coastal passages that the average of the ends is
+// close enough to the midpoint
+func newRouteLeg(from, to RouteWaypoint) RouteLeg {
+	distance := distanceBetween(from.Location, to.Location)
+	return RouteLeg{
+		From: from,
+		To:   to,
+		Midpoint: surfnerd.Location{
+			Latitude:  (from.Location.Latitude + to.Location.Latitude) / 2,
+			Longitude: (from.Location.Longitude + to.Location.Longitude) / 2,
+		},
+		MidpointETA: from.ETA.Add(to.ETA.Sub(from.ETA) / 2).UTC(),
+		DistanceNM:  ToFixedPoint(distance/kmPerNauticalMile, 2),
+		Bearing:     ToFixedPoint(bearingBetween(from.Location, to.Location), 1),
+	}
+}
+
+func routeConditionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 30*time.Second)
+	client := newFetchClient(ctx)
+
+	request := RouteRequest{}
+	if decodeErr := json.NewDecoder(r.Body).Decode(&request); decodeErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("Invalid request body: "+decodeErr.Error()))
+		return
+	}
+	if validateErr := request.validate(); validateErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, validateErr)
+		return
+	}
+
+	stations, stationsError := fetchAllStations(ctx, client)
+	if stationsError != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, stationsError)
+		return
+	}
+
+	options := parseClosestBuoyOptions(r)
+	fresh := map[string]bool{}
+	legs := make([]RouteLeg, len(request.Waypoints)-1)
+	stationIDs := []string{}
+	for index := range legs {
+		legs[index] = newRouteLeg(request.Waypoints[index], request.Waypoints[index+1])
+
+		closestBuoy, closestError := selectClosestBuoy(ctx, client, stations, legs[index].Midpoint, options, fresh)
+		if closestError != nil {
+			legs[index].Errors = append(legs[index].Errors, closestError.Error())
+			continue
+		}
+		legs[index].BuoyStationID = closestBuoy.StationID
+		legs[index].BuoyLocation = closestBuoy.Location
+		legs[index].BuoyDistanceNM = ToFixedPoint(distanceWith(options.DistanceAlgorithm, legs[index].Midpoint, *closestBuoy.Location)/kmPerNauticalMile, 2)
+		if !containsString(stationIDs, closestBuoy.StationID) {
+			stationIDs = append(stationIDs, closestBuoy.StationID)
+		}
+	}
+
+	// The observations and the forecasts are all fetched at once
+	wg := sync.WaitGroup{}
+	var buoys []*ClosestBuoy
+	var buoyErrs []error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		buoys, buoyErrs = fetchLatestBuoys(ctx, client, stationIDs)
+	}()
+	forecastErrs := make([]error, len(legs))
+	for index := range legs {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			legs[index].Forecast, forecastErrs[index] = fetchMarineForecast(client, legs[index].Midpoint, legs[index].MidpointETA)
+		}(index)
+	}
+	wg.Wait()
+
+	for index := range legs {
+		if forecastErrs[index] != nil {
+			legs[index].Errors = append(legs[index].Errors, "Forecast: "+forecastErrs[index].Error())
+		}
+		for stationIndex, stationID := range stationIDs {
+			if stationID != legs[index].BuoyStationID {
+				continue
+			}
+			if buoys[stationIndex] == nil {
+				legs[index].Errors = append(legs[index].Errors, "Observation: "+buoyErrs[stationIndex].Error())
+			} else {
+				legs[index].Observed = &buoys[stationIndex].BuoyData
+			}
+		}
+	}
+
+	writeDataResponse(w, r, client, &legs)
+}
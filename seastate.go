@@ -0,0 +1,89 @@
+package buoyfinder
+
+import (
+	"math"
+
+	"github.com/mpiannucci/surfnerd"
+)
+
+// The upper bound in meters of the significant height of each WMO sea state
+// code, from code table 3700. Heights above the last are phenomenal.
+var wmoSeaStateHeights = []float64{0, 0.1, 0.5, 1.25, 2.5, 4, 6, 9, 14}
+
+var wmoSeaStateDescriptions = []string{"calm (glassy)", "calm (rippled)", "smooth", "slight", "moderate", "rough", "very rough", "high", "very high", "phenomenal"}
+
+var douglasSwellDescriptions = []string{"no swell", "very low", "long and low", "short and moderate", "average and moderate", "long and moderate", "short and heavy", "average and heavy", "long and heavy", "confused"}
+
+// The sea state in the terms mariners use. Douglas degrees for the sea match
+// the WMO codes, while the Douglas swell scale also takes the swell's length
+// into account.
+type SeaState struct {
+	WMOCode                 int
+	Description             string
+	DouglasSwell            int
+	DouglasSwellDescription string
+}
+
+func newSeaState(data surfnerd.BuoyDataItem) *SeaState {
+	toMeters := 1.0
+	if data.Units == surfnerd.English {
+		toMeters = 1.0 / metersToFeet
+	}
+
+	height := data.WaveSummary.WaveHeight
+	if !isValidReading(height, missingHeightMarker) {
+		return nil
+	}
+	code := wmoSeaStateCode(height * toMeters)
+	state := &SeaState{WMOCode: code, Description: wmoSeaStateDescriptions[code]}
+
+	// The swell is the strongest component, or the whole sea when the
+	// spectra were not partitioned
+	swell, partitioned := data.WaveSummary, false
+	for _, component := range data.SwellComponents {
+		if isValidReading(component.WaveHeight, missingHeightMarker) && (!partitioned || component.WaveHeight > swell.WaveHeight) {
+			swell, partitioned = component, true
+		}
+	}
+	state.DouglasSwell = douglasSwellDegree(swell.WaveHeight*toMeters, swell.Period)
+	state.DouglasSwellDescription = douglasSwellDescriptions[state.DouglasSwell]
+	return state
+}
+
+func wmoSeaStateCode(heightMeters float64) int {
+	for code, limit := range wmoSeaStateHeights {
+		if heightMeters <= limit {
+			return code
+		}
+	}
+	return len(wmoSeaStateHeights)
+}
+
+// Swell is low below 2 meters and heavy above 4, and short, average, or long
+// by whether its deep water wavelength is under 100 meters, under 200, or
+// longer
+func douglasSwellDegree(heightMeters, period float64) int {
+	if !isValidReading(heightMeters, missingHeightMarker) || !isValidReading(period, missingPeriodMarker) || heightMeters <= 0 || period <= 0 {
+		return 0
+	}
+
+	wavelength := gravity * period * period / (2 * math.Pi)
+	length := 0
+	if wavelength >= 200 {
+		length = 2
+	} else if wavelength >= 100 {
+		length = 1
+	}
+
+	switch {
+	case heightMeters < 2:
+		// Low swell only distinguishes long swell from the rest
+		if length == 2 {
+			return 2
+		}
+		return 1
+	case heightMeters <= 4:
+		return 3 + length
+	}
+	return 6 + length
+}
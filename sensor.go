@@ -0,0 +1,120 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+// The latest conditions flattened for Home Assistant REST sensors. The keys
+// never change and missing readings are null, so templates like
+// value_json.wave_height keep working whatever the station reports.
+type SensorState struct {
+	Station          string   `json:"station"`
+	Status           string   `json:"status"`
+	Observed         string   `json:"observed"`
+	AgeMinutes       *float64 `json:"age_minutes"`
+	Units            string   `json:"units"`
+	WaveHeight       *float64 `json:"wave_height"`
+	DominantPeriod   *float64 `json:"dominant_period"`
+	AveragePeriod    *float64 `json:"average_period"`
+	WaveDirection    *float64 `json:"wave_direction"`
+	WaveCompass      string   `json:"wave_compass"`
+	WindSpeed        *float64 `json:"wind_speed"`
+	WindGust         *float64 `json:"wind_gust"`
+	WindDirection    *float64 `json:"wind_direction"`
+	WindCompass      string   `json:"wind_compass"`
+	Pressure         *float64 `json:"pressure"`
+	AirTemperature   *float64 `json:"air_temperature"`
+	WaterTemperature *float64 `json:"water_temperature"`
+	DewPoint         *float64 `json:"dew_point"`
+}
+
+// Metric by default, or english with ?units=english
+func sensorHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+
+	units := UnitsMetric
+	if r.URL.Query().Get("units") == UnitsEnglish {
+		units = UnitsEnglish
+	}
+
+	buoys, errs := fetchLatestBuoys(ctx, client, []string{stationID})
+	if buoys[0] == nil {
+		http.Error(w, errs[0].Error(), http.StatusBadGateway)
+		return
+	}
+
+	encoded, encodeErr := json.Marshal(newSensorState(buoys[0], units))
+	if encodeErr != nil {
+		http.Error(w, encodeErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(encoded)
+}
+
+func newSensorState(buoy *ClosestBuoy, units string) SensorState {
+	data := buoy.BuoyData
+	state := SensorState{
+		Station: buoy.BuoyStationID,
+		Status:  buoy.BuoyStatus,
+		Units:   units,
+	}
+	if !data.Date.IsZero() {
+		state.Observed = data.Date.UTC().Format(time.RFC3339)
+		age := ToFixedPoint(time.Since(data.Date).Minutes(), 1)
+		state.AgeMinutes = &age
+	}
+
+	// The missing markers are in metric, so which readings are valid has to
+	// be worked out before converting
+	valid := map[string]bool{}
+	for _, reading := range validReadings(data) {
+		valid[reading.Field] = true
+	}
+	if units == UnitsEnglish {
+		data.ChangeUnits(surfnerd.English)
+	}
+
+	reading := func(field string, value float64) *float64 {
+		if !valid[field] {
+			return nil
+		}
+		rounded := ToFixedPoint(value, 2)
+		return &rounded
+	}
+
+	state.WaveHeight = reading("wvht", data.WaveSummary.WaveHeight)
+	state.DominantPeriod = reading("dpd", data.WaveSummary.Period)
+	state.AveragePeriod = reading("apd", data.AveragePeriod)
+	state.WaveDirection = reading("mwd", data.WaveSummary.Direction)
+	state.WindSpeed = reading("wspd", data.WindSpeed)
+	state.WindGust = reading("gst", data.WindGust)
+	state.WindDirection = reading("wdir", data.WindDirection)
+	state.Pressure = reading("pres", data.Pressure)
+	state.AirTemperature = reading("atmp", data.AirTemperature)
+	state.WaterTemperature = reading("wtmp", data.WaterTemperature)
+	state.DewPoint = reading("dewp", data.DewpointTemperature)
+	if valid["mwd"] {
+		state.WaveCompass = CompassDirection(data.WaveSummary.Direction)
+	}
+	if valid["wdir"] {
+		state.WindCompass = CompassDirection(data.WindDirection)
+	}
+
+	return state
+}
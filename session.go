@@ -0,0 +1,129 @@
+package buoyfinder
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/user"
+)
+
+const userCookieName = "buoyfinder_user"
+const userCookieMaxAge = 365 * 24 * time.Hour
+
+const secretKind = "Secret"
+const cookieSecretKey = "cookie"
+
+type secret struct {
+	Value []byte
+}
+
+// The cookie signing key lives in the datastore so every instance shares it,
+// and is kept in memory once loaded since it never changes
+var cookieSecret struct {
+	sync.Mutex
+	value []byte
+}
+
+// Signed in users are identified by their Google account so their data follows
+// them between devices, everyone else by the id in their cookie
+func requestUserID(ctx context.Context, w http.ResponseWriter, r *http.Request) (string, error) {
+	if currentUser := user.Current(ctx); currentUser != nil {
+		return googleUserID(currentUser), nil
+	}
+	return userIDFromCookie(ctx, w, r)
+}
+
+func googleUserID(currentUser *user.User) string {
+	return "google:" + currentUser.ID
+}
+
+// Anonymous visitors are identified by a random id in a signed cookie. A new
+// id is handed out when the cookie is missing or has been tampered with.
+func userIDFromCookie(ctx context.Context, w http.ResponseWriter, r *http.Request) (string, error) {
+	key, keyErr := fetchCookieSecret(ctx)
+	if keyErr != nil {
+		return "", keyErr
+	}
+
+	if cookie, cookieErr := r.Cookie(userCookieName); cookieErr == nil {
+		if parts := strings.SplitN(cookie.Value, ".", 2); len(parts) == 2 && hmac.Equal([]byte(parts[1]), []byte(signUserID(key, parts[0]))) {
+			return parts[0], nil
+		}
+	}
+
+	userID, userIDErr := randomHex(16)
+	if userIDErr != nil {
+		return "", userIDErr
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     userCookieName,
+		Value:    userID + "." + signUserID(key, userID),
+		Path:     "/",
+		MaxAge:   int(userCookieMaxAge.Seconds()),
+		HttpOnly: true,
+	})
+	return userID, nil
+}
+
+func signUserID(key []byte, userID string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(userID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func fetchCookieSecret(ctx context.Context) ([]byte, error) {
+	cookieSecret.Lock()
+	defer cookieSecret.Unlock()
+	if cookieSecret.value != nil {
+		return cookieSecret.value, nil
+	}
+
+	stored := &secret{}
+	key := datastore.NewKey(ctx, secretKind, cookieSecretKey, 0, nil)
+	secretErr := datastore.RunInTransaction(ctx, func(tx context.Context) error {
+		getErr := datastore.Get(tx, key, stored)
+		if getErr != datastore.ErrNoSuchEntity {
+			return getErr
+		}
+
+		value, valueErr := randomHex(32)
+		if valueErr != nil {
+			return valueErr
+		}
+		stored.Value = []byte(value)
+		_, putErr := datastore.Put(tx, key, stored)
+		return putErr
+	}, nil)
+	if secretErr != nil {
+		return nil, secretErr
+	}
+
+	cookieSecret.value = stored.Value
+	return stored.Value, nil
+}
+
+// Api keys for other services are stored as secrets by hand in the console
+func fetchSecret(ctx context.Context, name string) ([]byte, error) {
+	stored := &secret{}
+	if getErr := datastore.Get(ctx, datastore.NewKey(ctx, secretKind, name, 0, nil), stored); getErr != nil {
+		return nil, getErr
+	}
+	return stored.Value, nil
+}
+
+func randomHex(length int) (string, error) {
+	b := make([]byte, length)
+	if _, randErr := rand.Read(b); randErr != nil {
+		return "", randErr
+	}
+	return hex.EncodeToString(b), nil
+}
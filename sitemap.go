@@ -0,0 +1,82 @@
+package buoyfinder
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/memcache"
+)
+
+const sitemapCacheKey = "sitemap"
+const sitemapCacheExpiration = time.Hour
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Location     string `xml:"loc"`
+	LastModified string `xml:"lastmod,omitempty"`
+}
+
+func sitemapHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	sitemap := []byte{}
+	if item, cacheErr := memcache.Get(ctx, sitemapCacheKey); cacheErr == nil {
+		sitemap = item.Value
+	} else {
+		stations, stationsError := fetchStations(ctx, client)
+		if stationsError != nil {
+			http.Error(w, stationsError.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// The sitemap is still useful without the dates, so a failure here
+		// only leaves them out
+		observations, _ := fetchLatestObservations(client)
+
+		baseURL := requestBaseURL(r)
+		urlSet := sitemapURLSet{
+			XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9",
+			URLs: []sitemapURL{
+				{Location: baseURL + "/"},
+				{Location: baseURL + "/api"},
+			},
+		}
+		for _, station := range stations.Stations {
+			if station.Active == "n" {
+				continue
+			}
+			stationURL := sitemapURL{Location: baseURL + "/buoy/" + station.StationID}
+			if observation, ok := observations[strings.ToUpper(station.StationID)]; ok {
+				stationURL.LastModified = observation.Date.UTC().Format(time.RFC3339)
+			}
+			urlSet.URLs = append(urlSet.URLs, stationURL)
+		}
+
+		encoded, encodeErr := xml.Marshal(urlSet)
+		if encodeErr != nil {
+			http.Error(w, encodeErr.Error(), http.StatusInternalServerError)
+			return
+		}
+		sitemap = append([]byte(xml.Header), encoded...)
+
+		memcache.Set(ctx, &memcache.Item{
+			Key:        sitemapCacheKey,
+			Value:      sitemap,
+			Expiration: sitemapCacheExpiration,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write(sitemap)
+}
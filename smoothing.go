@@ -0,0 +1,76 @@
+package buoyfinder
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Raw NDBC energy spectra are noisy enough that the charts end up spiky and the
+// partitioning picks out peaks that are not really there. Only these window
+// sizes are allowed since anything wider starts merging real swells together.
+var spectraSmoothingWindows = map[int]bool{3: true, 5: true}
+
+// The smoothing window from ?smooth=, or zero to leave the spectra alone
+func parseSpectraSmoothing(r *http.Request) int {
+	window, windowErr := strconv.Atoi(r.URL.Query().Get("smooth"))
+	if windowErr != nil || !spectraSmoothingWindows[window] {
+		return 0
+	}
+	return window
+}
+
+// Applies a centered moving average to the energies of each row of a raw
+// data_spec file. Rows are YYYY MM DD hh mm sep_freq followed by
+// "energy (frequency)" pairs. The rows are smoothed before they are parsed so
+// that the swell partitioning works from the smoothed spectra too.
+func smoothRawEnergySpectra(rawEnergyData []string, window int) []string {
+	if window < 2 {
+		return rawEnergyData
+	}
+
+	smoothed := make([]string, len(rawEnergyData))
+	for index, line := range rawEnergyData {
+		smoothed[index] = smoothRawEnergyRow(line, window)
+	}
+	return smoothed
+}
+
+func smoothRawEnergyRow(line string, window int) string {
+	fields := strings.Fields(line)
+	if len(fields) < 8 || strings.HasPrefix(fields[0], "#") {
+		return line
+	}
+
+	energies := []float64{}
+	for index := 6; index < len(fields); index += 2 {
+		energy, energyErr := strconv.ParseFloat(fields[index], 64)
+		if energyErr != nil {
+			return line
+		}
+		energies = append(energies, energy)
+	}
+
+	for index, energy := range movingAverage(energies, window) {
+		fields[6+index*2] = strconv.FormatFloat(energy, 'f', 3, 64)
+	}
+	return strings.Join(fields, " ")
+}
+
+// The window shrinks at the ends of the spectra rather than padding them, so
+// the lowest and highest frequency bins are not pulled towards zero
+func movingAverage(values []float64, window int) []float64 {
+	half := window / 2
+	averaged := make([]float64, len(values))
+	for index := range values {
+		sum, count := 0.0, 0
+		for offset := -half; offset <= half; offset++ {
+			if neighbor := index + offset; neighbor >= 0 && neighbor < len(values) {
+				sum += values[neighbor]
+				count++
+			}
+		}
+		averaged[index] = sum / float64(count)
+	}
+	return averaged
+}
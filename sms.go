@@ -0,0 +1,76 @@
+package buoyfinder
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"net/http"
+	"sort"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/log"
+)
+
+const twilioSecretKey = "twilio"
+
+type twimlResponse struct {
+	XMLName xml.Name `xml:"Response"`
+	Message string   `xml:"Message"`
+}
+
+// Replies to text messages sent to the Twilio number with the conditions
+func smsWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	if parseErr := r.ParseForm(); parseErr != nil {
+		http.Error(w, parseErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	authToken, tokenErr := fetchSecret(ctx, twilioSecretKey)
+	if tokenErr != nil {
+		log.Errorf(ctx, "The Twilio auth token is not set up: %v", tokenErr)
+		http.Error(w, "SMS is not set up", http.StatusServiceUnavailable)
+		return
+	}
+	if !validTwilioSignature(authToken, requestBaseURL(r)+r.URL.RequestURI(), r, r.Header.Get("X-Twilio-Signature")) {
+		http.Error(w, "Invalid signature", http.StatusForbidden)
+		return
+	}
+
+	reply, replyErr := xml.Marshal(twimlResponse{Message: replyToTextQuery(ctx, client, r.PostForm.Get("Body"))})
+	if replyErr != nil {
+		http.Error(w, replyErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write(append([]byte(xml.Header), reply...))
+}
+
+// Twilio signs the url followed by each posted field name and value, sorted
+// by name
+func validTwilioSignature(authToken []byte, requestURL string, r *http.Request, signature string) bool {
+	names := []string{}
+	for name := range r.PostForm {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	payload := requestURL
+	for _, name := range names {
+		for _, value := range r.PostForm[name] {
+			payload += name + value
+		}
+	}
+
+	mac := hmac.New(sha1.New, authToken)
+	mac.Write([]byte(payload))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
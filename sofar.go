@@ -0,0 +1,235 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+)
+
+const sofarAPIURL = "https://api.sofarocean.com/api/"
+
+// The Sofar api token, which only lists the spotters shared with it
+const sofarSecretKey = "sofar"
+
+// Spotter ids look like SPOT-0222, which becomes SOFAR0222
+const sofarSpotterPrefix = "SPOT-"
+
+// Spotters drift, so they are placed again about as often as they report
+const sofarStationsCacheExpiration = time.Hour
+
+// Until the token is set up, the secret is only looked up again this often
+// rather than on every station listing
+const sofarTokenRetryInterval = 10 * time.Minute
+
+// The token is kept in memory once loaded, like the cookie secret, along with
+// when it was last found missing
+var sofarToken struct {
+	sync.Mutex
+	value   string
+	missing time.Time
+}
+
+// Sofar Ocean's Spotter buoys. They are only listed once the api token has
+// been stored as a secret.
+type sofarProvider struct{}
+
+func (sofarProvider) Prefix() string {
+	return "SOFAR"
+}
+
+func (sofarProvider) Capabilities() []string {
+	return []string{CapabilityWaves, CapabilityMeteorology}
+}
+
+func (sofarProvider) Configure(ctx context.Context) bool {
+	sofarToken.Lock()
+	defer sofarToken.Unlock()
+	if sofarToken.value != "" {
+		return true
+	}
+	if time.Since(sofarToken.missing) < sofarTokenRetryInterval {
+		return false
+	}
+
+	token, tokenErr := fetchSecret(ctx, sofarSecretKey)
+	if tokenErr != nil || len(token) == 0 {
+		sofarToken.missing = time.Now()
+		return false
+	}
+	sofarToken.value = string(token)
+	return true
+}
+
+func (sofarProvider) StationsCacheExpiration() time.Duration {
+	return sofarStationsCacheExpiration
+}
+
+type sofarDevicesResponse struct {
+	Data struct {
+		Devices []struct {
+			SpotterID string `json:"spotterId"`
+			Name      string `json:"name"`
+		} `json:"devices"`
+	} `json:"data"`
+}
+
+type sofarWaveReading struct {
+	SignificantWaveHeight float64   `json:"significantWaveHeight"`
+	PeakPeriod            float64   `json:"peakPeriod"`
+	MeanPeriod            float64   `json:"meanPeriod"`
+	PeakDirection         float64   `json:"peakDirection"`
+	Latitude              float64   `json:"latitude"`
+	Longitude             float64   `json:"longitude"`
+	Timestamp             time.Time `json:"timestamp"`
+}
+
+type sofarWindReading struct {
+	Speed     float64   `json:"speed"`
+	Direction float64   `json:"direction"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type sofarTemperatureReading struct {
+	Degrees   float64   `json:"degrees"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type sofarWaveDataResponse struct {
+	Data struct {
+		Waves       []sofarWaveReading        `json:"waves"`
+		Wind        []sofarWindReading        `json:"wind"`
+		SurfaceTemp []sofarTemperatureReading `json:"surfaceTemp"`
+	} `json:"data"`
+}
+
+func fetchSofarJSON(client *http.Client, path string, query url.Values, data interface{}) error {
+	sofarToken.Lock()
+	token := sofarToken.value
+	sofarToken.Unlock()
+	if token == "" {
+		return errors.New("The Sofar api token is not set up")
+	}
+
+	req, reqErr := http.NewRequest("GET", sofarAPIURL+path+"?"+query.Encode(), nil)
+	if reqErr != nil {
+		return reqErr
+	}
+	req.Header.Set("token", token)
+
+	resp, fetchErr := client.Do(req)
+	if fetchErr != nil {
+		return fetchErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("The Sofar api could not be reached: " + resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(data)
+}
+
+// Spotters drift, so each one is placed where it last reported
+func (self sofarProvider) FetchStations(client *http.Client) ([]surfnerd.Buoy, error) {
+	devices := sofarDevicesResponse{}
+	if fetchErr := fetchSofarJSON(client, "devices", url.Values{}, &devices); fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	stations := make([]*surfnerd.Buoy, len(devices.Data.Devices))
+	wg := sync.WaitGroup{}
+	for index, device := range devices.Data.Devices {
+		wg.Add(1)
+		go func(index int, spotterID, name string) {
+			defer wg.Done()
+			latest := sofarWaveDataResponse{}
+			if fetchErr := fetchSofarJSON(client, "latest-data", url.Values{"spotterId": {spotterID}}, &latest); fetchErr != nil || len(latest.Data.Waves) == 0 {
+				return
+			}
+
+			reading := latest.Data.Waves[len(latest.Data.Waves)-1]
+			if name == "" {
+				name = spotterID
+			}
+			stations[index] = &surfnerd.Buoy{
+				StationID: self.Prefix() + strings.TrimPrefix(strings.ToUpper(spotterID), sofarSpotterPrefix),
+				Owner:     "Sofar Ocean",
+				PGM:       "Spotter",
+				Type:      "buoy",
+				Active:    "y",
+				Location: &surfnerd.Location{
+					Latitude:     reading.Latitude,
+					Longitude:    reading.Longitude,
+					LocationName: name,
+				},
+			}
+		}(index, device.SpotterID, device.Name)
+	}
+	wg.Wait()
+
+	buoys := []surfnerd.Buoy{}
+	for _, station := range stations {
+		if station != nil {
+			buoys = append(buoys, *station)
+		}
+	}
+	return buoys, nil
+}
+
+func (sofarProvider) FetchObservations(client *http.Client, stationID string, count int) ([]surfnerd.BuoyDataItem, error) {
+	query := url.Values{
+		"spotterId":              {sofarSpotterPrefix + stationID},
+		"limit":                  {strconv.Itoa(count)},
+		"includeWindData":        {"true"},
+		"includeSurfaceTempData": {"true"},
+	}
+	data := sofarWaveDataResponse{}
+	if fetchErr := fetchSofarJSON(client, "wave-data", query, &data); fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	// Wind and temperature are reported on their own timestamps, usually
+	// the same ones as the waves
+	winds := map[int64]sofarWindReading{}
+	for _, wind := range data.Data.Wind {
+		winds[wind.Timestamp.Unix()] = wind
+	}
+	temperatures := map[int64]sofarTemperatureReading{}
+	for _, temperature := range data.Data.SurfaceTemp {
+		temperatures[temperature.Timestamp.Unix()] = temperature
+	}
+
+	observations := []surfnerd.BuoyDataItem{}
+	for _, wave := range data.Data.Waves {
+		observation := newMissingObservation(wave.Timestamp.UTC())
+		observation.WaveSummary.WaveHeight = wave.SignificantWaveHeight
+		observation.WaveSummary.Period = wave.PeakPeriod
+		observation.WaveSummary.Direction = wave.PeakDirection
+		observation.WaveSummary.CompassDirection = CompassDirection(wave.PeakDirection)
+		observation.AveragePeriod = wave.MeanPeriod
+		if wind, ok := winds[wave.Timestamp.Unix()]; ok {
+			observation.WindSpeed = wind.Speed
+			observation.WindDirection = wind.Direction
+		}
+		if temperature, ok := temperatures[wave.Timestamp.Unix()]; ok {
+			observation.WaterTemperature = temperature.Degrees
+		}
+		observations = append(observations, observation)
+	}
+
+	sort.Slice(observations, func(i, j int) bool {
+		return observations[i].Date.After(observations[j].Date)
+	})
+	if len(observations) > count {
+		observations = observations[:count]
+	}
+	return observations, nil
+}
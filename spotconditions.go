@@ -0,0 +1,217 @@
+package buoyfinder
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/buoyfinder/geo"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+const metersToFeet = 3.28084
+
+var spotRatings = []string{"Flat", "Poor", "Poor to Fair", "Fair", "Good", "Epic"}
+
+// How much one of the spot's buoys counts towards the blended conditions
+type SpotBuoy struct {
+	Weight float64
+	Buoy   ClosestBuoy
+}
+
+// The conditions at a spot, blended from its buoys using only the swell that
+// falls inside the spot's swell window. Heights are in meters.
+type SpotConditions struct {
+	Spot             *Spot
+	Date             time.Time
+	WaveHeight       float64
+	Period           float64
+	Direction        float64
+	CompassDirection string
+	Score            int
+	Rating           string
+	Buoys            []SpotBuoy
+	Errors           map[string]string `json:",omitempty"`
+}
+
+func spotConditionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	spot, spotErr := fetchSpot(ctx, mux.Vars(r)["spot"])
+	if spotErr == errSpotNotFound {
+		writeErrorResponse(w, r, http.StatusNotFound, spotErr)
+		return
+	} else if spotErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, spotErr)
+		return
+	}
+
+	conditions, conditionsErr := fetchSpotConditions(ctx, client, spot, parseSpectraSmoothing(r))
+	if conditionsErr != nil {
+		writeErrorResponse(w, r, http.StatusBadGateway, conditionsErr)
+		return
+	}
+
+	envelope := newResponseEnvelope(r, client, conditions)
+	envelope.SetObservation("", conditions.Date)
+	writeEnvelope(w, r, http.StatusOK, envelope)
+}
+
+func fetchSpotConditions(ctx context.Context, client *http.Client, spot *Spot, smoothing int) (*SpotConditions, error) {
+	var wg sync.WaitGroup
+	now := time.Now()
+	buoys := make([]*ClosestBuoy, len(spot.BuoyStations))
+	errs := make([]error, len(spot.BuoyStations))
+
+	for index, stationID := range spot.BuoyStations {
+		wg.Add(1)
+		go func(index int, stationID string) {
+			defer wg.Done()
+			buoy, buoyErr := fetchBuoyWithID(ctx, client, stationID)
+			if buoyErr != nil {
+				errs[index] = buoyErr
+				return
+			}
+			if fetchErr := fetchDetailedWaveBuoyData(client, buoy, 1, smoothing); fetchErr != nil {
+				errs[index] = fetchErr
+				return
+			}
+
+			buoyData, timeDiff := buoy.FindConditionsForDateAndTime(now)
+			buoyData.ChangeUnits(surfnerd.Metric)
+			buoys[index] = &ClosestBuoy{
+				RequestedLocation: spot.Location,
+				RequestedDate:     now,
+				TimeDiffFound:     timeDiff,
+				BuoyStationID:     buoy.StationID,
+				BuoyStatus:        fetchBuoyStatus(ctx, buoy.StationID),
+				BuoyLocation:      *buoy.Location,
+				BuoyData:          buoyData,
+			}
+			buoys[index].SetDistance(geo.DefaultAlgorithm)
+		}(index, stationID)
+	}
+	wg.Wait()
+
+	conditions := &SpotConditions{
+		Spot:  spot,
+		Buoys: []SpotBuoy{},
+	}
+	for index, buoy := range buoys {
+		if buoy == nil {
+			if conditions.Errors == nil {
+				conditions.Errors = map[string]string{}
+			}
+			conditions.Errors[spot.BuoyStations[index]] = errs[index].Error()
+			continue
+		}
+		conditions.Buoys = append(conditions.Buoys, SpotBuoy{
+			Weight: spotBuoyWeight(spot, buoy),
+			Buoy:   *buoy,
+		})
+		if buoy.BuoyData.Date.After(conditions.Date) {
+			conditions.Date = buoy.BuoyData.Date
+		}
+	}
+	if len(conditions.Buoys) == 0 {
+		return nil, errors.New("None of the spot's buoys have data")
+	}
+
+	conditions.blend()
+	return conditions, nil
+}
+
+// Closer buoys count for more, as do buoys out in front of the beach rather
+// than off to the side of it or around a headland behind it
+func spotBuoyWeight(spot *Spot, buoy *ClosestBuoy) float64 {
+	distance := math.Max(buoy.DistanceKM, 1.0)
+	alignment := math.Cos((bearingBetween(spot.Location, buoy.BuoyLocation) - spot.BeachFacing) * math.Pi / 180.0)
+	exposure := math.Max((1.0+alignment)/2.0, 0.1)
+	return ToFixedPoint(exposure/distance, 4)
+}
+
+// Combines the swell window part of each buoy's spectra into one summary.
+// Heights add by energy within a buoy, and the buoys are then averaged by
+// their weights. Buoys with nothing in the window still count toward the
+// height as flat, but have no period or direction to add.
+func (self *SpotConditions) blend() {
+	totalWeight, height := 0.0, 0.0
+	peakWeight, period, directionX, directionY := 0.0, 0.0, 0.0, 0.0
+	for _, spotBuoy := range self.Buoys {
+		energy, peak := 0.0, surfnerd.Swell{}
+		for _, swell := range spotBuoy.Buoy.BuoyData.SwellComponents {
+			if !isValidReading(swell.WaveHeight, missingHeightMarker) || !self.Spot.InSwellWindow(swell.Direction) {
+				continue
+			}
+			energy += swell.WaveHeight * swell.WaveHeight
+			if swell.WaveHeight > peak.WaveHeight {
+				peak = swell
+			}
+		}
+
+		weight := spotBuoy.Weight
+		totalWeight += weight
+		height += weight * math.Sqrt(energy)
+		if energy == 0 {
+			continue
+		}
+
+		peakWeight += weight
+		period += weight * peak.Period
+		directionX += weight * math.Sin(peak.Direction*math.Pi/180.0)
+		directionY += weight * math.Cos(peak.Direction*math.Pi/180.0)
+	}
+	if totalWeight == 0 {
+		return
+	}
+
+	self.WaveHeight = ToFixedPoint(height/totalWeight, 2)
+	if peakWeight > 0 {
+		self.Period = ToFixedPoint(period/peakWeight, 1)
+		self.Direction = ToFixedPoint(math.Mod(math.Atan2(directionX, directionY)*180.0/math.Pi+360.0, 360.0), 0)
+		self.CompassDirection = CompassDirection(self.Direction)
+	}
+	self.Score = spotScore(self.WaveHeight, self.Period)
+	self.Rating = spotRatings[self.Score]
+}
+
+// A rough 0 to 5 score from the height in feet, nudged up for long period
+// groundswell and down for short period wind chop
+func spotScore(height, period float64) int {
+	heightFeet := height * metersToFeet
+	score := 0
+	switch {
+	case heightFeet < 1:
+		return 0
+	case heightFeet < 2:
+		score = 1
+	case heightFeet < 3:
+		score = 2
+	case heightFeet < 5:
+		score = 3
+	case heightFeet < 8:
+		score = 4
+	default:
+		score = 5
+	}
+
+	if period >= 12 {
+		score++
+	} else if period < 7 {
+		score--
+	}
+
+	if score < 1 {
+		return 1
+	} else if score >= len(spotRatings) {
+		return len(spotRatings) - 1
+	}
+	return score
+}
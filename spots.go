@@ -0,0 +1,245 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/log"
+	"google.golang.org/appengine/user"
+)
+
+const spotKind = "Spot"
+
+// Conditions are blended from a handful of buoys at most, anything more is
+// almost certainly a mistake
+const maxSpotBuoys = 5
+
+var spotIDRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+var errSpotNotFound = errors.New("Could not find the requested spot")
+var errSpotExists = errors.New("A spot with that name already exists")
+
+// A named surf break. The swell window is the range of directions, clockwise
+// from SwellWindowStart to SwellWindowEnd, that swell can reach the break from.
+type Spot struct {
+	ID               string `datastore:"-"`
+	Name             string
+	Location         surfnerd.Location
+	BeachFacing      float64
+	SwellWindowStart float64
+	SwellWindowEnd   float64
+	BuoyStations     []string
+	TideStation      string `json:",omitempty"`
+	// The depth in meters just off the break, looked up from the bathymetry
+	// when it is left out
+	Depth float64 `json:",omitempty"`
+}
+
+func (self Spot) validate() error {
+	switch {
+	case strings.TrimSpace(self.Name) == "":
+		return errors.New("The spot needs a name")
+	case self.Location.Latitude < -90 || self.Location.Latitude > 90 || self.Location.Longitude < -180 || self.Location.Longitude > 180:
+		return errors.New("Invalid spot location")
+	case !isValidHeading(self.BeachFacing) || !isValidHeading(self.SwellWindowStart) || !isValidHeading(self.SwellWindowEnd):
+		return errors.New("Directions must be between 0 and 360 degrees")
+	case len(self.BuoyStations) == 0 || len(self.BuoyStations) > maxSpotBuoys:
+		return errors.New("A spot needs between 1 and 5 buoy stations")
+	case self.Depth < 0:
+		return errors.New("The spot depth must be positive")
+	case self.TideStation != "" && !hasTides(self.Location):
+		return errors.New("Great Lakes spots have no tides, so they cannot have a tide station")
+	}
+	return nil
+}
+
+func isValidHeading(degrees float64) bool {
+	return degrees >= 0 && degrees <= 360
+}
+
+// Whether swell from the direction can reach the spot. Windows can wrap past
+// north, like 300 to 45.
+func (self Spot) InSwellWindow(direction float64) bool {
+	return inDirectionalWindow(direction, self.SwellWindowStart, self.SwellWindowEnd)
+}
+
+// Spot ids are the lowercased name with runs of anything else turned into
+// dashes, so "Point Judith Lighthouse" becomes point-judith-lighthouse
+func newSpotID(name string) string {
+	return strings.Trim(spotIDRegex.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func listSpotsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	spots := []Spot{}
+	keys, spotsErr := datastore.NewQuery(spotKind).Order("Name").GetAll(ctx, &spots)
+	if spotsErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, spotsErr)
+		return
+	}
+	for index, key := range keys {
+		spots[index].ID = key.StringID()
+	}
+
+	writeDataResponse(w, r, nil, &spots)
+}
+
+func getSpotHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	spot, spotErr := fetchSpot(ctx, mux.Vars(r)["spot"])
+	if spotErr == errSpotNotFound {
+		writeErrorResponse(w, r, http.StatusNotFound, spotErr)
+		return
+	} else if spotErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, spotErr)
+		return
+	}
+
+	writeDataResponse(w, r, nil, spot)
+}
+
+func createSpotHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+	if !user.IsAdmin(ctx) {
+		writeErrorResponse(w, r, http.StatusForbidden, errors.New("Only admins can add spots"))
+		return
+	}
+
+	spot, decodeErr := decodeSpot(r)
+	if decodeErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, decodeErr)
+		return
+	}
+	spot.ID = newSpotID(spot.Name)
+	if spot.ID == "" {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("The spot name needs at least one letter or number"))
+		return
+	}
+	fillSpotDepth(ctx, spot)
+
+	key := datastore.NewKey(ctx, spotKind, spot.ID, 0, nil)
+	putErr := datastore.RunInTransaction(ctx, func(tx context.Context) error {
+		if getErr := datastore.Get(tx, key, &Spot{}); getErr != datastore.ErrNoSuchEntity {
+			if getErr == nil {
+				return errSpotExists
+			}
+			return getErr
+		}
+		_, putErr := datastore.Put(tx, key, spot)
+		return putErr
+	}, nil)
+	if putErr == errSpotExists {
+		writeErrorResponse(w, r, http.StatusConflict, putErr)
+		return
+	} else if putErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, putErr)
+		return
+	}
+
+	writeEnvelope(w, r, http.StatusCreated, newResponseEnvelope(r, nil, spot))
+}
+
+func updateSpotHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+	if !user.IsAdmin(ctx) {
+		writeErrorResponse(w, r, http.StatusForbidden, errors.New("Only admins can edit spots"))
+		return
+	}
+
+	spotID := mux.Vars(r)["spot"]
+	if _, spotErr := fetchSpot(ctx, spotID); spotErr == errSpotNotFound {
+		writeErrorResponse(w, r, http.StatusNotFound, spotErr)
+		return
+	} else if spotErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, spotErr)
+		return
+	}
+
+	spot, decodeErr := decodeSpot(r)
+	if decodeErr != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, decodeErr)
+		return
+	}
+
+	// The id stays the same even if the spot is renamed so links keep working
+	spot.ID = spotID
+	fillSpotDepth(ctx, spot)
+	if _, putErr := datastore.Put(ctx, datastore.NewKey(ctx, spotKind, spotID, 0, nil), spot); putErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, putErr)
+		return
+	}
+
+	writeDataResponse(w, r, nil, spot)
+}
+
+func deleteSpotHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+	if !user.IsAdmin(ctx) {
+		writeErrorResponse(w, r, http.StatusForbidden, errors.New("Only admins can delete spots"))
+		return
+	}
+
+	key := datastore.NewKey(ctx, spotKind, mux.Vars(r)["spot"], 0, nil)
+	if deleteErr := datastore.Delete(ctx, key); deleteErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, deleteErr)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Spots saved without a depth get the bathymetry's. A spot the lookup fails
+// for is still saved, just without one.
+func fillSpotDepth(ctx context.Context, spot *Spot) {
+	if spot.Depth > 0 {
+		return
+	}
+
+	depthCtx, _ := context.WithTimeout(ctx, 10*time.Second)
+	depth, depthErr := fetchWaterDepth(depthCtx, newFetchClient(depthCtx), spot.Location)
+	if depthErr != nil {
+		log.Warningf(ctx, "Could not look up the depth at spot %s: %v", spot.ID, depthErr)
+		return
+	}
+	spot.Depth = depth.Depth
+}
+
+func fetchSpot(ctx context.Context, spotID string) (*Spot, error) {
+	spot := &Spot{}
+	getErr := datastore.Get(ctx, datastore.NewKey(ctx, spotKind, spotID, 0, nil), spot)
+	if getErr == datastore.ErrNoSuchEntity {
+		return nil, errSpotNotFound
+	} else if getErr != nil {
+		return nil, getErr
+	}
+
+	spot.ID = spotID
+	return spot, nil
+}
+
+func decodeSpot(r *http.Request) (*Spot, error) {
+	spot := &Spot{}
+	if decodeErr := json.NewDecoder(r.Body).Decode(spot); decodeErr != nil {
+		return nil, errors.New("Invalid request body: " + decodeErr.Error())
+	}
+
+	for index, stationID := range spot.BuoyStations {
+		spot.BuoyStations[index] = strings.ToUpper(strings.TrimSpace(stationID))
+	}
+
+	if validateErr := spot.validate(); validateErr != nil {
+		return nil, validateErr
+	}
+	return spot, nil
+}
@@ -0,0 +1,102 @@
+package buoyfinder
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine/log"
+)
+
+// NOAA's daily quarter degree OISST analysis, served by the CoastWatch ERDDAP
+const (
+	satelliteSSTURL     = "https://coastwatch.pfeg.noaa.gov/erddap/griddap/ncdcOisst21NrtAgg.json"
+	satelliteSSTDataset = "NOAA OISST v2.1"
+)
+
+// The analysis is only produced once a day
+const satelliteSSTCacheExpiration = 6 * time.Hour
+
+// The near real time analysis lags a day or so behind, so anything more
+// recent reads the latest one
+const satelliteSSTLag = 48 * time.Hour
+
+// Where a water temperature that did not come from the buoy itself came from
+type WaterTemperatureSource struct {
+	Source   string
+	Dataset  string
+	Date     time.Time
+	Location surfnerd.Location
+}
+
+// Only observations read from the meteorological data can be missing the water
+// temperature. The spectra files never carry one, so a wave observation from a
+// buoy with a working sensor would otherwise be labeled as satellite data.
+func isMissingWaterTemperature(data surfnerd.BuoyDataItem) bool {
+	return len(data.WaveSpectra.Frequencies) == 0 && !isValidReading(data.WaterTemperature, missingTemperatureMarker)
+}
+
+// Buoys without a temperature sensor get the satellite temperature at the
+// requested point instead, or at the buoy for station id requests
+func applySatelliteWaterTemperature(ctx context.Context, client *http.Client, container *ClosestBuoy) {
+	location := container.RequestedLocation
+	if !hasLocation(location) {
+		location = container.BuoyLocation
+	}
+	if !hasLocation(location) {
+		return
+	}
+
+	temperature, source, fetchErr := fetchSatelliteWaterTemperature(ctx, client, location, container.BuoyData.Date)
+	if fetchErr != nil {
+		log.Warningf(ctx, "Could not fetch the satellite water temperature for %s: %v", container.BuoyStationID, fetchErr)
+		return
+	}
+
+	if container.BuoyData.Units == surfnerd.English {
+		temperature = temperature*9.0/5.0 + 32.0
+	}
+	container.BuoyData.WaterTemperature = ToFixedPoint(temperature, 1)
+	container.WaterTemperatureSource = source
+}
+
+// The sea surface temperature in celsius at the grid cell nearest the location
+func fetchSatelliteWaterTemperature(ctx context.Context, client *http.Client, location surfnerd.Location, date time.Time) (float64, *WaterTemperatureSource, error) {
+	// The grid runs from 0 to 360 degrees east
+	longitude := math.Mod(location.Longitude+360.0, 360.0)
+	timeIndex := "last"
+	if !date.IsZero() && time.Since(date) > satelliteSSTLag {
+		timeIndex = "(" + date.UTC().Format("2006-01-02T12:00:00Z") + ")"
+	}
+	query := "sst[" + timeIndex + "][0][(" + strconv.FormatFloat(location.Latitude, 'f', 3, 64) + ")][(" + strconv.FormatFloat(longitude, 'f', 3, 64) + ")]"
+
+	row, fetchErr := fetchERDDAPRow(ctx, client, satelliteSSTURL, query, satelliteSSTCacheExpiration)
+	if fetchErr != nil {
+		return 0, nil, fetchErr
+	}
+
+	// Cells over land come back as null
+	temperature, ok := row["sst"].(float64)
+	if !ok {
+		return 0, nil, errors.New("The satellite analysis has no water temperature for the location")
+	}
+
+	source := &WaterTemperatureSource{Source: "satellite", Dataset: satelliteSSTDataset}
+	if timestamp, ok := row["time"].(string); ok {
+		source.Date, _ = time.Parse(time.RFC3339, timestamp)
+	}
+	if latitude, ok := row["latitude"].(float64); ok {
+		source.Location.Latitude = latitude
+	}
+	if cellLongitude, ok := row["longitude"].(float64); ok {
+		if cellLongitude > 180.0 {
+			cellLongitude -= 360.0
+		}
+		source.Location.Longitude = cellLongitude
+	}
+	return temperature, source, nil
+}
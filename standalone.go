@@ -0,0 +1,141 @@
+package buoyfinder
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+)
+
+// Entry points for cmd/grpcserver, which serves the messages described by
+// proto/buoyfinder.proto. Each returns the messages in the protocol buffers
+// wire format, encoded the same way as the REST API's protobuf responses.
+
+// The chart types of buoyfinder.GetChartRequest
+const (
+	DirectionalSpectraChart  = 0
+	SpectraDistributionChart = 1
+)
+
+// Creates the client the standalone server talks to NDBC and the chart
+// exporter with, throttled like the handlers' clients
+func NewFetchClient(ctx context.Context) *http.Client {
+	return newFetchClient(ctx)
+}
+
+// Encodes every station as a buoyfinder.GetStationsResponse message
+func FetchStationsMessage(ctx context.Context, client *http.Client) ([]byte, error) {
+	stations, stationsErr := fetchAllStations(ctx, client)
+	if stationsErr != nil {
+		return nil, stationsErr
+	}
+	return encodeStations(newStationsListing(stations, "")), nil
+}
+
+// The buoy a buoyfinder.BuoyQuery picks. Without a station id the closest
+// recently reporting wave buoy to the location is used.
+func findQueriedBuoy(ctx context.Context, client *http.Client, stationID string, location *surfnerd.Location) (*surfnerd.Buoy, error) {
+	if stationID != "" {
+		return &surfnerd.Buoy{StationID: stationID}, nil
+	}
+	if location == nil {
+		return nil, errors.New("Either a station id or a location is required")
+	}
+	return fetchClosestBuoy(ctx, client, *location, ClosestBuoyOptions{MaxAge: stationReportingThreshold})
+}
+
+// Encodes the latest metric conditions as a buoyfinder.ClosestBuoy message
+func FetchLatestMessage(ctx context.Context, client *http.Client, stationID string, location *surfnerd.Location) ([]byte, error) {
+	buoy, buoyErr := findQueriedBuoy(ctx, client, stationID, location)
+	if buoyErr != nil {
+		return nil, buoyErr
+	}
+	if fetchErr := fetchLatestBuoyData(client, buoy); fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	requestedDate := time.Now()
+	buoyData, timeDiff := buoy.FindConditionsForDateAndTime(requestedDate)
+	buoyData.ChangeUnits(surfnerd.Metric)
+
+	container := ClosestBuoy{
+		RequestedDate: requestedDate,
+		TimeDiffFound: timeDiff,
+		BuoyStationID: buoy.StationID,
+		BuoyStatus:    fetchBuoyStatus(ctx, buoy.StationID),
+		BuoyData:      buoyData,
+	}
+	if location != nil {
+		container.RequestedLocation = *location
+	}
+	if buoy.Location != nil {
+		container.BuoyLocation = *buoy.Location
+	}
+	return container.ToProtobuf(), nil
+}
+
+// Encodes the metric wave observations between the epochs, oldest first, as
+// a buoyfinder.GetRangeResponse message. Like the exports, ranges before the
+// realtime files are read from the archive.
+func FetchRangeMessage(ctx context.Context, client *http.Client, stationID string, location *surfnerd.Location, start, end int64) ([]byte, error) {
+	if end == 0 {
+		end = time.Now().Unix()
+	}
+	if start >= end {
+		return nil, errors.New("The start date must be before the end date")
+	}
+	if end-start > int64(maxExportRange/time.Second) {
+		return nil, errors.New("Only 45 days of data can be fetched at a time")
+	}
+
+	buoy, buoyErr := findQueriedBuoy(ctx, client, stationID, location)
+	if buoyErr != nil {
+		return nil, buoyErr
+	}
+	observations, fetchErr := fetchObservationRange(ctx, client, buoy, time.Unix(start, 0), time.Unix(end, 0), 0)
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	b := appendStringField([]byte{}, 1, buoy.StationID)
+	for _, observation := range observations {
+		observation.ChangeUnits(surfnerd.Metric)
+		b = appendMessageField(b, 2, encodeObservation(observation))
+	}
+	return b, nil
+}
+
+// Encodes the exported chart of the observation nearest the epoch, or the
+// latest one when it is zero, as a buoyfinder.GetChartResponse message
+func FetchChartMessage(ctx context.Context, client *http.Client, stationID string, location *surfnerd.Location, chartType int, date int64) ([]byte, error) {
+	buoy, buoyErr := findQueriedBuoy(ctx, client, stationID, location)
+	if buoyErr != nil {
+		return nil, buoyErr
+	}
+
+	requestedDate := time.Now()
+	if date != 0 {
+		requestedDate = time.Unix(date, 0)
+	}
+	if fetchErr := fetchDetailedWaveBuoyData(client, buoy, historyCountSince(requestedDate), 0); fetchErr != nil {
+		return nil, fetchErr
+	}
+	buoyData, _ := buoy.FindConditionsForDateAndTime(requestedDate)
+
+	var chartURL string
+	var chartErr error
+	switch chartType {
+	case DirectionalSpectraChart:
+		chartURL, chartErr = fetchDirectionalSpectraChart(ctx, client, buoy.StationID, buoyData, SpectraChartOptions{})
+	case SpectraDistributionChart:
+		chartURL, chartErr = fetchSpectraDistributionChart(ctx, client, buoy.StationID, buoyData, SpectraChartOptions{})
+	default:
+		return nil, errors.New("Unknown chart type")
+	}
+	if chartErr != nil {
+		return nil, chartErr
+	}
+	return appendStringField([]byte{}, 1, chartURL), nil
+}
@@ -10,11 +10,12 @@ function initBuoyMap() {
     $.ajax({
         url: '/api/stations',
         type: 'GET'
-    }).done(function(data) {
+    }).done(function(response) {
         var buoyInfoPopup = new google.maps.InfoWindow();
+        var stations = response.data.Stations;
 
-        for (var i = 0; i < data.Stations.length; i++) {
-            var station = data.Stations[i];
+        for (var i = 0; i < stations.length; i++) {
+            var station = stations[i];
 
             // Ignore inactive stations
             if (station.Active === 'n') {
@@ -38,4 +39,30 @@ function initBuoyMap() {
             });
         }
     });
-}
\ No newline at end of file
+}
+
+// Buoys report every half hour or so, so there is no need to check more often
+var conditionsRefreshInterval = 10 * 60 * 1000;
+
+function refreshBuoyConditions() {
+    var conditions = $('#conditions');
+    var refreshURL = conditions.data('refresh');
+    if (!refreshURL) {
+        return;
+    }
+
+    $.ajax({
+        url: refreshURL,
+        type: 'GET'
+    }).done(function(response) {
+        conditions.html(response);
+    }).always(function() {
+        setTimeout(refreshBuoyConditions, conditionsRefreshInterval);
+    });
+}
+
+$(function() {
+    if ($('#conditions').data('refresh')) {
+        setTimeout(refreshBuoyConditions, conditionsRefreshInterval);
+    }
+});
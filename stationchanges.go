@@ -0,0 +1,378 @@
+package buoyfinder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/log"
+	"google.golang.org/appengine/taskqueue"
+)
+
+const stationChangeKind = "StationChange"
+const stationSnapshotKind = "StationSnapshot"
+const stationSnapshotKey = "ndbc"
+
+const (
+	StationChangeAdded     = "added"
+	StationChangeRemoved   = "removed"
+	StationChangeRelocated = "relocated"
+)
+
+// The station list rounds positions to a few decimals, so small shifts are
+// just noise. A station that moves further than this was redeployed.
+const stationRelocatedKM = 1.0
+
+// A refresh that loses more than half the stations is a broken list rather
+// than half the network being retired, so it is not diffed
+const minStationSnapshotFraction = 0.5
+
+const maxStationChanges = 1000
+
+// Where the changes are sent as they are found, set in app.yaml.
+// STATION_CHANGES_WEBHOOKS is a comma separated list of https urls that are
+// posted the feed, and STATION_CHANGES_TOPIC a Pub/Sub topic in this project.
+const stationChangesWebhooksEnv = "STATION_CHANGES_WEBHOOKS"
+const stationChangesTopicEnv = "STATION_CHANGES_TOPIC"
+const pubsubTargetPrefix = "pubsub:"
+
+// A station that appeared in, disappeared from, or moved in the NDBC station
+// list. The previous position is only set on relocations.
+type StationChange struct {
+	StationID         string
+	Change            string
+	Date              time.Time
+	Name              string  `datastore:",noindex"`
+	Latitude          float64 `datastore:",noindex"`
+	Longitude         float64 `datastore:",noindex"`
+	PreviousLatitude  float64 `datastore:",noindex" json:",omitempty"`
+	PreviousLongitude float64 `datastore:",noindex" json:",omitempty"`
+}
+
+// The changes after Since. Until is when the list was last checked, which is
+// the since to pass next time.
+type StationChangeFeed struct {
+	Since   time.Time
+	Until   time.Time
+	Changes []StationChange
+}
+
+// The station list as of the last check, kept as json since datastore
+// cannot index a map
+type stationSnapshot struct {
+	Stations []byte `datastore:",noindex"`
+	Updated  time.Time
+}
+
+type snapshotStation struct {
+	Name      string
+	Latitude  float64
+	Longitude float64
+}
+
+func stationChangesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+
+	now := time.Now().Truncate(time.Second)
+	feed := StationChangeFeed{Since: now.AddDate(0, 0, -7), Until: now}
+	if rawSince := r.URL.Query().Get("since"); rawSince != "" {
+		since, sinceErr := strconv.ParseInt(rawSince, 10, 64)
+		if sinceErr != nil {
+			writeErrorResponse(w, r, http.StatusBadRequest, errors.New("Invalid since "+rawSince))
+			return
+		}
+		feed.Since = time.Unix(since, 0)
+	}
+
+	snapshot := stationSnapshot{}
+	if getErr := datastore.Get(ctx, datastore.NewKey(ctx, stationSnapshotKind, stationSnapshotKey, 0, nil), &snapshot); getErr == nil {
+		feed.Until = snapshot.Updated
+	} else if getErr != datastore.ErrNoSuchEntity {
+		writeErrorResponse(w, r, http.StatusInternalServerError, getErr)
+		return
+	}
+
+	feed.Changes = []StationChange{}
+	query := datastore.NewQuery(stationChangeKind).Filter("Date >", feed.Since).Order("Date").Limit(maxStationChanges + 1)
+	if _, changesErr := query.GetAll(ctx, &feed.Changes); changesErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, changesErr)
+		return
+	}
+	// Every change from a check has the same date, so a page ends on a whole
+	// check and Until points at it for the next page to start after
+	if len(feed.Changes) > maxStationChanges {
+		last := feed.Changes[maxStationChanges].Date
+		end := maxStationChanges
+		for end > 0 && feed.Changes[end-1].Date.Equal(last) {
+			end--
+		}
+
+		if end > 0 {
+			feed.Changes = feed.Changes[:end]
+		} else {
+			// One check changed more than a page, which is sent whole
+			feed.Changes = []StationChange{}
+			if _, changesErr := datastore.NewQuery(stationChangeKind).Filter("Date =", last).GetAll(ctx, &feed.Changes); changesErr != nil {
+				writeErrorResponse(w, r, http.StatusInternalServerError, changesErr)
+				return
+			}
+		}
+		feed.Until = feed.Changes[len(feed.Changes)-1].Date
+	}
+
+	writeDataResponse(w, r, nil, &feed)
+}
+
+// Run by cron, compares the station list to the one seen last time, saves
+// what changed, and queues the changes for every configured target
+func checkStationChangesHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 60*time.Second)
+	client := newFetchClient(ctx)
+
+	stations, stationsError := fetchStations(ctx, client)
+	if stationsError != nil {
+		http.Error(w, stationsError.Error(), http.StatusInternalServerError)
+		return
+	}
+	current := newSnapshotStations(stations)
+
+	snapshotKey := datastore.NewKey(ctx, stationSnapshotKind, stationSnapshotKey, 0, nil)
+	snapshot := stationSnapshot{}
+	previous := map[string]snapshotStation{}
+	getErr := datastore.Get(ctx, snapshotKey, &snapshot)
+	if getErr == nil {
+		if decodeErr := json.Unmarshal(snapshot.Stations, &previous); decodeErr != nil {
+			http.Error(w, decodeErr.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else if getErr != datastore.ErrNoSuchEntity {
+		http.Error(w, getErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if float64(len(current)) < float64(len(previous))*minStationSnapshotFraction {
+		log.Warningf(ctx, "The station list dropped from %d to %d stations, skipping the check", len(previous), len(current))
+		return
+	}
+
+	// Checks are dated to the second, the precision of ?since=, so passing
+	// Until back as since starts after the check instead of repeating it
+	now := time.Now().Truncate(time.Second)
+	changes := []StationChange{}
+	// The first check has nothing to compare to, so it only saves the list
+	if getErr == nil {
+		changes = diffStationSnapshots(previous, current, now)
+	}
+
+	if len(changes) > 0 {
+		keys := make([]*datastore.Key, len(changes))
+		for index := range changes {
+			keys[index] = datastore.NewIncompleteKey(ctx, stationChangeKind, nil)
+		}
+		if putErr := putMultiInBatches(ctx, keys, changes); putErr != nil {
+			http.Error(w, putErr.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	encoded, encodeErr := json.Marshal(current)
+	if encodeErr != nil {
+		http.Error(w, encodeErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, putErr := datastore.Put(ctx, snapshotKey, &stationSnapshot{Stations: encoded, Updated: now}); putErr != nil {
+		http.Error(w, putErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if len(changes) > 0 {
+		queueStationChanges(ctx, StationChangeFeed{Since: snapshot.Updated, Until: now, Changes: changes})
+	}
+	log.Infof(ctx, "Station change check found %d changes in %d stations", len(changes), len(current))
+}
+
+func newSnapshotStations(stations *surfnerd.BuoyStations) map[string]snapshotStation {
+	snapshot := map[string]snapshotStation{}
+	for _, station := range stations.Stations {
+		if station.Location == nil {
+			continue
+		}
+		snapshot[strings.ToUpper(station.StationID)] = snapshotStation{
+			Name:      station.LocationName,
+			Latitude:  station.Latitude,
+			Longitude: station.Longitude,
+		}
+	}
+	return snapshot
+}
+
+func diffStationSnapshots(previous, current map[string]snapshotStation, now time.Time) []StationChange {
+	changes := []StationChange{}
+	for stationID, station := range current {
+		change := StationChange{
+			StationID: stationID,
+			Date:      now,
+			Name:      station.Name,
+			Latitude:  station.Latitude,
+			Longitude: station.Longitude,
+		}
+
+		before, existed := previous[stationID]
+		switch {
+		case !existed:
+			change.Change = StationChangeAdded
+		case distanceBetween(surfnerd.NewLocationForLatLong(before.Latitude, before.Longitude), surfnerd.NewLocationForLatLong(station.Latitude, station.Longitude)) > stationRelocatedKM:
+			change.Change = StationChangeRelocated
+			change.PreviousLatitude = before.Latitude
+			change.PreviousLongitude = before.Longitude
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	for stationID, station := range previous {
+		if _, exists := current[stationID]; exists {
+			continue
+		}
+		changes = append(changes, StationChange{
+			StationID: stationID,
+			Change:    StationChangeRemoved,
+			Date:      now,
+			Name:      station.Name,
+			Latitude:  station.Latitude,
+			Longitude: station.Longitude,
+		})
+	}
+	return changes
+}
+
+// The configured webhooks and topic, with topics marked by their prefix
+func stationChangeTargets() []string {
+	targets := []string{}
+	for _, webhook := range strings.Split(os.Getenv(stationChangesWebhooksEnv), ",") {
+		if webhook = strings.TrimSpace(webhook); strings.HasPrefix(webhook, "https://") {
+			targets = append(targets, webhook)
+		}
+	}
+	if topic := strings.TrimSpace(os.Getenv(stationChangesTopicEnv)); topic != "" {
+		targets = append(targets, pubsubTargetPrefix+topic)
+	}
+	return targets
+}
+
+// Each target gets its own task, so the queue retries a target that is down
+// without sending the changes to the others again
+func queueStationChanges(ctx context.Context, feed StationChangeFeed) {
+	targets := stationChangeTargets()
+	if len(targets) == 0 {
+		return
+	}
+
+	encoded, encodeErr := json.Marshal(feed)
+	if encodeErr != nil {
+		log.Errorf(ctx, "Could not encode the station changes: %v", encodeErr)
+		return
+	}
+
+	for _, target := range targets {
+		task := taskqueue.NewPOSTTask("/tasks/stationchanges/deliver", url.Values{
+			"target":  {target},
+			"changes": {string(encoded)},
+		})
+		if _, queueErr := taskqueue.Add(ctx, task, ""); queueErr != nil {
+			log.Errorf(ctx, "Could not queue the station changes for %s: %v", target, queueErr)
+		}
+	}
+}
+
+// Run by the task queue, which retries the delivery until it succeeds
+func deliverStationChangesHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	target := r.FormValue("target")
+	changes := []byte(r.FormValue("changes"))
+	if !containsString(stationChangeTargets(), target) {
+		// The target was taken out of app.yaml since the task was queued
+		log.Warningf(ctx, "Dropping the station changes for %s, which is no longer configured", target)
+		return
+	}
+
+	var deliverErr error
+	if strings.HasPrefix(target, pubsubTargetPrefix) {
+		deliverErr = publishStationChanges(ctx, client, strings.TrimPrefix(target, pubsubTargetPrefix), changes)
+	} else {
+		deliverErr = postStationChanges(client, target, changes)
+	}
+	if deliverErr != nil {
+		log.Errorf(ctx, "Could not deliver the station changes to %s: %v", target, deliverErr)
+		http.Error(w, deliverErr.Error(), http.StatusInternalServerError)
+	}
+}
+
+func postStationChanges(client *http.Client, webhookURL string, changes []byte) error {
+	resp, postErr := client.Post(webhookURL, "application/json", bytes.NewReader(changes))
+	if postErr != nil {
+		return postErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return errors.New("The webhook did not accept the station changes: " + resp.Status)
+	}
+	return nil
+}
+
+// Publishes through the Pub/Sub REST api as the app's service account
+func publishStationChanges(ctx context.Context, client *http.Client, topic string, changes []byte) error {
+	token, _, tokenErr := appengine.AccessToken(ctx, "https://www.googleapis.com/auth/pubsub")
+	if tokenErr != nil {
+		return tokenErr
+	}
+
+	message, encodeErr := json.Marshal(map[string]interface{}{
+		"messages": []map[string]interface{}{
+			{
+				"data":       base64.StdEncoding.EncodeToString(changes),
+				"attributes": map[string]string{"type": "stationchanges"},
+			},
+		},
+	})
+	if encodeErr != nil {
+		return encodeErr
+	}
+
+	publishURL := fmt.Sprintf("https://pubsub.googleapis.com/v1/projects/%s/topics/%s:publish", appengine.AppID(ctx), url.PathEscape(topic))
+	req, reqErr := http.NewRequest("POST", publishURL, bytes.NewReader(message))
+	if reqErr != nil {
+		return reqErr
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, publishErr := client.Do(req)
+	if publishErr != nil {
+		return publishErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("Pub/Sub did not accept the station changes: " + resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package buoyfinder
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine/memcache"
+)
+
+const stationPageURL = "http://www.ndbc.noaa.gov/station_page.php?station="
+const realtimeDataURL = "http://www.ndbc.noaa.gov/data/realtime2/"
+
+// Station hardware rarely changes, so the scraped pages are kept much longer
+// than the station list itself
+const stationMetadataCacheExpiration = 24 * time.Hour
+
+// The realtime products a station can publish along with the file extension
+// NDBC uses for them
+var stationProducts = []struct {
+	Name      string
+	Extension string
+}{
+	{"stdmet", "txt"},
+	{"spectral_summary", "spec"},
+	{"spectra", "data_spec"},
+	{"spectral_direction", "swdir"},
+	{"cwind", "cwind"},
+	{"ocean", "ocean"},
+	{"dart", "dart"},
+}
+
+var stationPageFieldRegex = regexp.MustCompile(`<b>([A-Za-z ]+):</b>\s*([^<]+)<`)
+var stationHullRegex = regexp.MustCompile(`(?i)<br\s*/?>\s*([0-9.]+-meter [a-z ]*(?:buoy|hull)|[a-z ]*(?:discus|navy|foam|coastal) buoy|c-man station)`)
+var leadingNumberRegex = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?`)
+
+// Metadata from the NDBC station pages that is not part of the active station
+// list. Heights are in meters, relative to the reference the station page
+// states for them.
+type StationMetadata struct {
+	WaterDepth         float64  `json:",omitempty"`
+	HullType           string   `json:",omitempty"`
+	Owner              string   `json:",omitempty"`
+	Program            string   `json:",omitempty"`
+	AirTempHeight      float64  `json:",omitempty"`
+	AnemometerHeight   float64  `json:",omitempty"`
+	BarometerElevation float64  `json:",omitempty"`
+	SeaTempDepth       float64  `json:",omitempty"`
+	Products           []string `json:",omitempty"`
+}
+
+type StationInfo struct {
+	*surfnerd.Buoy
+	Class        string
+	Capabilities []string
+	Metadata     StationMetadata
+}
+
+func fetchStationMetadata(ctx context.Context, client *http.Client, buoy *surfnerd.Buoy) (StationMetadata, error) {
+	cacheKey := "stationmetadata:" + buoy.StationID
+	metadata := StationMetadata{}
+	if _, cacheErr := memcache.Gob.Get(ctx, cacheKey, &metadata); cacheErr == nil {
+		return metadata, nil
+	}
+
+	pageResponse, pageError := client.Get(stationPageURL + strings.ToLower(buoy.StationID))
+	if pageError != nil {
+		return metadata, pageError
+	}
+	defer pageResponse.Body.Close()
+
+	// The station page is html, so it is only checked for size
+	pageContents, readErr := readUpstreamBody(pageResponse, maxNDBCResponseSize)
+	if readErr != nil {
+		return metadata, readErr
+	}
+	metadata = parseStationPage(string(pageContents))
+	metadata.Owner = buoy.Owner
+	metadata.Program = buoy.PGM
+	metadata.Products = fetchStationProducts(client, buoy.StationID)
+
+	memcache.Gob.Set(ctx, &memcache.Item{
+		Key:        cacheKey,
+		Object:     metadata,
+		Expiration: stationMetadataCacheExpiration,
+	})
+
+	return metadata, nil
+}
+
+func parseStationPage(page string) StationMetadata {
+	metadata := StationMetadata{}
+
+	for _, match := range stationPageFieldRegex.FindAllStringSubmatch(page, -1) {
+		value := parseLeadingNumber(match[2])
+		switch strings.ToLower(strings.TrimSpace(match[1])) {
+		case "water depth":
+			metadata.WaterDepth = value
+		case "air temp height":
+			metadata.AirTempHeight = value
+		case "anemometer height":
+			metadata.AnemometerHeight = value
+		case "barometer elevation":
+			metadata.BarometerElevation = value
+		case "sea temp depth":
+			metadata.SeaTempDepth = value
+		}
+	}
+
+	if hullMatch := stationHullRegex.FindStringSubmatch(page); hullMatch != nil {
+		metadata.HullType = strings.TrimSpace(hullMatch[1])
+	}
+
+	return metadata
+}
+
+func parseLeadingNumber(raw string) float64 {
+	value, _ := strconv.ParseFloat(leadingNumberRegex.FindString(strings.TrimSpace(raw)), 64)
+	return value
+}
+
+// Checks which of the realtime files exist for the station. The requests are
+// only HEADs so this is cheap even though there is one per product.
+func fetchStationProducts(client *http.Client, stationID string) []string {
+	var wg sync.WaitGroup
+	available := make([]bool, len(stationProducts))
+
+	for index, product := range stationProducts {
+		wg.Add(1)
+		go func(index int, extension string) {
+			defer wg.Done()
+			productResponse, productError := client.Head(realtimeDataURL + strings.ToUpper(stationID) + "." + extension)
+			if productError != nil {
+				return
+			}
+			productResponse.Body.Close()
+			available[index] = productResponse.StatusCode == http.StatusOK
+		}(index, product.Extension)
+	}
+	wg.Wait()
+
+	products := []string{}
+	for index, product := range stationProducts {
+		if available[index] {
+			products = append(products, product.Name)
+		}
+	}
+	return products
+}
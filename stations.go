@@ -0,0 +1,187 @@
+package buoyfinder
+
+import (
+	"encoding/xml"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mpiannucci/buoyfinder/geo"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine/memcache"
+)
+
+// NDBC regenerates the active station list a few times a day so there is no
+// reason to download it on every request
+const stationsCacheKey = "stations"
+const stationsCacheExpiration = time.Hour
+
+func fetchStations(ctx context.Context, client *http.Client) (*surfnerd.BuoyStations, error) {
+	stations := &surfnerd.BuoyStations{}
+	if _, cacheErr := memcache.Gob.Get(ctx, stationsCacheKey, stations); cacheErr == nil {
+		return stations, nil
+	}
+
+	stationsResponse, stationsError := client.Get(surfnerd.ActiveBuoysURL)
+	if stationsError != nil {
+		return nil, stationsError
+	}
+	defer stationsResponse.Body.Close()
+
+	stationsContents, readErr := readNDBCBody(stationsResponse)
+	if readErr != nil {
+		return nil, readErr
+	}
+	if parseError := xml.Unmarshal(stationsContents, stations); parseError != nil {
+		return nil, parseError
+	}
+
+	memcache.Gob.Set(ctx, &memcache.Item{
+		Key:        stationsCacheKey,
+		Object:     stations,
+		Expiration: stationsCacheExpiration,
+	})
+
+	return stations, nil
+}
+
+// Controls how the closest buoy to a location is picked
+type ClosestBuoyOptions struct {
+	// Station ids that should never be picked
+	Exclude []string
+	// Skip stations whose latest observation is older than this. Zero turns
+	// the check off.
+	MaxAge time.Duration
+	// How distances to the stations are measured
+	DistanceAlgorithm geo.Algorithm
+	// Weather lookups can also pick C-MAN and other fixed stations, which
+	// have wind and pressure but no wave sensor
+	Weather bool
+	// Only pick stations that are publishing both spectra files, since the
+	// wave capability is claimed by buoys whose spectra have been down for
+	// months
+	Spectra bool
+}
+
+// How many of the nearest stations are checked for fresh data before giving
+// up, since each check is another request to NDBC
+const maxClosestBuoyCandidates = 10
+
+// Far fewer stations publish spectra, so spectra lookups walk further out
+const maxSpectraBuoyCandidates = 25
+
+// The spectra are posted about hourly, and a spectra lookup would otherwise
+// make two requests for every candidate on every lookup
+const spectraDateCacheExpiration = 10 * time.Minute
+
+func parseClosestBuoyOptions(r *http.Request) ClosestBuoyOptions {
+	options := ClosestBuoyOptions{
+		MaxAge:            stationReportingThreshold,
+		DistanceAlgorithm: geo.ParseAlgorithm(r.URL.Query().Get("distance")),
+	}
+
+	if rawMaxAge := r.URL.Query().Get("maxage"); rawMaxAge != "" {
+		if maxAgeHours, maxAgeErr := strconv.ParseFloat(rawMaxAge, 64); maxAgeErr == nil && maxAgeHours >= 0 {
+			options.MaxAge = time.Duration(maxAgeHours * float64(time.Hour))
+		}
+	}
+
+	options.Spectra, _ = strconv.ParseBool(r.URL.Query().Get("spectra"))
+
+	for _, stationID := range strings.Split(r.URL.Query().Get("exclude"), ",") {
+		if stationID = strings.TrimSpace(stationID); stationID != "" {
+			options.Exclude = append(options.Exclude, stationID)
+		}
+	}
+
+	return options
+}
+
+// Whether the station has reported within the max age. Spectra lookups check
+// the spectra files instead, which are only posted alongside a fresh
+// observation.
+func isFreshStation(ctx context.Context, client *http.Client, stationID string, options ClosestBuoyOptions) bool {
+	if !options.Spectra {
+		lastObservation, lastObservationErr := fetchLatestObservationDate(client, stationID)
+		return lastObservationErr == nil && time.Since(lastObservation) <= options.MaxAge
+	}
+
+	// Providers only report the wave summary
+	if provider, _ := stationProvider(stationID); provider != nil {
+		return false
+	}
+	lastSpectra := fetchLatestSpectraDate(ctx, client, stationID)
+	return !lastSpectra.IsZero() && (options.MaxAge == 0 || time.Since(lastSpectra) <= options.MaxAge)
+}
+
+// When the station last posted both spectra files, which is the older of the
+// two dates, or zero when it is not publishing them
+func fetchLatestSpectraDate(ctx context.Context, client *http.Client, stationID string) time.Time {
+	cacheKey := "spectradate:" + strings.ToUpper(stationID)
+	lastSpectra := time.Time{}
+	if _, cacheErr := memcache.Gob.Get(ctx, cacheKey, &lastSpectra); cacheErr == nil {
+		return lastSpectra
+	}
+
+	for _, product := range []string{"spectra", "spectral_direction"} {
+		productDate, productErr := fetchLatestProductDate(client, stationID, product)
+		if productErr != nil {
+			lastSpectra = time.Time{}
+			break
+		}
+		if lastSpectra.IsZero() || productDate.Before(lastSpectra) {
+			lastSpectra = productDate
+		}
+	}
+
+	memcache.Gob.Set(ctx, &memcache.Item{
+		Key:        cacheKey,
+		Object:     lastSpectra,
+		Expiration: spectraDateCacheExpiration,
+	})
+	return lastSpectra
+}
+
+func (self ClosestBuoyOptions) isExcluded(stationID string) bool {
+	for _, excluded := range self.Exclude {
+		if strings.EqualFold(excluded, stationID) {
+			return true
+		}
+	}
+	return false
+}
+
+// All of the active stations with the capability, ordered from closest to
+// furthest from the location
+func sortedActiveStations(stations *surfnerd.BuoyStations, location surfnerd.Location, algorithm geo.Algorithm, capability string) []*surfnerd.Buoy {
+	buoys := []*surfnerd.Buoy{}
+	distances := map[*surfnerd.Buoy]float64{}
+	for index := range stations.Stations {
+		buoy := &stations.Stations[index]
+		if buoy.Active == "n" || buoy.Location == nil || !containsString(stationCapabilities(*buoy), capability) {
+			continue
+		}
+		buoys = append(buoys, buoy)
+		distances[buoy] = distanceWith(algorithm, location, *buoy.Location)
+	}
+
+	// Buoys on the same water come first, so a lake location does not get a
+	// buoy on the next lake over, or an ocean one, just for being closer
+	water := greatLakeAt(location)
+	sameWater := map[*surfnerd.Buoy]bool{}
+	for _, buoy := range buoys {
+		sameWater[buoy] = greatLakeAt(*buoy.Location) == water
+	}
+
+	sort.Slice(buoys, func(i, j int) bool {
+		if sameWater[buoys[i]] != sameWater[buoys[j]] {
+			return sameWater[buoys[i]]
+		}
+		return distances[buoys[i]] < distances[buoys[j]]
+	})
+
+	return buoys
+}
@@ -0,0 +1,171 @@
+package buoyfinder
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+// A station is considered to be reporting if its latest observation is newer
+// than this. NDBC stations report hourly, with up to an hour of delay on the
+// realtime files.
+const stationReportingThreshold = 3 * time.Hour
+
+// Only the start of each realtime file is needed since the newest observation
+// is always the first row
+const statusReadLimit = 4096
+
+const (
+	StationHealthy  = "healthy"
+	StationDegraded = "degraded"
+	StationOffline  = "offline"
+)
+
+var statusProducts = []string{"stdmet", "spectra", "cwind"}
+
+type ProductStatus struct {
+	Product         string
+	Available       bool
+	LastObservation time.Time
+	AgeMinutes      float64
+}
+
+type StationStatus struct {
+	BuoyStationID   string
+	Reporting       bool
+	Health          string
+	LastObservation time.Time
+	AgeMinutes      float64
+	Products        []ProductStatus
+}
+
+func stationStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := vars["station"]
+
+	status := fetchStationStatus(client, stationID)
+
+	writeDataResponse(w, r, client, &status)
+}
+
+func fetchStationStatus(client *http.Client, stationID string) StationStatus {
+	var wg sync.WaitGroup
+	now := time.Now()
+	products := make([]ProductStatus, len(statusProducts))
+
+	for index, product := range statusProducts {
+		wg.Add(1)
+		go func(index int, product string) {
+			defer wg.Done()
+			products[index] = ProductStatus{Product: product}
+			lastObservation, fetchErr := fetchLatestProductDate(client, stationID, product)
+			if fetchErr != nil {
+				return
+			}
+			products[index].Available = true
+			products[index].LastObservation = lastObservation
+			products[index].AgeMinutes = now.Sub(lastObservation).Minutes()
+		}(index, product)
+	}
+	wg.Wait()
+
+	status := StationStatus{
+		BuoyStationID: stationID,
+		Products:      products,
+	}
+
+	availableCount, reportingCount := 0, 0
+	for _, product := range products {
+		if !product.Available {
+			continue
+		}
+		availableCount++
+		if product.LastObservation.After(status.LastObservation) {
+			status.LastObservation = product.LastObservation
+		}
+		if now.Sub(product.LastObservation) <= stationReportingThreshold {
+			reportingCount++
+		}
+	}
+
+	if availableCount > 0 {
+		status.AgeMinutes = now.Sub(status.LastObservation).Minutes()
+	}
+	status.Reporting = reportingCount > 0
+
+	switch {
+	case reportingCount == 0:
+		status.Health = StationOffline
+	case reportingCount < availableCount:
+		status.Health = StationDegraded
+	default:
+		status.Health = StationHealthy
+	}
+
+	return status
+}
+
+// Reads the date of the newest row of one of the station's realtime files
+func fetchLatestProductDate(client *http.Client, stationID, product string) (time.Time, error) {
+	productResponse, productError := client.Get(realtimeProductURL(stationID, product))
+	if productError != nil {
+		return time.Time{}, productError
+	}
+	defer productResponse.Body.Close()
+
+	if productResponse.StatusCode != http.StatusOK {
+		return time.Time{}, errors.New("The station does not publish " + product + " data")
+	}
+
+	scanner := bufio.NewScanner(io.LimitReader(productResponse.Body, statusReadLimit))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return parseRealtimeRowDate(strings.Fields(line))
+	}
+
+	return time.Time{}, errors.New("No " + product + " observations found")
+}
+
+func realtimeProductURL(stationID, product string) string {
+	extension := ""
+	for _, stationProduct := range stationProducts {
+		if stationProduct.Name == product {
+			extension = stationProduct.Extension
+		}
+	}
+	return realtimeDataURL + strings.ToUpper(stationID) + "." + extension
+}
+
+// Every realtime product starts its rows with YYYY MM DD hh mm in UTC
+func parseRealtimeRowDate(fields []string) (time.Time, error) {
+	if len(fields) < 5 {
+		return time.Time{}, errors.New("Invalid observation row")
+	}
+
+	parts := make([]int, 5)
+	for index := range parts {
+		value, parseErr := strconv.Atoi(fields[index])
+		if parseErr != nil {
+			return time.Time{}, parseErr
+		}
+		parts[index] = value
+	}
+
+	return time.Date(parts[0], time.Month(parts[1]), parts[2], parts[3], parts[4], 0, 0, time.UTC), nil
+}
@@ -0,0 +1,132 @@
+package buoyfinder
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+const summaryChartHours = 48
+
+// A single shareable image with the wave height history across the top and the
+// current spectra side by side beneath it
+func summaryChartHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+	smoothing := parseSpectraSmoothing(r)
+	spectraOptions := parseSpectraChartOptions(r)
+
+	cacheKey := "summary:" + stationID + ":" + parsePalette(r) + ":" + strconv.Itoa(smoothing) + ":" + strconv.FormatBool(spectraOptions.LogScale) + ":" + strconv.FormatBool(spectraOptions.Normalize) + ".png"
+	writeCachedImage(ctx, w, r, cacheKey, "image/png", func() ([]byte, int, error) {
+		return renderSummaryChart(ctx, client, stationID, smoothing, spectraOptions)
+	})
+}
+
+// Renders the summary chart as a png, along with the status to answer with
+// when it fails
+func renderSummaryChart(ctx context.Context, client *http.Client, stationID string, smoothing int, spectraOptions SpectraChartOptions) ([]byte, int, error) {
+	history, fetchErr := fetchStandardObservationsSince(client, stationID, time.Now().Add(-summaryChartHours*time.Hour))
+	if fetchErr != nil {
+		return nil, http.StatusBadGateway, fetchErr
+	}
+	for index := range history {
+		history[index].ChangeUnits(surfnerd.English)
+	}
+
+	spectraBuoy := &surfnerd.Buoy{StationID: stationID}
+	if fetchErr := fetchDetailedWaveBuoyData(client, spectraBuoy, 1, smoothing); fetchErr != nil {
+		return nil, waveFetchErrorStatus(fetchErr, http.StatusBadGateway), fetchErr
+	}
+	if len(spectraBuoy.BuoyData) == 0 {
+		return nil, http.StatusNotFound, errors.New("Station " + stationID + " has no wave spectra")
+	}
+
+	heightOptions, heightErr := waveHeightChartOptions(stationID, history, spectraOptions.Gradient, nil)
+	if heightErr != nil {
+		return nil, http.StatusNotFound, heightErr
+	}
+
+	charts, exportErr := exportChartImages(ctx, client, []string{
+		heightOptions,
+		spectraDistributionChartOptions(stationID, spectraBuoy.BuoyData[0], spectraOptions),
+		directionalSpectraChartOptions(stationID, spectraBuoy.BuoyData[0], spectraOptions),
+	})
+	if exportErr != nil {
+		return nil, http.StatusBadGateway, exportErr
+	}
+
+	summary := bytes.Buffer{}
+	if encodeErr := png.Encode(&summary, composeSummaryChart(charts[0], charts[1], charts[2])); encodeErr != nil {
+		return nil, http.StatusInternalServerError, encodeErr
+	}
+	return summary.Bytes(), http.StatusOK, nil
+}
+
+// Renders each of the charts as a png at the same time
+func exportChartImages(ctx context.Context, client *http.Client, options []string) ([]image.Image, error) {
+	images := make([]image.Image, len(options))
+	errs := make([]error, len(options))
+
+	wg := sync.WaitGroup{}
+	for index := range options {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+
+			exported, exportErr := exportChart(ctx, client, Chart{Options: options[index]}, "image/png")
+			if exportErr != nil {
+				errs[index] = exportErr
+				return
+			}
+			images[index], errs[index] = png.Decode(bytes.NewReader(exported))
+		}(index)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return images, nil
+}
+
+func composeSummaryChart(top, left, right image.Image) *image.RGBA {
+	topSize := top.Bounds().Size()
+	leftSize := left.Bounds().Size()
+	rightSize := right.Bounds().Size()
+
+	width := topSize.X
+	if leftSize.X+rightSize.X > width {
+		width = leftSize.X + rightSize.X
+	}
+	height := topSize.Y + leftSize.Y
+	if rightSize.Y > leftSize.Y {
+		height = topSize.Y + rightSize.Y
+	}
+
+	summary := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(summary, summary.Bounds(), &image.Uniform{color.White}, image.ZP, draw.Src)
+	draw.Draw(summary, image.Rect(0, 0, topSize.X, topSize.Y), top, top.Bounds().Min, draw.Over)
+	draw.Draw(summary, image.Rect(0, topSize.Y, leftSize.X, topSize.Y+leftSize.Y), left, left.Bounds().Min, draw.Over)
+	draw.Draw(summary, image.Rect(leftSize.X, topSize.Y, leftSize.X+rightSize.X, topSize.Y+rightSize.Y), right, right.Bounds().Min, draw.Over)
+
+	return summary
+}
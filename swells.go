@@ -0,0 +1,131 @@
+package buoyfinder
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mpiannucci/surfnerd"
+)
+
+const (
+	SwellSortEnergy = "energy"
+	SwellSortPeriod = "period"
+)
+
+// How the swell components should be ordered and which are dropped
+type SwellOptions struct {
+	// Either SwellSortEnergy or SwellSortPeriod, strongest or longest first.
+	// Empty keeps the order the components were partitioned in.
+	Sort string
+	// Components whose peak energy is below this are dropped
+	MinEnergy float64
+	// Components coming from outside the window are dropped
+	Window *SwellWindow
+}
+
+// A range of directions, clockwise from Start to End, like the directions a
+// break is open to. Windows can wrap past north, like 300 to 45.
+type SwellWindow struct {
+	Start float64
+	End   float64
+	// The combined height of the components inside the window, which is how
+	// much of the swell actually reaches the break
+	WaveHeight float64
+	// How many components were dropped for coming from outside the window
+	Excluded int
+}
+
+func (self SwellWindow) Contains(direction float64) bool {
+	return inDirectionalWindow(direction, self.Start, self.End)
+}
+
+func inDirectionalWindow(direction, start, end float64) bool {
+	if start <= end {
+		return direction >= start && direction <= end
+	}
+	return direction >= start || direction <= end
+}
+
+// Parses a window like 180-250
+func parseSwellWindow(raw string) *SwellWindow {
+	parts := strings.Split(raw, "-")
+	if len(parts) != 2 {
+		return nil
+	}
+	start, startErr := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	end, endErr := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if startErr != nil || endErr != nil || !isValidHeading(start) || !isValidHeading(end) {
+		return nil
+	}
+	return &SwellWindow{Start: start, End: end}
+}
+
+// Fills in how much of the components' height comes from inside the window.
+// Heights add by energy, so the combined height is the root of the summed
+// squares.
+func (self SwellWindow) summarize(components []surfnerd.Swell) *SwellWindow {
+	energy := 0.0
+	for _, swell := range components {
+		if !isValidReading(swell.WaveHeight, missingHeightMarker) {
+			continue
+		}
+		if self.Contains(swell.Direction) {
+			energy += swell.WaveHeight * swell.WaveHeight
+		} else {
+			self.Excluded++
+		}
+	}
+	self.WaveHeight = ToFixedPoint(math.Sqrt(energy), 2)
+	return &self
+}
+
+func parseSwellOptions(r *http.Request) SwellOptions {
+	options := SwellOptions{}
+
+	switch sortBy := r.URL.Query().Get("sortswells"); sortBy {
+	case SwellSortEnergy, SwellSortPeriod:
+		options.Sort = sortBy
+	}
+
+	if minEnergy, minEnergyErr := strconv.ParseFloat(r.URL.Query().Get("minenergy"), 64); minEnergyErr == nil && minEnergy > 0 {
+		options.MinEnergy = minEnergy
+	}
+
+	// ?window=180-250 keeps only the swell from those directions
+	options.Window = parseSwellWindow(r.URL.Query().Get("window"))
+
+	return options
+}
+
+// Returns the filtered and sorted components, leaving the given slice alone
+func (self SwellOptions) apply(components []surfnerd.Swell) []surfnerd.Swell {
+	if self.Sort == "" && self.MinEnergy == 0 && self.Window == nil {
+		return components
+	}
+
+	swells := []surfnerd.Swell{}
+	for _, swell := range components {
+		if self.Window != nil && !self.Window.Contains(swell.Direction) {
+			continue
+		}
+		if swell.MaxEnergy >= self.MinEnergy {
+			swells = append(swells, swell)
+		}
+	}
+
+	switch self.Sort {
+	case SwellSortEnergy:
+		sort.SliceStable(swells, func(i, j int) bool {
+			return swells[i].MaxEnergy > swells[j].MaxEnergy
+		})
+	case SwellSortPeriod:
+		sort.SliceStable(swells, func(i, j int) bool {
+			return swells[i].Period > swells[j].Period
+		})
+	}
+
+	return swells
+}
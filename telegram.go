@@ -0,0 +1,124 @@
+package buoyfinder
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/log"
+)
+
+// The secret token the webhook was registered with, which Telegram sends back
+// on every update
+const telegramSecretKey = "telegram"
+
+const telegramHelp = "/latest 44097 or a spot name for the conditions, /closest 41.3,-71.5 for the closest buoy, /chart 44097 for the summary chart. You can also send a location."
+
+type telegramUpdate struct {
+	Message *struct {
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		Text     string `json:"text"`
+		Location *struct {
+			Latitude  float64 `json:"latitude"`
+			Longitude float64 `json:"longitude"`
+		} `json:"location"`
+	} `json:"message"`
+}
+
+// Answers bot commands by replying to the webhook with the bot api method to
+// call, so the bot never has to call Telegram itself. Charts are sent as links
+// to our own chart endpoints for Telegram to fetch.
+func telegramWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	secretToken, secretErr := fetchSecret(ctx, telegramSecretKey)
+	if secretErr != nil {
+		log.Errorf(ctx, "The Telegram secret token is not set up: %v", secretErr)
+		http.Error(w, "Telegram is not set up", http.StatusServiceUnavailable)
+		return
+	}
+	if subtle.ConstantTimeCompare(secretToken, []byte(r.Header.Get("X-Telegram-Bot-Api-Secret-Token"))) != 1 {
+		http.Error(w, "Invalid secret token", http.StatusForbidden)
+		return
+	}
+
+	update := telegramUpdate{}
+	if decodeErr := json.NewDecoder(r.Body).Decode(&update); decodeErr != nil {
+		http.Error(w, decodeErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Telegram retries anything but a success, so updates that are not
+	// messages are acknowledged and dropped
+	if update.Message == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	reply := map[string]interface{}{
+		"method":  "sendMessage",
+		"chat_id": update.Message.Chat.ID,
+	}
+
+	command, argument := parseTelegramCommand(update.Message.Text)
+	if location := update.Message.Location; location != nil {
+		command = "/closest"
+		argument = strconv.FormatFloat(location.Latitude, 'f', -1, 64) + "," + strconv.FormatFloat(location.Longitude, 'f', -1, 64)
+	}
+
+	if command == "" && argument != "" {
+		command = "/latest"
+	}
+
+	switch command {
+	case "/latest":
+		reply["text"] = replyToTextQuery(ctx, client, argument)
+	case "/closest":
+		reply["text"] = replyToTextQuery(ctx, client, "closest "+argument)
+	case "/chart":
+		stationID := strings.ToUpper(argument)
+		if _, stationErr := fetchBuoyWithID(ctx, client, stationID); stationErr != nil {
+			reply["text"] = "Station " + stationID + " could not be found"
+			break
+		}
+		reply["method"] = "sendPhoto"
+		reply["photo"] = requestBaseURL(r) + "/api/charts/summary/" + stationID + ".png"
+		reply["caption"] = fetchCompactReport(ctx, client, stationID)
+	default:
+		reply["text"] = telegramHelp
+	}
+
+	encoded, encodeErr := json.Marshal(reply)
+	if encodeErr != nil {
+		http.Error(w, encodeErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(encoded)
+}
+
+// Splits "/latest@BuoyFinderBot 44097" into the command and its argument
+func parseTelegramCommand(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return "", text
+	}
+
+	command, argument := text, ""
+	if split := strings.IndexAny(text, " \n"); split >= 0 {
+		command, argument = text[:split], strings.TrimSpace(text[split+1:])
+	}
+	if mention := strings.Index(command, "@"); mention >= 0 {
+		command = command[:mention]
+	}
+	return strings.ToLower(command), argument
+}
@@ -0,0 +1,79 @@
+package buoyfinder
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// How many requests to NDBC and the chart exporter an instance makes at once,
+// set with OUTBOUND_CONCURRENCY in app.yaml. NDBC throttles clients that open
+// too many connections, which during a traffic spike fails every request
+// instead of slowing a few of them down.
+const outboundConcurrencyEnv = "OUTBOUND_CONCURRENCY"
+const defaultOutboundConcurrency = 8
+
+// How long a request waits for a free slot before giving up, unless the
+// handler's context runs out first
+const outboundWaitTimeout = 10 * time.Second
+
+var errOutboundBusy = errors.New("Too many upstream requests are in flight, try again shortly")
+
+var outboundSlots struct {
+	sync.Once
+	slots chan struct{}
+}
+
+func outboundSlotsChannel() chan struct{} {
+	outboundSlots.Do(func() {
+		concurrency, parseErr := strconv.Atoi(os.Getenv(outboundConcurrencyEnv))
+		if parseErr != nil || concurrency < 1 {
+			concurrency = defaultOutboundConcurrency
+		}
+		outboundSlots.slots = make(chan struct{}, concurrency)
+	})
+	return outboundSlots.slots
+}
+
+func acquireOutboundSlot(ctx context.Context) error {
+	// A free slot would otherwise win the select even once the handler has
+	// given up
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	select {
+	case outboundSlotsChannel() <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(outboundWaitTimeout):
+		return errOutboundBusy
+	}
+}
+
+func releaseOutboundSlot() {
+	<-outboundSlotsChannel()
+}
+
+// NDBC and the export servers are the upstreams that throttle. The other
+// providers answer far fewer requests so are left alone.
+func isThrottledHost(host string) bool {
+	host = strings.ToLower(host)
+	if host == "ndbc.noaa.gov" || strings.HasSuffix(host, ".ndbc.noaa.gov") {
+		return true
+	}
+
+	for _, exportURL := range []string{highchartsExportURL, os.Getenv(chartExportURLEnv)} {
+		if parsed, parseErr := url.Parse(exportURL); parseErr == nil && parsed.Hostname() != "" && strings.EqualFold(parsed.Hostname(), host) {
+			return true
+		}
+	}
+	return false
+}
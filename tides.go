@@ -0,0 +1,171 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+const tidePredictionsURL = "https://api.tidesandcurrents.noaa.gov/api/prod/datagetter"
+
+const defaultTideChartHours = 24
+const maxTideChartHours = 72
+
+// Predictions are six minutes apart, which is far more than a chart needs
+// over more than a few days
+const maxSixMinuteTideSpan = 72 * time.Hour
+
+// A predicted tide level in feet above mean lower low water
+type TidePrediction struct {
+	Date   time.Time
+	Height float64
+}
+
+type tidePredictionsResponse struct {
+	Predictions []struct {
+		Time  string `json:"t"`
+		Value string `json:"v"`
+	} `json:"predictions"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Charts the predicted tide over ?hours= centered on now, with a marker at the
+// current level
+func tideChartHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	tideStation := vars["station"]
+	hours := parseChartSpan(r, "hours", defaultTideChartHours, maxTideChartHours)
+
+	now := time.Now()
+	span := time.Duration(hours) * time.Hour / 2
+	if wantsVegaSpec(r) {
+		writeVegaSpec(w, r, func() (vegaChart, error) {
+			predictions, fetchErr := fetchTidePredictions(client, tideStation, now.Add(-span), now.Add(span))
+			if fetchErr != nil {
+				return vegaChart{}, fetchErr
+			}
+			return tideLines(tideStation, predictions), nil
+		})
+		return
+	}
+
+	cacheKey := "tide:" + tideStation + ":" + strconv.Itoa(hours)
+	writeCachedChart(ctx, w, r, client, cacheKey, vars["format"], func() (Chart, error) {
+		predictions, fetchErr := fetchTidePredictions(client, tideStation, now.Add(-span), now.Add(span))
+		if fetchErr != nil {
+			return Chart{}, fetchErr
+		}
+		options, optionsErr := tideChartOptions(tideStation, now, predictions)
+		if optionsErr != nil {
+			return Chart{}, optionsErr
+		}
+		lines := tideLines(tideStation, predictions)
+		return Chart{Options: options, Lines: &lines}, nil
+	})
+}
+
+func tideLines(tideStation string, predictions []TidePrediction) vegaChart {
+	return vegaChart{
+		Title:  "Tide Station " + tideStation + ": Predicted Tide",
+		Series: []ChartSeries{tideSeries(predictions)},
+	}
+}
+
+func fetchTidePredictions(client *http.Client, tideStation string, begin, end time.Time) ([]TidePrediction, error) {
+	interval := "6"
+	if end.Sub(begin) > maxSixMinuteTideSpan {
+		interval = "h"
+	}
+
+	query := url.Values{}
+	query.Set("product", "predictions")
+	query.Set("station", tideStation)
+	query.Set("begin_date", begin.UTC().Format("20060102 15:04"))
+	query.Set("end_date", end.UTC().Format("20060102 15:04"))
+	query.Set("datum", "MLLW")
+	query.Set("units", "english")
+	query.Set("time_zone", "gmt")
+	query.Set("interval", interval)
+	query.Set("format", "json")
+	query.Set("application", "buoyfinder")
+
+	tideResponse, tideError := client.Get(tidePredictionsURL + "?" + query.Encode())
+	if tideError != nil {
+		return nil, tideError
+	}
+	defer tideResponse.Body.Close()
+
+	response := tidePredictionsResponse{}
+	if decodeErr := json.NewDecoder(tideResponse.Body).Decode(&response); decodeErr != nil {
+		return nil, decodeErr
+	}
+	if response.Error != nil {
+		return nil, errors.New("Could not fetch the tides for station " + tideStation + ": " + response.Error.Message)
+	}
+
+	predictions := []TidePrediction{}
+	for _, rawPrediction := range response.Predictions {
+		date, dateErr := time.Parse("2006-01-02 15:04", rawPrediction.Time)
+		height, heightErr := strconv.ParseFloat(strings.TrimSpace(rawPrediction.Value), 64)
+		if dateErr != nil || heightErr != nil {
+			continue
+		}
+		predictions = append(predictions, TidePrediction{Date: date, Height: height})
+	}
+
+	if len(predictions) == 0 {
+		return nil, errors.New("Station " + tideStation + " has no tide predictions")
+	}
+	return predictions, nil
+}
+
+// The predicted level at the date, between the predictions on either side of
+// it. The predictions must be sorted oldest first.
+func tideLevelAt(predictions []TidePrediction, date time.Time) (float64, bool) {
+	for index := 1; index < len(predictions); index++ {
+		before, after := predictions[index-1], predictions[index]
+		if date.Before(before.Date) || date.After(after.Date) {
+			continue
+		}
+
+		span := after.Date.Sub(before.Date).Seconds()
+		if span <= 0 {
+			return before.Height, true
+		}
+		fraction := date.Sub(before.Date).Seconds() / span
+		return before.Height + (after.Height-before.Height)*fraction, true
+	}
+	return 0, false
+}
+
+func tideSeries(predictions []TidePrediction) ChartSeries {
+	series := ChartSeries{Name: "Tide", XTitle: "Time", YTitle: "Height (ft MLLW)", Points: [][]float64{}}
+	for _, prediction := range predictions {
+		series.Points = append(series.Points, []float64{float64(prediction.Date.Unix() * 1000), ToFixedPoint(prediction.Height, 2)})
+	}
+	return series
+}
+
+func tideChartOptions(tideStation string, now time.Time, predictions []TidePrediction) (string, error) {
+	series := "[{type: 'spline', name: 'Predicted', data: " + highchartsPoints(tideSeries(predictions).Points) + "}"
+	if level, ok := tideLevelAt(predictions, now); ok {
+		series += ", {type: 'scatter', name: 'Now', color: '#d53e4f', marker: {radius: 6, symbol: 'circle'}, data: [[" + strconv.FormatInt(now.Unix()*1000, 10) + "," + strconv.FormatFloat(level, 'f', 2, 64) + "]]}"
+	}
+	series += "]"
+
+	return "{chart: {width: 600}, navigation: {buttonOptions: {enabled: false}}, title: {text: 'Tide Station " + tideStation + ": Predicted Tide', style: {font: '10px Helvetica, sans-serif'}}, legend: {enabled: false}, credits: {enabled: false}, xAxis: {type: 'datetime', labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1, plotLines: [{value: " + strconv.FormatInt(now.Unix()*1000, 10) + ", width: 1, color: '#999999', dashStyle: 'Dash'}]}, yAxis: {labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1, title: {text: 'Height (ft MLLW)'}}, plotOptions: {series: {shadow: false, marker: {enabled: false}}}, series: " + series + "};", nil
+}
@@ -0,0 +1,85 @@
+package buoyfinder
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TimeFormatUnix    = "unix"
+	TimeFormatISO     = "iso"
+	TimeFormatRFC1123 = "rfc1123"
+)
+
+// The ?timefmt= to render times in, or an empty string to keep Go's default
+// RFC 3339 encoding
+func parseTimeFormat(r *http.Request) string {
+	switch format := strings.ToLower(r.URL.Query().Get("timefmt")); format {
+	case TimeFormatUnix, TimeFormatISO, TimeFormatRFC1123:
+		return format
+	}
+	return ""
+}
+
+// Rewrites every time in the encoded JSON in the format. Like the precision
+// rounding, the strings are swapped in place so the field order and
+// formatting are kept. Unix times are epoch seconds, and unset times become
+// null since epoch seconds cannot tell them apart from a real date.
+func formatJSONTimes(encoded []byte, format string) []byte {
+	formatted := bytes.Buffer{}
+	formatted.Grow(len(encoded))
+
+	for index := 0; index < len(encoded); index++ {
+		c := encoded[index]
+		if c != '"' {
+			formatted.WriteByte(c)
+			continue
+		}
+
+		end := index + 1
+		for end < len(encoded) && encoded[end] != '"' {
+			if encoded[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(encoded) {
+			formatted.Write(encoded[index:])
+			break
+		}
+
+		formatted.Write(formatJSONTime(encoded[index:end+1], format))
+		index = end
+	}
+
+	return formatted.Bytes()
+}
+
+// Formats the quoted string if it holds a time, otherwise returns it as is
+func formatJSONTime(quoted []byte, format string) []byte {
+	// The shortest RFC 3339 time, 2006-01-02T15:04:05Z, is 20 characters
+	if len(quoted) < 22 || quoted[5] != '-' || quoted[11] != 'T' {
+		return quoted
+	}
+
+	date, parseErr := time.Parse(time.RFC3339Nano, string(quoted[1:len(quoted)-1]))
+	if parseErr != nil {
+		return quoted
+	}
+
+	switch format {
+	case TimeFormatUnix:
+		if date.IsZero() {
+			return []byte("null")
+		}
+		return []byte(strconv.FormatInt(date.Unix(), 10))
+	case TimeFormatISO:
+		return []byte(strconv.Quote(date.UTC().Format(time.RFC3339)))
+	case TimeFormatRFC1123:
+		return []byte(strconv.Quote(date.UTC().Format(http.TimeFormat)))
+	}
+	return quoted
+}
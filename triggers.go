@@ -0,0 +1,193 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+// Polling triggers only need to reach back past the last poll, which are
+// minutes apart
+const triggerHistoryDays = 3
+
+const defaultTriggerLimit = 50
+
+const (
+	newObservationTrigger   = "new_observation"
+	thresholdCrossedTrigger = "threshold_crossed"
+)
+
+// An event for no-code automation platforms that poll for new items. The id
+// is unique to the trigger, station, and observation so the platforms can tell
+// which events they have already seen.
+type TriggerEvent struct {
+	ID string `json:"id"`
+	SensorState
+	Summary string           `json:"summary"`
+	Link    string           `json:"link"`
+	Meta    TriggerEventMeta `json:"meta"`
+}
+
+// The item metadata IFTTT dedupes and sorts events by
+type TriggerEventMeta struct {
+	ID        string `json:"id"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+func newTriggerEvent(baseURL, trigger string, buoy *ClosestBuoy, units string) TriggerEvent {
+	epoch := buoy.BuoyData.Date.Unix()
+	id := trigger + ":" + buoy.BuoyStationID + ":" + strconv.FormatInt(epoch, 10)
+	return TriggerEvent{
+		ID:          id,
+		SensorState: newSensorState(buoy, units),
+		Summary:     compactReport(buoy),
+		Link:        baseURL + "/buoy/" + buoy.BuoyStationID + "/" + strconv.FormatInt(epoch, 10),
+		Meta:        TriggerEventMeta{ID: id, Timestamp: epoch},
+	}
+}
+
+// The station's metric observations from the last few days, newest first
+func fetchTriggerObservations(client *http.Client, stationID string) ([]surfnerd.BuoyDataItem, error) {
+	observations, fetchErr := fetchStandardObservationsSince(client, stationID, time.Now().AddDate(0, 0, -triggerHistoryDays))
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	for index := range observations {
+		observations[index].ChangeUnits(surfnerd.Metric)
+	}
+	return observations, nil
+}
+
+// Every recent observation, newest first
+func newObservationEvents(client *http.Client, baseURL, stationID, units string, limit int) ([]TriggerEvent, error) {
+	observations, fetchErr := fetchTriggerObservations(client, stationID)
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	events := []TriggerEvent{}
+	for _, item := range observations {
+		if len(events) >= limit {
+			break
+		}
+		events = append(events, newTriggerEvent(baseURL, newObservationTrigger, &ClosestBuoy{BuoyStationID: stationID, BuoyData: item}, units))
+	}
+	return events, nil
+}
+
+// The observations where the conditions went from under the thresholds to over
+// them, newest first. Thresholds are checked the same way alerts check them.
+func thresholdCrossedEvents(client *http.Client, baseURL, stationID, units string, threshold Alert, limit int) ([]TriggerEvent, error) {
+	observations, fetchErr := fetchTriggerObservations(client, stationID)
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	events := []TriggerEvent{}
+	for index := 0; index < len(observations)-1 && len(events) < limit; index++ {
+		buoy := &ClosestBuoy{BuoyStationID: stationID, BuoyData: observations[index]}
+		previous := &ClosestBuoy{BuoyStationID: stationID, BuoyData: observations[index+1]}
+		if threshold.isTriggeredBy(buoy) && !threshold.isTriggeredBy(previous) {
+			events = append(events, newTriggerEvent(baseURL, thresholdCrossedTrigger, buoy, units))
+		}
+	}
+	return events, nil
+}
+
+// The thresholds are in the requested units. Heights are turned into meters
+// to compare against the metric observations.
+func parseTriggerThreshold(height, period, units string) (Alert, error) {
+	threshold := Alert{}
+	if height != "" {
+		minHeight, heightErr := strconv.ParseFloat(height, 64)
+		if heightErr != nil {
+			return threshold, errors.New("The minimum wave height must be a number")
+		}
+		if units == UnitsEnglish {
+			minHeight /= metersToFeet
+		}
+		threshold.MinWaveHeight = minHeight
+	}
+	if period != "" {
+		minPeriod, periodErr := strconv.ParseFloat(period, 64)
+		if periodErr != nil {
+			return threshold, errors.New("The minimum period must be a number")
+		}
+		threshold.MinPeriod = minPeriod
+	}
+	if threshold.MinWaveHeight <= 0 && threshold.MinPeriod <= 0 {
+		return threshold, errors.New("A minimum wave height or period is required")
+	}
+	return threshold, nil
+}
+
+func parseTriggerUnits(units string) string {
+	if units == UnitsEnglish {
+		return UnitsEnglish
+	}
+	return UnitsMetric
+}
+
+// Zapier polls for a bare array of items, newest first
+func writeTriggerEvents(w http.ResponseWriter, events []TriggerEvent) {
+	encoded, encodeErr := json.Marshal(events)
+	if encodeErr != nil {
+		http.Error(w, encodeErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(encoded)
+}
+
+func newObservationTriggerHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+	units := parseTriggerUnits(r.URL.Query().Get("units"))
+	limit := parseChartSpan(r, "limit", defaultTriggerLimit, defaultTriggerLimit)
+
+	events, eventsErr := newObservationEvents(client, requestBaseURL(r), stationID, units, limit)
+	if eventsErr != nil {
+		http.Error(w, eventsErr.Error(), http.StatusBadGateway)
+		return
+	}
+	writeTriggerEvents(w, events)
+}
+
+// Takes the thresholds as ?height= and ?period=
+func thresholdCrossedTriggerHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+	query := r.URL.Query()
+	units := parseTriggerUnits(query.Get("units"))
+	limit := parseChartSpan(r, "limit", defaultTriggerLimit, defaultTriggerLimit)
+
+	threshold, thresholdErr := parseTriggerThreshold(query.Get("height"), query.Get("period"), units)
+	if thresholdErr != nil {
+		http.Error(w, thresholdErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, eventsErr := thresholdCrossedEvents(client, requestBaseURL(r), stationID, units, threshold, limit)
+	if eventsErr != nil {
+		http.Error(w, eventsErr.Error(), http.StatusBadGateway)
+		return
+	}
+	writeTriggerEvents(w, events)
+}
@@ -0,0 +1,171 @@
+package buoyfinder
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/buoyfinder/geo"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+const defaultValidationHours = 48
+
+// Observations from the two stations are paired up when they are at most
+// this far apart
+const validationPairWindow = 30 * time.Minute
+
+// How the readings that can be compared are pulled out of an observation,
+// with the marker that means the reading is missing
+var validationReadings = []struct {
+	Name    string
+	Marker  float64
+	Reading func(surfnerd.BuoyDataItem) float64
+}{
+	{"WaveHeight", missingHeightMarker, func(item surfnerd.BuoyDataItem) float64 { return item.WaveSummary.WaveHeight }},
+	{"DominantPeriod", missingPeriodMarker, func(item surfnerd.BuoyDataItem) float64 { return item.WaveSummary.Period }},
+	{"AveragePeriod", missingPeriodMarker, func(item surfnerd.BuoyDataItem) float64 { return item.AveragePeriod }},
+	{"WindSpeed", missingSpeedMarker, func(item surfnerd.BuoyDataItem) float64 { return item.WindSpeed }},
+	{"WaterTemperature", missingTemperatureMarker, func(item surfnerd.BuoyDataItem) float64 { return item.WaterTemperature }},
+}
+
+// How closely station B tracks station A over the same hours, in metric.
+// Bias is the mean of B minus A.
+type StationComparison struct {
+	StationA   string
+	StationB   string
+	Hours      int
+	DistanceKM float64 `json:",omitempty"`
+	Pairs      int
+	Readings   map[string]ReadingComparison
+}
+
+type ReadingComparison struct {
+	Count       int
+	Bias        float64
+	RMSE        float64
+	Correlation float64
+}
+
+func validateStationsHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationIDs := []string{strings.ToUpper(vars["stationA"]), strings.ToUpper(vars["stationB"])}
+	if stationIDs[0] == stationIDs[1] {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("Pick two different stations to compare"))
+		return
+	}
+	hours := parseChartSpan(r, "hours", defaultValidationHours, maxHistoryHours)
+
+	buoys := make([]*surfnerd.Buoy, len(stationIDs))
+	for index, stationID := range stationIDs {
+		buoy, buoyErr := fetchBuoyWithID(ctx, client, stationID)
+		if buoyErr != nil {
+			writeErrorResponse(w, r, http.StatusNotFound, errors.New("Could not find station "+stationID))
+			return
+		}
+		buoys[index] = buoy
+	}
+
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+	observations := make([][]surfnerd.BuoyDataItem, len(stationIDs))
+	errs := make([]error, len(stationIDs))
+	var wg sync.WaitGroup
+	for index, stationID := range stationIDs {
+		wg.Add(1)
+		go func(index int, stationID string) {
+			defer wg.Done()
+			observations[index], errs[index] = fetchStandardObservationsSince(client, stationID, since)
+		}(index, stationID)
+	}
+	wg.Wait()
+	for _, fetchErr := range errs {
+		if fetchErr != nil {
+			writeErrorResponse(w, r, http.StatusBadGateway, fetchErr)
+			return
+		}
+	}
+
+	comparison := compareStations(observations[0], observations[1])
+	comparison.StationA, comparison.StationB, comparison.Hours = stationIDs[0], stationIDs[1], hours
+	if buoys[0].Location != nil && buoys[1].Location != nil {
+		comparison.DistanceKM = ToFixedPoint(distanceWith(geo.ParseAlgorithm(r.URL.Query().Get("distance")), *buoys[0].Location, *buoys[1].Location), 2)
+	}
+	writeDataResponse(w, r, client, comparison)
+}
+
+// Pairs each of A's observations with B's nearest one in time, then compares
+// every reading both have
+func compareStations(a, b []surfnerd.BuoyDataItem) StationComparison {
+	comparison := StationComparison{Readings: map[string]ReadingComparison{}}
+
+	pairs := [][2]surfnerd.BuoyDataItem{}
+	for _, itemA := range a {
+		var nearest *surfnerd.BuoyDataItem
+		for index := range b {
+			if gap := absDuration(b[index].Date.Sub(itemA.Date)); gap <= validationPairWindow && (nearest == nil || gap < absDuration(nearest.Date.Sub(itemA.Date))) {
+				nearest = &b[index]
+			}
+		}
+		if nearest != nil {
+			pairs = append(pairs, [2]surfnerd.BuoyDataItem{itemA, *nearest})
+		}
+	}
+	comparison.Pairs = len(pairs)
+
+	for _, reading := range validationReadings {
+		valuesA, valuesB := []float64{}, []float64{}
+		for _, pair := range pairs {
+			valueA, valueB := reading.Reading(pair[0]), reading.Reading(pair[1])
+			if isValidReading(valueA, reading.Marker) && isValidReading(valueB, reading.Marker) {
+				valuesA = append(valuesA, valueA)
+				valuesB = append(valuesB, valueB)
+			}
+		}
+		if len(valuesA) > 0 {
+			comparison.Readings[reading.Name] = compareReadings(valuesA, valuesB)
+		}
+	}
+	return comparison
+}
+
+func compareReadings(a, b []float64) ReadingComparison {
+	count := float64(len(a))
+	meanA, meanB, bias, squared := 0.0, 0.0, 0.0, 0.0
+	for index := range a {
+		meanA += a[index]
+		meanB += b[index]
+		bias += b[index] - a[index]
+		squared += (b[index] - a[index]) * (b[index] - a[index])
+	}
+	meanA, meanB = meanA/count, meanB/count
+
+	covariance, varianceA, varianceB := 0.0, 0.0, 0.0
+	for index := range a {
+		covariance += (a[index] - meanA) * (b[index] - meanB)
+		varianceA += (a[index] - meanA) * (a[index] - meanA)
+		varianceB += (b[index] - meanB) * (b[index] - meanB)
+	}
+
+	// A reading that never changed has no meaningful correlation
+	correlation := 0.0
+	if varianceA > 0 && varianceB > 0 {
+		correlation = covariance / math.Sqrt(varianceA*varianceB)
+	}
+
+	return ReadingComparison{
+		Count:       len(a),
+		Bias:        ToFixedPoint(bias/count, 3),
+		RMSE:        ToFixedPoint(math.Sqrt(squared/count), 3),
+		Correlation: ToFixedPoint(correlation, 3),
+	}
+}
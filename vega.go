@@ -0,0 +1,128 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+const vegaLiteSchema = "https://vega.github.io/schema/vega-lite/v5.json"
+
+// A chart to describe as a Vega-Lite spec. The x values of every series are
+// times unless XType says otherwise.
+type vegaChart struct {
+	Title    string
+	Subtitle string
+	Series   []ChartSeries
+	Colors   []string
+	// A Vega-Lite type like quantitative, and the mark to draw the points
+	// with, which default to temporal and line
+	XType   string
+	XDomain []float64
+	Mark    string
+}
+
+// Chart endpoints return a Vega-Lite spec instead of an image with ?format=vega
+// or a .vega extension, so web clients can draw the chart interactively
+func wantsVegaSpec(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "vega" || mux.Vars(r)["format"] == "vega"
+}
+
+func writeVegaSpec(w http.ResponseWriter, r *http.Request, buildChart func() (vegaChart, error)) {
+	chart, chartErr := buildChart()
+	if chartErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, chartErr)
+		return
+	}
+
+	writeVegaLiteJSON(w, r, newVegaLiteSpec(chart))
+}
+
+func writeVegaLiteJSON(w http.ResponseWriter, r *http.Request, vegaSpec map[string]interface{}) {
+	spec, specErr := json.MarshalIndent(vegaSpec, "", "    ")
+	if specErr != nil {
+		writeErrorResponse(w, r, http.StatusInternalServerError, specErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(spec)
+}
+
+func newVegaLiteSpec(chart vegaChart) map[string]interface{} {
+	values := []map[string]interface{}{}
+	names := []string{}
+	xTitle, yTitle := "", ""
+	for _, series := range chart.Series {
+		names = append(names, series.Name)
+		xTitle, yTitle = series.XTitle, series.YTitle
+		for _, point := range series.Points {
+			values = append(values, map[string]interface{}{
+				"series": series.Name,
+				"x":      point[0],
+				"y":      point[1],
+			})
+		}
+	}
+
+	color := map[string]interface{}{
+		"field":  "series",
+		"type":   "nominal",
+		"title":  nil,
+		"scale":  map[string]interface{}{"domain": names},
+		"legend": nil,
+	}
+	if len(chart.Colors) > 0 {
+		color["scale"].(map[string]interface{})["range"] = chart.Colors
+	}
+	if len(chart.Series) > 1 {
+		delete(color, "legend")
+	}
+
+	xType, mark := "temporal", "line"
+	if chart.XType != "" {
+		xType = chart.XType
+	}
+	if chart.Mark != "" {
+		mark = chart.Mark
+	}
+	x := map[string]interface{}{"field": "x", "type": xType, "title": xTitle}
+	if len(chart.XDomain) > 0 {
+		x["scale"] = map[string]interface{}{"domain": chart.XDomain}
+	}
+
+	return map[string]interface{}{
+		"$schema": vegaLiteSchema,
+		"title": map[string]interface{}{
+			"text":     chart.Title,
+			"subtitle": chart.Subtitle,
+		},
+		"width":  "container",
+		"height": 300,
+		"data":   map[string]interface{}{"values": values},
+		"mark":   map[string]interface{}{"type": mark, "tooltip": true},
+		"encoding": map[string]interface{}{
+			"x":     x,
+			"y":     map[string]interface{}{"field": "y", "type": "quantitative", "title": yTitle},
+			"color": color,
+		},
+	}
+}
+
+// Stacks the charts into one spec, each keeping its own title and axes
+func newVegaLiteConcatSpec(title string, charts []vegaChart) map[string]interface{} {
+	views := []map[string]interface{}{}
+	for _, chart := range charts {
+		view := newVegaLiteSpec(chart)
+		delete(view, "$schema")
+		views = append(views, view)
+	}
+
+	return map[string]interface{}{
+		"$schema": vegaLiteSchema,
+		"title":   title,
+		"vconcat": views,
+	}
+}
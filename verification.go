@@ -0,0 +1,102 @@
+package buoyfinder
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+const (
+	defaultVerificationDays = 7
+	maxVerificationDays     = 30
+)
+
+// How the model forecast for the station's location held up against what
+// the station measured, in metric. Bias is the mean of the forecast minus
+// the observation.
+type ForecastVerification struct {
+	StationID string
+	Source    string
+	Days      int
+	Pairs     int
+	Readings  map[string]ReadingComparison
+}
+
+func forecastVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+	days := parseChartSpan(r, "days", defaultVerificationDays, maxVerificationDays)
+
+	buoy, buoyErr := fetchBuoyWithID(ctx, client, stationID)
+	if buoyErr != nil {
+		writeErrorResponse(w, r, http.StatusNotFound, buoyErr)
+		return
+	} else if buoy.Location == nil {
+		writeErrorResponse(w, r, http.StatusNotFound, errors.New("Station "+stationID+" has no location"))
+		return
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	observations, observationsErr := fetchStandardObservationsSince(client, stationID, since)
+	if observationsErr != nil {
+		writeErrorResponse(w, r, http.StatusBadGateway, observationsErr)
+		return
+	}
+	forecast, forecastErr := fetchMarineForecastHours(client, *buoy.Location, days)
+	if forecastErr != nil {
+		writeErrorResponse(w, r, http.StatusBadGateway, forecastErr)
+		return
+	}
+
+	verification := verifyForecast(observations, forecast)
+	verification.StationID, verification.Days = stationID, days
+	writeDataResponse(w, r, client, verification)
+}
+
+// Pairs each observation with the forecast hour it falls in. The model's
+// wave period is a mean period, so it is checked against the average period.
+func verifyForecast(observations []surfnerd.BuoyDataItem, forecast []ForecastConditions) ForecastVerification {
+	verification := ForecastVerification{Readings: map[string]ReadingComparison{}}
+	hours := map[int64]ForecastConditions{}
+	for _, hour := range forecast {
+		hours[hour.Date.Unix()] = hour
+		verification.Source = hour.Source
+	}
+
+	heightsObserved, heightsForecast := []float64{}, []float64{}
+	periodsObserved, periodsForecast := []float64{}, []float64{}
+	for _, observation := range observations {
+		hour, ok := hours[observation.Date.Round(time.Hour).Unix()]
+		if !ok || hour.Date.After(time.Now()) {
+			continue
+		}
+		verification.Pairs++
+
+		if hour.WaveHeight != nil && isValidReading(observation.WaveSummary.WaveHeight, missingHeightMarker) {
+			heightsObserved = append(heightsObserved, observation.WaveSummary.WaveHeight)
+			heightsForecast = append(heightsForecast, *hour.WaveHeight)
+		}
+		if hour.WavePeriod != nil && isValidReading(observation.AveragePeriod, missingPeriodMarker) {
+			periodsObserved = append(periodsObserved, observation.AveragePeriod)
+			periodsForecast = append(periodsForecast, *hour.WavePeriod)
+		}
+	}
+
+	if len(heightsObserved) > 0 {
+		verification.Readings["WaveHeight"] = compareReadings(heightsObserved, heightsForecast)
+	}
+	if len(periodsObserved) > 0 {
+		verification.Readings["AveragePeriod"] = compareReadings(periodsObserved, periodsForecast)
+	}
+	return verification
+}
@@ -0,0 +1,124 @@
+package buoyfinder
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	"google.golang.org/appengine/log"
+)
+
+// Skill and fulfillment requests are a few kilobytes
+const maxVoiceRequestSize = 64 << 10
+
+// The parts of an Alexa skill request and a Dialogflow fulfillment request
+// that carry the place being asked about
+type voiceRequest struct {
+	Request *struct {
+		Type      string `json:"type"`
+		Timestamp string `json:"timestamp"`
+		Intent    struct {
+			Slots map[string]struct {
+				Value string `json:"value"`
+			} `json:"slots"`
+		} `json:"intent"`
+	} `json:"request"`
+	QueryResult *struct {
+		Parameters map[string]interface{} `json:"parameters"`
+	} `json:"queryResult"`
+}
+
+// Fulfills "what's the surf at ..." for Alexa and Google Assistant. The place
+// comes from a slot or parameter called location.
+func voiceWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	// The Alexa signature covers the exact bytes of the body
+	body, readErr := ioutil.ReadAll(io.LimitReader(r.Body, maxVoiceRequestSize))
+	if readErr != nil {
+		http.Error(w, readErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	request := voiceRequest{}
+	if decodeErr := json.Unmarshal(body, &request); decodeErr != nil {
+		http.Error(w, decodeErr.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if isAlexaRequest(r) {
+		timestamp := ""
+		if request.Request != nil {
+			timestamp = request.Request.Timestamp
+		}
+		if verifyErr := verifyAlexaRequest(client, r, body, timestamp); verifyErr != nil {
+			log.Warningf(ctx, "Rejected an Alexa request: %v", verifyErr)
+			http.Error(w, verifyErr.Error(), http.StatusForbidden)
+			return
+		}
+	} else {
+		verified, secretErr := verifyDialogflowRequest(ctx, r)
+		if secretErr != nil {
+			log.Errorf(ctx, "The Dialogflow credentials are not set up: %v", secretErr)
+			http.Error(w, "Dialogflow is not set up", http.StatusServiceUnavailable)
+			return
+		}
+		if !verified {
+			http.Error(w, "Invalid credentials", http.StatusForbidden)
+			return
+		}
+	}
+
+	place := ""
+	if request.Request != nil {
+		for name, slot := range request.Request.Intent.Slots {
+			if name == "location" || name == "Location" {
+				place = slot.Value
+			}
+		}
+	} else if request.QueryResult != nil {
+		place, _ = request.QueryResult.Parameters["location"].(string)
+	}
+
+	speech := ""
+	if request.Request != nil && request.Request.Type == "LaunchRequest" {
+		speech = "Ask me about the surf at a spot or buoy."
+	} else if report, reportErr := fetchPlaceReport(ctx, client, place); reportErr == errPlaceNotFound {
+		speech = "Sorry, I could not find a spot or buoy called " + place + "."
+	} else if reportErr != nil {
+		log.Errorf(ctx, "Voice report for %q failed: %v", place, reportErr)
+		speech = "Sorry, the buoys are not answering right now."
+	} else {
+		speech = report
+	}
+
+	var response interface{}
+	if request.Request != nil {
+		response = map[string]interface{}{
+			"version": "1.0",
+			"response": map[string]interface{}{
+				"outputSpeech": map[string]string{
+					"type": "PlainText",
+					"text": speech,
+				},
+				"shouldEndSession": true,
+			},
+		}
+	} else {
+		response = map[string]string{"fulfillmentText": speech}
+	}
+
+	encoded, encodeErr := json.Marshal(response)
+	if encodeErr != nil {
+		http.Error(w, encodeErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(encoded)
+}
@@ -0,0 +1,150 @@
+package buoyfinder
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// The basic auth credentials, as user:password, the Dialogflow fulfillment
+// was set up with
+const dialogflowSecretKey = "dialogflow"
+
+// Alexa rejects skills that answer requests signed more than this long ago,
+// which stops captured requests from being replayed
+const alexaTimestampTolerance = 150 * time.Second
+
+const alexaCertificateHost = "s3.amazonaws.com"
+const alexaCertificatePath = "/echo.api/"
+const alexaCertificateName = "echo-api.amazon.com"
+const maxAlexaCertificateSize = 64 << 10
+
+var errInvalidAlexaSignature = errors.New("Invalid Alexa signature")
+
+// The certificate chains by url. Amazon rotates them rarely, so they are kept
+// for the life of the instance and checked for expiry on every request.
+var alexaCertificates struct {
+	sync.Mutex
+	chains map[string][]*x509.Certificate
+}
+
+func isAlexaRequest(r *http.Request) bool {
+	return r.Header.Get("SignatureCertChainUrl") != ""
+}
+
+// Alexa signs the body with the certificate at SignatureCertChainUrl, which has
+// to be one of Amazon's, and the body carries the time it was signed
+func verifyAlexaRequest(client *http.Client, r *http.Request, body []byte, timestamp string) error {
+	signedAt, timestampErr := time.Parse(time.RFC3339, timestamp)
+	if timestampErr != nil || absDuration(time.Since(signedAt)) > alexaTimestampTolerance {
+		return errors.New("The Alexa request is too old")
+	}
+
+	signature, signatureErr := base64.StdEncoding.DecodeString(r.Header.Get("Signature-256"))
+	if signatureErr != nil || len(signature) == 0 {
+		return errInvalidAlexaSignature
+	}
+
+	chain, chainErr := fetchAlexaCertificateChain(client, r.Header.Get("SignatureCertChainUrl"))
+	if chainErr != nil {
+		return chainErr
+	}
+	publicKey, ok := chain[0].PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errInvalidAlexaSignature
+	}
+
+	digest := sha256.Sum256(body)
+	if rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature) != nil {
+		return errInvalidAlexaSignature
+	}
+	return nil
+}
+
+func fetchAlexaCertificateChain(client *http.Client, chainURL string) ([]*x509.Certificate, error) {
+	if !isAlexaCertificateURL(chainURL) {
+		return nil, errors.New("The Alexa certificate url is not Amazon's")
+	}
+
+	alexaCertificates.Lock()
+	defer alexaCertificates.Unlock()
+	chain, cached := alexaCertificates.chains[chainURL]
+	if !cached {
+		resp, fetchErr := client.Get(chainURL)
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		defer resp.Body.Close()
+		contents, readErr := readUpstreamBody(resp, maxAlexaCertificateSize)
+		if readErr != nil {
+			return nil, readErr
+		}
+
+		for block, rest := pem.Decode(contents); block != nil; block, rest = pem.Decode(rest) {
+			certificate, parseErr := x509.ParseCertificate(block.Bytes)
+			if parseErr != nil {
+				return nil, parseErr
+			}
+			chain = append(chain, certificate)
+		}
+		if len(chain) == 0 {
+			return nil, errors.New("The Alexa certificate chain is empty")
+		}
+	}
+
+	// Verifying also checks the certificates have not expired since they were
+	// cached
+	intermediates := x509.NewCertPool()
+	for _, certificate := range chain[1:] {
+		intermediates.AddCert(certificate)
+	}
+	if _, verifyErr := chain[0].Verify(x509.VerifyOptions{DNSName: alexaCertificateName, Intermediates: intermediates}); verifyErr != nil {
+		return nil, verifyErr
+	}
+
+	if alexaCertificates.chains == nil {
+		alexaCertificates.chains = map[string][]*x509.Certificate{}
+	}
+	alexaCertificates.chains[chainURL] = chain
+	return chain, nil
+}
+
+// Only https on the default port of s3.amazonaws.com under /echo.api/, with
+// the dot segments resolved first
+func isAlexaCertificateURL(chainURL string) bool {
+	parsed, parseErr := url.Parse(chainURL)
+	if parseErr != nil || !strings.EqualFold(parsed.Scheme, "https") || !strings.EqualFold(parsed.Hostname(), alexaCertificateHost) {
+		return false
+	}
+	if parsed.Port() != "" && parsed.Port() != "443" {
+		return false
+	}
+	return strings.HasPrefix(path.Clean(parsed.Path), alexaCertificatePath)
+}
+
+// Dialogflow sends the basic auth credentials the fulfillment was set up with
+func verifyDialogflowRequest(ctx context.Context, r *http.Request) (bool, error) {
+	credentials, secretErr := fetchSecret(ctx, dialogflowSecretKey)
+	if secretErr != nil {
+		return false, secretErr
+	}
+
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return false, nil
+	}
+	return subtle.ConstantTimeCompare(credentials, []byte(user+":"+password)) == 1, nil
+}
@@ -0,0 +1,96 @@
+package buoyfinder
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+const defaultWaterTemperatureDays = 7
+const maxWaterTemperatureDays = 30
+
+// Charts the water temperature in fahrenheit, or celsius with ?units=metric
+func waterTemperatureChartHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+	days := parseChartSpan(r, "days", defaultWaterTemperatureDays, maxWaterTemperatureDays)
+	metric := r.URL.Query().Get("units") == UnitsMetric
+
+	if wantsVegaSpec(r) {
+		writeVegaSpec(w, r, func() (vegaChart, error) {
+			observations, fetchErr := fetchStandardObservationsSince(client, stationID, time.Now().AddDate(0, 0, -days))
+			if fetchErr != nil {
+				return vegaChart{}, fetchErr
+			}
+			return waterTemperatureLines(stationID, days, observations, metric), nil
+		})
+		return
+	}
+
+	cacheKey := "watertemp:" + stationID + ":" + strconv.Itoa(days) + ":" + strconv.FormatBool(metric)
+	writeCachedChart(ctx, w, r, client, cacheKey, vars["format"], func() (Chart, error) {
+		observations, fetchErr := fetchStandardObservationsSince(client, stationID, time.Now().AddDate(0, 0, -days))
+		if fetchErr != nil {
+			return Chart{}, fetchErr
+		}
+		options, optionsErr := waterTemperatureChartOptions(stationID, days, observations, metric)
+		if optionsErr != nil {
+			return Chart{}, optionsErr
+		}
+		lines := waterTemperatureLines(stationID, days, observations, metric)
+		return Chart{Options: options, Lines: &lines}, nil
+	})
+}
+
+func waterTemperatureLines(stationID string, days int, observations []surfnerd.BuoyDataItem, metric bool) vegaChart {
+	return vegaChart{
+		Title:    "Station " + stationID + ": Water Temperature",
+		Subtitle: "Last " + strconv.Itoa(days) + " days",
+		Series:   []ChartSeries{waterTemperatureSeries(observations, metric)},
+	}
+}
+
+func waterTemperatureSeries(observations []surfnerd.BuoyDataItem, metric bool) ChartSeries {
+	series := ChartSeries{Name: "Water Temperature", XTitle: "Time", YTitle: "Water Temperature (°F)", Points: [][]float64{}}
+	if metric {
+		series.YTitle = "Water Temperature (°C)"
+	}
+
+	for index := len(observations) - 1; index >= 0; index-- {
+		item := observations[index]
+		// The readings are checked in celsius, the way ndbc reports them, before
+		// any conversion
+		if !isValidReading(item.WaterTemperature, missingTemperatureMarker) {
+			continue
+		}
+
+		temperature := item.WaterTemperature
+		if !metric {
+			temperature = temperature*9.0/5.0 + 32.0
+		}
+		series.Points = append(series.Points, []float64{float64(item.Date.Unix() * 1000), ToFixedPoint(temperature, 1)})
+	}
+	return series
+}
+
+func waterTemperatureChartOptions(stationID string, days int, observations []surfnerd.BuoyDataItem, metric bool) (string, error) {
+	series := waterTemperatureSeries(observations, metric)
+	if len(series.Points) == 0 {
+		return "", errors.New("Station " + stationID + " has no water temperature observations")
+	}
+
+	subtitle := "Last " + strconv.Itoa(days) + " days"
+
+	return "{chart: {type: 'line', width: 600}, navigation: {buttonOptions: {enabled: false}}, title: {text: 'Station " + stationID + ": Water Temperature', style: {font: '10px Helvetica, sans-serif'}}, subtitle: {text: '" + subtitle + "', style: {font: '8px Helvetica, sans-serif'}}, legend: {enabled: false}, credits: {enabled: false}, xAxis: {type: 'datetime', labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1}, yAxis: {labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1, title: {text: '" + series.YTitle + "'}}, plotOptions: {series: {shadow: false, marker: {enabled: false}}}, series: [{type: 'line', name: 'Water Temperature', data: " + highchartsPoints(series.Points) + "}]};", nil
+}
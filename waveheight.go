@@ -0,0 +1,136 @@
+package buoyfinder
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+const defaultWaveHeightChartHours = 48
+
+// Wave heights in feet at or above this get the last color of the gradient
+const waveHeightColorMax = 15.0
+
+// The step in feet between the color bands of the wave height chart
+const waveHeightColorStep = 1.0
+
+func waveHeightChartHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+	hours := parseChartSpan(r, "hours", defaultWaveHeightChartHours, maxHistoryHours)
+
+	// ?tide= co-plots the predicted tide at that tide station, except at lake
+	// buoys where there is no tide to plot
+	tideStation := r.URL.Query().Get("tide")
+	if tideStation != "" {
+		if buoy, buoyErr := fetchBuoyWithID(ctx, client, stationID); buoyErr == nil && buoy.Location != nil && !hasTides(*buoy.Location) {
+			tideStation = ""
+		}
+	}
+
+	palette := parsePalette(r)
+	if wantsVegaSpec(r) {
+		writeVegaSpec(w, r, func() (vegaChart, error) {
+			observations, fetchErr := fetchStandardObservationsSince(client, stationID, time.Now().Add(-time.Duration(hours)*time.Hour))
+			if fetchErr != nil {
+				return vegaChart{}, fetchErr
+			}
+			for index := range observations {
+				observations[index].ChangeUnits(surfnerd.English)
+			}
+			return waveHeightLines(stationID, observations, gradientPalettes[palette]()), nil
+		})
+		return
+	}
+
+	cacheKey := "waveheight:" + stationID + ":" + strconv.Itoa(hours) + ":" + palette + ":" + tideStation
+	writeCachedChart(ctx, w, r, client, cacheKey, vars["format"], func() (Chart, error) {
+		now := time.Now()
+		since := now.Add(-time.Duration(hours) * time.Hour)
+		observations, fetchErr := fetchStandardObservationsSince(client, stationID, since)
+		if fetchErr != nil {
+			return Chart{}, fetchErr
+		}
+		for index := range observations {
+			observations[index].ChangeUnits(surfnerd.English)
+		}
+
+		// The tide pane is only drawn by the export servers
+		if tideStation != "" {
+			tide, tideErr := fetchTidePredictions(client, tideStation, since, now)
+			if tideErr != nil {
+				return Chart{}, tideErr
+			}
+			return optionsChart(waveHeightChartOptions(stationID, observations, gradientPalettes[palette](), tide))
+		}
+
+		options, optionsErr := waveHeightChartOptions(stationID, observations, gradientPalettes[palette](), []TidePrediction{})
+		if optionsErr != nil {
+			return Chart{}, optionsErr
+		}
+		lines := waveHeightLines(stationID, observations, gradientPalettes[palette]())
+		return Chart{Options: options, Lines: &lines}, nil
+	})
+}
+
+func waveHeightLines(stationID string, observations []surfnerd.BuoyDataItem, gradient Gradient) vegaChart {
+	return vegaChart{
+		Title:  "Station " + stationID + ": Wave Height",
+		Series: []ChartSeries{waveHeightSeries(observations)},
+		Colors: []string{gradient.GetInterpolatedColorForFraction(0.5).Hex()},
+	}
+}
+
+// The wave height history in feet as an area over time, banded through the
+// gradient by height. The observations are expected newest first, the way ndbc
+// lists them. When there are tide predictions they are drawn in a pane of
+// their own beneath the wave heights.
+func waveHeightChartOptions(stationID string, observations []surfnerd.BuoyDataItem, gradient Gradient, tide []TidePrediction) (string, error) {
+	points := waveHeightSeries(observations).Points
+	values := highchartsPoints(points)
+
+	if len(points) == 0 {
+		return "", errors.New("Station " + stationID + " has no wave height observations")
+	}
+
+	height := "300"
+	yAxis := "{labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1, min: 0, title: {text: 'Wave Height (ft)'}}"
+	series := "{type: 'area', name: 'Wave Height', zones: " + waveHeightZones(gradient) + ", data: " + values + "}"
+	if len(tide) > 0 {
+		height = "450"
+		yAxis = "[{labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1, min: 0, height: '65%', title: {text: 'Wave Height (ft)'}}, {labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1, top: '70%', height: '30%', offset: 0, title: {text: 'Tide (ft)'}}]"
+		series += ", {type: 'spline', name: 'Tide', yAxis: 1, color: '#3288bd', data: " + highchartsPoints(tideSeries(tide).Points) + "}"
+	}
+
+	return "{chart: {width: 1200, height: " + height + "}, navigation: {buttonOptions: {enabled: false}}, title: {text: 'Station " + stationID + ": Wave Height', style: {font: '10px Helvetica, sans-serif'}}, legend: {enabled: false}, credits: {enabled: false}, xAxis: {type: 'datetime', labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1}, yAxis: " + yAxis + ", plotOptions: {series: {shadow: false, fillOpacity: 0.6, marker: {enabled: false}}}, series: [" + series + "]};", nil
+}
+
+// The gradient color the wave height chart uses for a height in feet
+func waveHeightColor(gradient Gradient, height float64) colorful.Color {
+	return gradient.GetInterpolatedColorForFraction(math.Min(math.Max(height, 0)/waveHeightColorMax, 1.0))
+}
+
+// Highcharts zones coloring each band of wave height the way the gradient does
+// everywhere else
+func waveHeightZones(gradient Gradient) string {
+	zones := "["
+	for height := waveHeightColorStep; height <= waveHeightColorMax; height += waveHeightColorStep {
+		color := gradient.GetInterpolatedColorForFraction((height - waveHeightColorStep) / waveHeightColorMax)
+		zones += "{value: " + strconv.FormatFloat(height, 'f', 1, 64) + ", color: " + chartColor(color, 1.0) + "},"
+	}
+	zones += "{color: " + chartColor(gradient.GetInterpolatedColorForFraction(1.0), 1.0) + "}]"
+	return zones
+}
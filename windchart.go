@@ -0,0 +1,86 @@
+package buoyfinder
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+const defaultWindChartHours = 24
+const maxWindChartHours = 72
+
+// Charts the wind speed and gusts in knots with direction barbs along the
+// bottom, over the last ?hours=
+func windChartHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+	hours := parseChartSpan(r, "hours", defaultWindChartHours, maxWindChartHours)
+
+	cacheKey := "wind:" + stationID + ":" + strconv.Itoa(hours)
+	writeCachedChart(ctx, w, r, client, cacheKey, vars["format"], func() (Chart, error) {
+		observations, fetchErr := fetchStandardObservationsSince(client, stationID, time.Now().Add(-time.Duration(hours)*time.Hour))
+		if fetchErr != nil {
+			return Chart{}, fetchErr
+		}
+		return optionsChart(windChartOptions(stationID, hours, observations))
+	})
+}
+
+func windChartOptions(stationID string, hours int, observations []surfnerd.BuoyDataItem) (string, error) {
+	speeds := "["
+	gusts := "["
+	barbs := "["
+	count := 0
+	for index := len(observations) - 1; index >= 0; index-- {
+		item := observations[index]
+		if !isValidReading(item.WindSpeed, missingSpeedMarker) {
+			continue
+		}
+
+		if count > 0 {
+			speeds += ","
+			gusts += ","
+		}
+		date := strconv.FormatInt(item.Date.Unix()*1000, 10)
+		speed := strconv.FormatFloat(item.WindSpeed*metersPerSecondToKnots, 'f', 1, 64)
+		speeds += "[" + date + "," + speed + "]"
+
+		gust := "null"
+		if isValidReading(item.WindGust, missingSpeedMarker) {
+			gust = strconv.FormatFloat(item.WindGust*metersPerSecondToKnots, 'f', 1, 64)
+		}
+		gusts += "[" + date + "," + gust + "]"
+
+		// Barbs need a direction, and the highcharts windbarb series takes the
+		// speed in meters per second
+		if isValidReading(item.WindDirection, missingDirectionMarker) {
+			if barbs != "[" {
+				barbs += ","
+			}
+			barbs += "[" + date + "," + strconv.FormatFloat(item.WindSpeed, 'f', 1, 64) + "," + strconv.FormatFloat(item.WindDirection, 'f', 0, 64) + "]"
+		}
+		count++
+	}
+	speeds += "]"
+	gusts += "]"
+	barbs += "]"
+
+	if count == 0 {
+		return "", errors.New("Station " + stationID + " has no wind observations")
+	}
+
+	subtitle := "Last " + strconv.Itoa(hours) + " hours"
+
+	return "{chart: {width: 600}, navigation: {buttonOptions: {enabled: false}}, title: {text: 'Station " + stationID + ": Wind', style: {font: '10px Helvetica, sans-serif'}}, subtitle: {text: '" + subtitle + "', style: {font: '8px Helvetica, sans-serif'}}, credits: {enabled: false}, xAxis: {type: 'datetime', offset: 40, labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1}, yAxis: {labels: {style: {fontWeight: 'bold', fontSize: '13px'}}, gridLineWidth: 1, min: 0, title: {text: 'Wind Speed (kts)'}}, plotOptions: {series: {shadow: false, marker: {enabled: false}}}, series: [{type: 'windbarb', name: 'Direction', data: " + barbs + ", showInLegend: false}, {type: 'line', name: 'Speed', data: " + speeds + "}, {type: 'line', name: 'Gust', dashStyle: 'ShortDash', data: " + gusts + "}]};", nil
+}
@@ -0,0 +1,115 @@
+package buoyfinder
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mpiannucci/surfnerd"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+)
+
+const metersPerSecondToKnots = 1.94384
+
+const defaultWindRoseDays = 7
+
+// The lower edge of each wind speed band in knots
+var windRoseSpeedBands = []float64{0, 5, 10, 15, 20, 25}
+
+func windRoseChartHandler(w http.ResponseWriter, r *http.Request) {
+	ctxParent := appengine.NewContext(r)
+	ctx, _ := context.WithTimeout(ctxParent, 20*time.Second)
+	client := newFetchClient(ctx)
+
+	vars := mux.Vars(r)
+	stationID := strings.ToUpper(vars["station"])
+
+	days := parseChartSpan(r, "days", defaultWindRoseDays, maxHistoryHours/24)
+
+	palette := parsePalette(r)
+	cacheKey := "windrose:" + stationID + ":" + strconv.Itoa(days) + ":" + palette
+	writeCachedChart(ctx, w, r, client, cacheKey, vars["format"], func() (Chart, error) {
+		observations, fetchErr := fetchStandardObservationsSince(client, stationID, time.Now().AddDate(0, 0, -days))
+		if fetchErr != nil {
+			return Chart{}, fetchErr
+		}
+		return optionsChart(windRoseChartOptions(stationID, days, observations, gradientPalettes[palette]()))
+	})
+}
+
+// Bins the observations into 16 compass directions and the speed bands, as the
+// percentage of observations that fell in each bin
+func windRoseFrequencies(observations []surfnerd.BuoyDataItem) ([][]float64, int) {
+	frequencies := make([][]float64, len(windRoseSpeedBands))
+	for band := range frequencies {
+		frequencies[band] = make([]float64, len(compassPoints))
+	}
+
+	total := 0
+	for _, item := range observations {
+		if !isValidReading(item.WindDirection, missingDirectionMarker) || !isValidReading(item.WindSpeed, missingSpeedMarker) {
+			continue
+		}
+
+		speed := item.WindSpeed * metersPerSecondToKnots
+
+		band := 0
+		for index, lower := range windRoseSpeedBands {
+			if speed >= lower {
+				band = index
+			}
+		}
+		frequencies[band][compassPointIndex(item.WindDirection)]++
+		total++
+	}
+
+	if total > 0 {
+		for band := range frequencies {
+			for sector := range frequencies[band] {
+				frequencies[band][sector] = ToFixedPoint(frequencies[band][sector]*100.0/float64(total), 2)
+			}
+		}
+	}
+	return frequencies, total
+}
+
+func windRoseChartOptions(stationID string, days int, observations []surfnerd.BuoyDataItem, gradient Gradient) (string, error) {
+	frequencies, total := windRoseFrequencies(observations)
+	if total == 0 {
+		return "", errors.New("Station " + stationID + " has no wind observations")
+	}
+
+	series := "["
+	for band, lower := range windRoseSpeedBands {
+		if band > 0 {
+			series += ","
+		}
+
+		name := strconv.FormatFloat(lower, 'f', 0, 64) + "+ kts"
+		if band < len(windRoseSpeedBands)-1 {
+			name = strconv.FormatFloat(lower, 'f', 0, 64) + "-" + strconv.FormatFloat(windRoseSpeedBands[band+1], 'f', 0, 64) + " kts"
+		}
+
+		values := "["
+		for sector, frequency := range frequencies[band] {
+			if sector > 0 {
+				values += ","
+			}
+			values += strconv.FormatFloat(frequency, 'f', 2, 64)
+		}
+		values += "]"
+
+		color := gradient.GetInterpolatedColorForFraction(float64(band) / float64(len(windRoseSpeedBands)-1))
+		series += "{type: 'column', name: '" + name + "', color: " + chartColor(color, 0.9) + ", data: " + values + "}"
+	}
+	series += "]"
+
+	categories := "['" + strings.Join(compassPoints, "','") + "']"
+	subtitle := "Last " + strconv.Itoa(days) + " days, " + strconv.Itoa(total) + " observations"
+
+	return "{chart: {polar: true, type: 'column', width: 600, height: 600}, navigation: {buttonOptions: {enabled: false}}, title: {text: 'Station " + stationID + ": Wind Rose', style: {font: '10px Helvetica, sans-serif'}}, subtitle: {text: '" + subtitle + "', style: {font: '8px Helvetica, sans-serif'}}, credits: {enabled: false}, legend: {align: 'right', verticalAlign: 'top', layout: 'vertical', y: 100}, pane: {size: '85%'}, xAxis: {categories: " + categories + ", tickmarkPlacement: 'on'}, yAxis: {min: 0, endOnTick: false, showLastLabel: true, reversedStacks: false, title: {text: 'Frequency (%)'}, labels: {formatter: function(){return this.value + '%'}}}, plotOptions: {series: {stacking: 'normal', shadow: false, groupPadding: 0, pointPlacement: 'on'}}, series: " + series + "};", nil
+}
@@ -0,0 +1,143 @@
+package buoyfinder
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+const (
+	WindWaveMethodJONSWAP = "jonswap"
+	WindWaveMethodSMB     = "smb"
+)
+
+const (
+	WindWaveLimitFetch          = "fetch"
+	WindWaveLimitDuration       = "duration"
+	WindWaveLimitFullyDeveloped = "fully developed"
+)
+
+// The wind sea a steady wind raises over open water, for lakes and bays
+// without a buoy. Speeds and fetches are in m/s and km, or in knots and
+// nautical miles for english units, durations in hours.
+type WindWaveEstimate struct {
+	Method    string
+	Units     string
+	WindSpeed float64
+	Duration  float64
+	Fetch     float64
+	// Whichever of the fetch or the duration the growth ran out of first
+	Limit      string
+	WaveHeight float64
+	Period     float64
+	// How long the wind has to blow for the sea to fill the whole fetch
+	MinimumDuration float64
+}
+
+// Estimates the wind sea from ?speed=, ?duration=, and ?fetch=.
+// ?method=smb uses the older Sverdrup-Munk-Bretschneider curves instead of
+// the JONSWAP growth laws.
+func windWaveHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	speed, speedErr := strconv.ParseFloat(query.Get("speed"), 64)
+	duration, durationErr := strconv.ParseFloat(query.Get("duration"), 64)
+	fetch, fetchErr := strconv.ParseFloat(query.Get("fetch"), 64)
+	if speedErr != nil || durationErr != nil || fetchErr != nil || speed <= 0 || duration <= 0 || fetch <= 0 {
+		writeErrorResponse(w, r, http.StatusBadRequest, errors.New("The wind speed, duration, and fetch must all be positive numbers"))
+		return
+	}
+
+	method := WindWaveMethodJONSWAP
+	if query.Get("method") == WindWaveMethodSMB {
+		method = WindWaveMethodSMB
+	}
+	units := parseTriggerUnits(query.Get("units"))
+
+	estimate := WindWaveEstimate{Method: method, Units: units, WindSpeed: speed, Duration: duration, Fetch: fetch}
+	speedMetersPerSecond, fetchMeters := speed, fetch*1000.0
+	if units == UnitsEnglish {
+		speedMetersPerSecond = speed / metersPerSecondToKnots
+		fetchMeters = fetch * kmPerNauticalMile * 1000.0
+	}
+
+	height, period, limit, minimumDuration := 0.0, 0.0, "", 0.0
+	if method == WindWaveMethodSMB {
+		height, period, limit, minimumDuration = smbWindWaves(speedMetersPerSecond, duration*3600.0, fetchMeters)
+	} else {
+		height, period, limit, minimumDuration = jonswapWindWaves(speedMetersPerSecond, duration*3600.0, fetchMeters)
+	}
+	if units == UnitsEnglish {
+		height *= metersToFeet
+	}
+
+	estimate.WaveHeight = ToFixedPoint(height, 2)
+	estimate.Period = ToFixedPoint(period, 1)
+	estimate.Limit = limit
+	estimate.MinimumDuration = ToFixedPoint(minimumDuration/3600.0, 1)
+	writeDataResponse(w, r, nil, estimate)
+}
+
+// The Shore Protection Manual's JONSWAP growth laws for deep water, on the
+// wind stress factor rather than the raw wind. Returns the significant
+// height in meters, the peak period, the limit, and the minimum duration in
+// seconds.
+func jonswapWindWaves(speed, duration, fetch float64) (float64, float64, string, float64) {
+	adjusted := 0.71 * math.Pow(speed, 1.23)
+	dimensionless := func(fetch float64) float64 {
+		return gravity * fetch / (adjusted * adjusted)
+	}
+
+	// The growth stops at the fully developed sea, and a short blow only
+	// grows the sea as far as the fetch it has had time to cover
+	minimumDuration := 68.8 * math.Pow(dimensionless(fetch), 2.0/3.0) * adjusted / gravity
+	limit := WindWaveLimitFetch
+	if duration < minimumDuration {
+		fetch = adjusted * adjusted / gravity * math.Pow(gravity*duration/(68.8*adjusted), 1.5)
+		limit = WindWaveLimitDuration
+	}
+
+	height := 1.6e-3 * math.Sqrt(dimensionless(fetch)) * adjusted * adjusted / gravity
+	period := 2.857e-1 * math.Pow(dimensionless(fetch), 1.0/3.0) * adjusted / gravity
+	if fullyDeveloped := 2.433e-1 * adjusted * adjusted / gravity; height >= fullyDeveloped {
+		height, period, limit = fullyDeveloped, 8.134*adjusted/gravity, WindWaveLimitFullyDeveloped
+		minimumDuration = math.Min(minimumDuration, 7.15e4*adjusted/gravity)
+	}
+	return height, period, limit, minimumDuration
+}
+
+// The Sverdrup-Munk-Bretschneider curves for deep water. They have no closed
+// form for the duration, so the fetch a short blow covers is found by
+// bisection.
+func smbWindWaves(speed, duration, fetch float64) (float64, float64, string, float64) {
+	dimensionless := func(fetch float64) float64 {
+		return gravity * fetch / (speed * speed)
+	}
+	minimumDurationFor := func(fetch float64) float64 {
+		x := math.Log(dimensionless(fetch))
+		return 6.5882 * math.Exp(math.Sqrt(math.Max(0.0161*x*x-0.3692*x+2.2024, 0))+0.8798*x) * speed / gravity
+	}
+
+	minimumDuration := minimumDurationFor(fetch)
+	limit := WindWaveLimitFetch
+	if duration < minimumDuration {
+		low, high := 1.0, fetch
+		for i := 0; i < 60; i++ {
+			middle := (low + high) / 2
+			if minimumDurationFor(middle) < duration {
+				low = middle
+			} else {
+				high = middle
+			}
+		}
+		fetch = low
+		limit = WindWaveLimitDuration
+	}
+
+	height := 0.283 * math.Tanh(0.0125*math.Pow(dimensionless(fetch), 0.42)) * speed * speed / gravity
+	period := 2 * math.Pi * 1.2 * math.Tanh(0.077*math.Pow(dimensionless(fetch), 0.25)) * speed / gravity
+	if height >= 0.99*0.283*speed*speed/gravity {
+		limit = WindWaveLimitFullyDeveloped
+	}
+	return height, period, limit, minimumDuration
+}